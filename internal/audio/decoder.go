@@ -0,0 +1,181 @@
+package audio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// Decoder turns the bytes of one container format into a PCMSource.
+type Decoder interface {
+	// Name is the short format name shown to the user, e.g. "flac".
+	Name() string
+	// Sniff reports whether header (with any leading ID3v2 tag removed) belongs to this format.
+	Sniff(header []byte) bool
+	// Decode opens a PCM stream over the whole file.
+	Decode(r io.ReadSeeker) (PCMSource, error)
+}
+
+// decoders holds the registered decoders in sniffing order. MP3 goes last because
+// a bare MPEG frame sync is the weakest signature.
+var decoders = []Decoder{
+	wavDecoder{},
+	aiffDecoder{},
+	flacDecoder{},
+	vorbisDecoder{},
+	mp3Decoder{},
+}
+
+// RegisterDecoder adds a decoder that is tried before the built-in ones.
+func RegisterDecoder(d Decoder) {
+	decoders = append([]Decoder{d}, decoders...)
+}
+
+// DetectDecoder sniffs the file header and returns the decoder that understands it.
+func DetectDecoder(data []byte) (Decoder, error) {
+	header := data[id3v2Size(data):]
+	for _, d := range decoders {
+		if d.Sniff(header) {
+			return d, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported audio format")
+}
+
+// DecodeBytes detects the format of data and opens a PCMSource over it.
+func DecodeBytes(data []byte) (PCMSource, string, error) {
+	d, err := DetectDecoder(data)
+	if err != nil {
+		return nil, "", err
+	}
+	src, err := d.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, d.Name(), fmt.Errorf("%s: %w", d.Name(), err)
+	}
+	return src, d.Name(), nil
+}
+
+// id3v2Size returns the length of an ID3v2 tag at the start of data, or 0 if there is none.
+func id3v2Size(data []byte) int {
+	if size := id3v2Len(data); size < len(data) {
+		return size
+	}
+	return len(data)
+}
+
+// id3v2Len returns the length declared by an ID3v2 tag header at the start of data, which
+// may run past the end of data, or 0 if there is none.
+func id3v2Len(data []byte) int {
+	if len(data) < 10 || string(data[:3]) != "ID3" {
+		return 0
+	}
+	// The tag size is a 28-bit "syncsafe" integer: 7 bits per byte.
+	size := int(data[6]&0x7f)<<21 | int(data[7]&0x7f)<<14 | int(data[8]&0x7f)<<7 | int(data[9]&0x7f)
+	size += 10
+	if data[5]&0x10 != 0 {
+		size += 10 // footer
+	}
+	return size
+}
+
+// skipID3v2 advances r past a leading ID3v2 tag, if any.
+func skipID3v2(r io.ReadSeeker) error {
+	header := make([]byte, 10)
+	if _, err := io.ReadFull(r, header); err != nil {
+		_, serr := r.Seek(0, io.SeekStart)
+		return serr
+	}
+	_, err := r.Seek(int64(id3v2Len(header)), io.SeekStart)
+	return err
+}
+
+// blockSource serves PCMSource reads for decoders that produce whole blocks of samples.
+// next returns the next block of interleaved samples already scaled to 16 bits. seek
+// repositions the decoder at or before the requested frame and reports where it landed;
+// blockSource drops the difference so seeking stays sample-accurate.
+type blockSource struct {
+	sampleRate int
+	channels   int
+	length     int64
+
+	next func() ([]int16, error)
+	seek func(frame int64) (int64, error)
+
+	pending []byte
+	skip    int
+	err     error
+}
+
+func (s *blockSource) Read(p []byte) (int, error) {
+	for len(s.pending) == 0 {
+		if s.err != nil {
+			return 0, s.err
+		}
+		block, err := s.next()
+		s.err = err
+		if s.skip > 0 {
+			n := s.skip
+			if n > len(block) {
+				n = len(block)
+			}
+			block = block[n:]
+			s.skip -= n
+		}
+		s.pending = appendInt16LE(s.pending[:0], block)
+	}
+	n := copy(p, s.pending)
+	s.pending = s.pending[n:]
+	return n, nil
+}
+
+func (s *blockSource) SampleRate() int { return s.sampleRate }
+func (s *blockSource) Channels() int   { return s.channels }
+func (s *blockSource) Length() int64   { return s.length }
+
+func (s *blockSource) SeekFrame(frame int64) error {
+	if s.seek == nil {
+		return fmt.Errorf("source is not seekable")
+	}
+	if frame < 0 {
+		frame = 0
+	}
+	if s.length >= 0 && frame > s.length {
+		frame = s.length
+	}
+	start, err := s.seek(frame)
+	if err != nil {
+		return err
+	}
+	s.pending = s.pending[:0]
+	s.err = nil
+	s.skip = int(frame-start) * s.channels
+	return nil
+}
+
+// appendInt16LE encodes samples as little-endian bytes onto dst.
+func appendInt16LE(dst []byte, samples []int16) []byte {
+	for _, v := range samples {
+		dst = append(dst, byte(v), byte(uint16(v)>>8))
+	}
+	return dst
+}
+
+// clipFloat converts a float sample in [-1, 1] to 16 bits, clipping out-of-range values.
+func clipFloat(v float64) int16 {
+	v *= 32768
+	if v > 32767 {
+		return 32767
+	}
+	if v < -32768 {
+		return -32768
+	}
+	return int16(v)
+}
+
+// scaleInt converts a signed integer sample of the given bit depth to 16 bits.
+func scaleInt(v int32, bits int) int16 {
+	if bits > 16 {
+		return int16(v >> uint(bits-16))
+	}
+	return int16(v << uint(16-bits))
+}
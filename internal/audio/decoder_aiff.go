@@ -0,0 +1,134 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"gowav/pkg/utils"
+)
+
+// aiffDecoder decodes AIFF and uncompressed AIFF-C files.
+type aiffDecoder struct{}
+
+func (aiffDecoder) Name() string { return "aiff" }
+
+func (aiffDecoder) Sniff(header []byte) bool {
+	if !utils.HasMagic(header, "aiff") || len(header) < 12 {
+		return false
+	}
+	kind := string(header[8:12])
+	return kind == "AIFF" || kind == "AIFC"
+}
+
+func (aiffDecoder) Decode(r io.ReadSeeker) (PCMSource, error) {
+	if err := skipID3v2(r); err != nil {
+		return nil, err
+	}
+
+	form := make([]byte, 12)
+	if _, err := io.ReadFull(r, form); err != nil {
+		return nil, fmt.Errorf("read FORM header: %w", err)
+	}
+	if string(form[0:4]) != "FORM" {
+		return nil, fmt.Errorf("not an AIFF file")
+	}
+	isAIFC := string(form[8:12]) == "AIFC"
+
+	var (
+		format  rawFormat
+		frames  int64
+		haveFmt bool
+	)
+	chunk := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return nil, fmt.Errorf("no SSND chunk found")
+		}
+		id := string(chunk[0:4])
+		size := int64(binary.BigEndian.Uint32(chunk[4:8]))
+
+		switch id {
+		case "COMM":
+			if size > maxFormatChunk {
+				return nil, fmt.Errorf("COMM chunk too large (%d bytes)", size)
+			}
+			body := make([]byte, size)
+			if _, err := io.ReadFull(r, body); err != nil {
+				return nil, fmt.Errorf("read COMM chunk: %w", err)
+			}
+			f, n, err := parseAIFFCommon(body, isAIFC)
+			if err != nil {
+				return nil, err
+			}
+			format, frames, haveFmt = f, n, true
+			if size%2 == 1 {
+				r.Seek(1, io.SeekCurrent)
+			}
+
+		case "SSND":
+			if !haveFmt {
+				return nil, fmt.Errorf("SSND chunk before COMM chunk")
+			}
+			hdr := make([]byte, 8)
+			if _, err := io.ReadFull(r, hdr); err != nil {
+				return nil, fmt.Errorf("read SSND header: %w", err)
+			}
+			offset := int64(binary.BigEndian.Uint32(hdr[0:4]))
+			if _, err := r.Seek(offset, io.SeekCurrent); err != nil {
+				return nil, err
+			}
+			return newRawSource(r, format, frames*int64(format.blockAlign()))
+
+		default:
+			if _, err := r.Seek(size+size%2, io.SeekCurrent); err != nil {
+				return nil, fmt.Errorf("skip %q chunk: %w", id, err)
+			}
+		}
+	}
+}
+
+// parseAIFFCommon reads the COMM chunk and returns the sample format and frame count.
+func parseAIFFCommon(body []byte, isAIFC bool) (rawFormat, int64, error) {
+	if len(body) < 18 {
+		return rawFormat{}, 0, fmt.Errorf("COMM chunk too short")
+	}
+	f := rawFormat{
+		channels:   int(binary.BigEndian.Uint16(body[0:2])),
+		bits:       int(binary.BigEndian.Uint16(body[6:8])),
+		sampleRate: int(extendedToFloat(body[8:18])),
+		bigEndian:  true,
+	}
+	frames := int64(binary.BigEndian.Uint32(body[2:6]))
+
+	if isAIFC && len(body) >= 22 {
+		switch compression := string(body[18:22]); compression {
+		case "NONE", "twos":
+		case "sowt":
+			f.bigEndian = false
+		case "fl32", "FL32":
+			f.float, f.bits = true, 32
+		case "fl64", "FL64":
+			f.float, f.bits = true, 64
+		default:
+			return rawFormat{}, 0, fmt.Errorf("unsupported AIFF-C compression %q", compression)
+		}
+	}
+	return f, frames, f.validate()
+}
+
+// extendedToFloat decodes the 80-bit IEEE 754 extended float AIFF uses for its sample rate.
+func extendedToFloat(b []byte) float64 {
+	exp := int(binary.BigEndian.Uint16(b[0:2]))
+	mantissa := binary.BigEndian.Uint64(b[2:10])
+	sign := 1.0
+	if exp&0x8000 != 0 {
+		sign = -1
+		exp &= 0x7fff
+	}
+	if exp == 0 && mantissa == 0 {
+		return 0
+	}
+	return sign * math.Ldexp(float64(mantissa), exp-16383-63)
+}
@@ -0,0 +1,594 @@
+package audio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+
+	"gowav/pkg/utils"
+)
+
+// flacDecoder is a pure-Go decoder for native FLAC streams.
+type flacDecoder struct{}
+
+func (flacDecoder) Name() string { return "flac" }
+
+func (flacDecoder) Sniff(header []byte) bool {
+	return utils.HasMagic(header, "flac")
+}
+
+func (flacDecoder) Decode(r io.ReadSeeker) (PCMSource, error) {
+	if err := skipID3v2(r); err != nil {
+		return nil, err
+	}
+	start, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(r)
+
+	marker := make([]byte, 4)
+	if _, err := io.ReadFull(br, marker); err != nil || string(marker) != "fLaC" {
+		return nil, fmt.Errorf("missing fLaC marker")
+	}
+
+	info, metaSize, err := readFLACMetadata(br)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &flacStream{
+		info:       info,
+		src:        r,
+		buf:        br,
+		bits:       &bitReader{r: br},
+		firstFrame: start + 4 + metaSize,
+	}
+	length := int64(info.totalSamples)
+	if length == 0 {
+		length = -1
+	}
+
+	return &blockSource{
+		sampleRate: info.sampleRate,
+		channels:   info.channels,
+		length:     length,
+		next:       d.nextFrame,
+		seek:       d.seek,
+	}, nil
+}
+
+// flacStreamInfo holds the STREAMINFO fields that decoding needs, plus the optional seek table.
+type flacStreamInfo struct {
+	sampleRate    int
+	channels      int
+	bitsPerSample int
+	totalSamples  uint64
+	seekPoints    []flacSeekPoint
+}
+
+// flacSeekPoint maps a sample number to the byte offset of its frame, relative to the first frame.
+type flacSeekPoint struct {
+	sample uint64
+	offset int64
+}
+
+// readFLACMetadata parses STREAMINFO and SEEKTABLE, skips every other metadata block,
+// and returns the total size in bytes of the metadata blocks.
+func readFLACMetadata(r *bufio.Reader) (flacStreamInfo, int64, error) {
+	var info flacStreamInfo
+	var total int64
+	haveInfo := false
+	header := make([]byte, 4)
+
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return info, 0, fmt.Errorf("read metadata block header: %w", err)
+		}
+		last := header[0]&0x80 != 0
+		blockType := header[0] & 0x7f
+		size := int(header[1])<<16 | int(header[2])<<8 | int(header[3])
+		total += 4 + int64(size)
+
+		switch blockType {
+		case 0:
+			body := make([]byte, size)
+			if _, err := io.ReadFull(r, body); err != nil || size < 18 {
+				return info, 0, fmt.Errorf("invalid STREAMINFO block")
+			}
+			info.sampleRate = int(body[10])<<12 | int(body[11])<<4 | int(body[12])>>4
+			info.channels = int(body[12]>>1&0x07) + 1
+			info.bitsPerSample = int(body[12]&0x01)<<4 | int(body[13]>>4) + 1
+			info.totalSamples = uint64(body[13]&0x0f)<<32 |
+				uint64(body[14])<<24 | uint64(body[15])<<16 | uint64(body[16])<<8 | uint64(body[17])
+			haveInfo = true
+		case 3:
+			body := make([]byte, size)
+			if _, err := io.ReadFull(r, body); err != nil {
+				return info, 0, fmt.Errorf("invalid SEEKTABLE block")
+			}
+			for p := 0; p+18 <= len(body); p += 18 {
+				sample := binary.BigEndian.Uint64(body[p : p+8])
+				if sample == math.MaxUint64 {
+					continue // placeholder point
+				}
+				info.seekPoints = append(info.seekPoints, flacSeekPoint{
+					sample: sample,
+					offset: int64(binary.BigEndian.Uint64(body[p+8 : p+16])),
+				})
+			}
+		default:
+			if _, err := r.Discard(size); err != nil {
+				return info, 0, fmt.Errorf("skip metadata block: %w", err)
+			}
+		}
+
+		if last {
+			break
+		}
+	}
+
+	if !haveInfo {
+		return info, 0, fmt.Errorf("missing STREAMINFO block")
+	}
+	if info.sampleRate <= 0 {
+		return info, 0, fmt.Errorf("invalid sample rate %d", info.sampleRate)
+	}
+	return info, total, nil
+}
+
+// flacStream decodes audio frames one at a time.
+type flacStream struct {
+	info       flacStreamInfo
+	src        io.ReadSeeker
+	buf        *bufio.Reader
+	bits       *bitReader
+	firstFrame int64
+
+	channels [][]int32
+	out      []int16
+}
+
+// Channel assignments from the frame header beyond plain independent channels.
+const (
+	flacLeftSide  = 8
+	flacSideRight = 9
+	flacMidSide   = 10
+)
+
+// seek restarts decoding from the last seek point at or before frame and returns its sample number.
+func (d *flacStream) seek(frame int64) (int64, error) {
+	var point flacSeekPoint
+	for _, p := range d.info.seekPoints {
+		if int64(p.sample) > frame {
+			break
+		}
+		point = p
+	}
+	if _, err := d.src.Seek(d.firstFrame+point.offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	d.buf.Reset(d.src)
+	d.bits.cur, d.bits.n = 0, 0
+	return int64(point.sample), nil
+}
+
+// nextFrame decodes one FLAC frame into interleaved 16-bit samples.
+func (d *flacStream) nextFrame() ([]int16, error) {
+	br := d.bits
+	br.align()
+	if err := br.syncFrame(); err != nil {
+		return nil, err
+	}
+
+	blockSizeCode, _ := br.read(4)
+	rateCode, _ := br.read(4)
+	assignment, _ := br.read(4)
+	sizeCode, _ := br.read(3)
+	br.read(1)
+
+	// Frame or sample number, UTF-8 style variable length; only skipped.
+	first, err := br.read(8)
+	if err != nil {
+		return nil, unexpected(err)
+	}
+	for extra := bits.LeadingZeros8(^uint8(first)) - 1; extra > 0; extra-- {
+		br.read(8)
+	}
+
+	blockSize, err := flacBlockSize(br, blockSizeCode)
+	if err != nil {
+		return nil, err
+	}
+	switch rateCode {
+	case 12:
+		br.read(8)
+	case 13, 14:
+		br.read(16)
+	}
+	br.read(8) // header CRC-8
+
+	bps := d.info.bitsPerSample
+	switch sizeCode {
+	case 1:
+		bps = 8
+	case 2:
+		bps = 12
+	case 4:
+		bps = 16
+	case 5:
+		bps = 20
+	case 6:
+		bps = 24
+	case 7:
+		bps = 32
+	}
+
+	channels := int(assignment) + 1
+	if assignment >= flacLeftSide {
+		if assignment > flacMidSide {
+			return nil, fmt.Errorf("reserved channel assignment %d", assignment)
+		}
+		channels = 2
+	}
+
+	for len(d.channels) < channels {
+		d.channels = append(d.channels, nil)
+	}
+	for ch := 0; ch < channels; ch++ {
+		if cap(d.channels[ch]) < blockSize {
+			d.channels[ch] = make([]int32, blockSize)
+		}
+		d.channels[ch] = d.channels[ch][:blockSize]
+
+		// The side channel carries one extra bit.
+		chBps := bps
+		if (assignment == flacLeftSide || assignment == flacMidSide) && ch == 1 ||
+			assignment == flacSideRight && ch == 0 {
+			chBps++
+		}
+		if err := d.decodeSubframe(d.channels[ch], chBps); err != nil {
+			return nil, unexpected(err)
+		}
+	}
+
+	br.align()
+	if _, err := br.read(16); err != nil { // frame CRC-16
+		return nil, unexpected(err)
+	}
+
+	decorrelate(d.channels[:channels], assignment)
+
+	if cap(d.out) < blockSize*channels {
+		d.out = make([]int16, blockSize*channels)
+	}
+	out := d.out[:blockSize*channels]
+	for i := 0; i < blockSize; i++ {
+		for ch := 0; ch < channels; ch++ {
+			out[i*channels+ch] = scaleInt(d.channels[ch][i], bps)
+		}
+	}
+	return out, nil
+}
+
+// flacBlockSize resolves the 4-bit block size code, reading the explicit size when present.
+func flacBlockSize(br *bitReader, code uint64) (int, error) {
+	switch {
+	case code == 1:
+		return 192, nil
+	case code >= 2 && code <= 5:
+		return 576 << (code - 2), nil
+	case code == 6:
+		v, err := br.read(8)
+		return int(v) + 1, err
+	case code == 7:
+		v, err := br.read(16)
+		return int(v) + 1, err
+	case code >= 8:
+		return 256 << (code - 8), nil
+	}
+	return 0, fmt.Errorf("reserved block size code")
+}
+
+// decodeSubframe decodes one channel of a frame into dst.
+func (d *flacStream) decodeSubframe(dst []int32, bps int) error {
+	br := d.bits
+	header, err := br.read(8)
+	if err != nil {
+		return err
+	}
+	kind := header >> 1 & 0x3f
+
+	wasted := 0
+	if header&1 != 0 {
+		n, err := br.unary()
+		if err != nil {
+			return err
+		}
+		wasted = n + 1
+		bps -= wasted
+	}
+
+	switch {
+	case kind == 0:
+		v, err := br.signed(bps)
+		if err != nil {
+			return err
+		}
+		for i := range dst {
+			dst[i] = v
+		}
+	case kind == 1:
+		for i := range dst {
+			if dst[i], err = br.signed(bps); err != nil {
+				return err
+			}
+		}
+	case kind >= 8 && kind <= 12:
+		if err := d.decodeFixed(dst, int(kind-8), bps); err != nil {
+			return err
+		}
+	case kind >= 32:
+		if err := d.decodeLPC(dst, int(kind-31), bps); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("reserved subframe type %d", kind)
+	}
+
+	if wasted > 0 {
+		for i := range dst {
+			dst[i] <<= uint(wasted)
+		}
+	}
+	return nil
+}
+
+// fixedCoefficients are the predictors for fixed subframes of order 0 to 4.
+var fixedCoefficients = [][]int32{
+	{},
+	{1},
+	{2, -1},
+	{3, -3, 1},
+	{4, -6, 4, -1},
+}
+
+func (d *flacStream) decodeFixed(dst []int32, order, bps int) error {
+	var err error
+	for i := 0; i < order; i++ {
+		if dst[i], err = d.bits.signed(bps); err != nil {
+			return err
+		}
+	}
+	if err := d.decodeResidual(dst, order); err != nil {
+		return err
+	}
+	predict(dst, fixedCoefficients[order], 0)
+	return nil
+}
+
+func (d *flacStream) decodeLPC(dst []int32, order, bps int) error {
+	br := d.bits
+	var err error
+	for i := 0; i < order; i++ {
+		if dst[i], err = br.signed(bps); err != nil {
+			return err
+		}
+	}
+
+	precision, err := br.read(4)
+	if err != nil {
+		return err
+	}
+	if precision == 0x0f {
+		return fmt.Errorf("invalid LPC coefficient precision")
+	}
+	shift, err := br.signed(5)
+	if err != nil {
+		return err
+	}
+	if shift < 0 {
+		shift = 0
+	}
+	coeffs := make([]int32, order)
+	for i := range coeffs {
+		if coeffs[i], err = br.signed(int(precision) + 1); err != nil {
+			return err
+		}
+	}
+
+	if err := d.decodeResidual(dst, order); err != nil {
+		return err
+	}
+	predict(dst, coeffs, uint(shift))
+	return nil
+}
+
+// predict turns residuals after the warm-up samples into samples in place.
+func predict(dst []int32, coeffs []int32, shift uint) {
+	order := len(coeffs)
+	for i := order; i < len(dst); i++ {
+		var sum int64
+		for j, c := range coeffs {
+			sum += int64(c) * int64(dst[i-j-1])
+		}
+		dst[i] += int32(sum >> shift)
+	}
+}
+
+// decodeResidual reads the Rice-coded residual that follows the warm-up samples.
+func (d *flacStream) decodeResidual(dst []int32, order int) error {
+	br := d.bits
+	method, err := br.read(2)
+	if err != nil {
+		return err
+	}
+	paramBits, escape := 4, uint64(0x0f)
+	if method == 1 {
+		paramBits, escape = 5, 0x1f
+	} else if method > 1 {
+		return fmt.Errorf("reserved residual coding method")
+	}
+
+	partitionOrder, err := br.read(4)
+	if err != nil {
+		return err
+	}
+	partitions := 1 << partitionOrder
+	perPartition := len(dst) >> partitionOrder
+
+	i := order
+	for p := 0; p < partitions; p++ {
+		n := perPartition
+		if p == 0 {
+			n -= order
+		}
+		if n < 0 || i+n > len(dst) {
+			return fmt.Errorf("invalid residual partition")
+		}
+
+		param, err := br.read(uint(paramBits))
+		if err != nil {
+			return err
+		}
+		if param == escape {
+			raw, err := br.read(5)
+			if err != nil {
+				return err
+			}
+			for end := i + n; i < end; i++ {
+				if dst[i], err = br.signed(int(raw)); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		for end := i + n; i < end; i++ {
+			q, err := br.unary()
+			if err != nil {
+				return err
+			}
+			low, err := br.read(uint(param))
+			if err != nil {
+				return err
+			}
+			v := uint32(q)<<param | uint32(low)
+			dst[i] = int32(v>>1) ^ -int32(v&1)
+		}
+	}
+	return nil
+}
+
+// decorrelate restores left/right from the stereo decorrelation modes.
+func decorrelate(ch [][]int32, assignment uint64) {
+	switch assignment {
+	case flacLeftSide:
+		for i := range ch[0] {
+			ch[1][i] = ch[0][i] - ch[1][i]
+		}
+	case flacSideRight:
+		for i := range ch[0] {
+			ch[0][i] += ch[1][i]
+		}
+	case flacMidSide:
+		for i := range ch[0] {
+			mid := ch[0][i]<<1 | ch[1][i]&1
+			side := ch[1][i]
+			ch[0][i] = (mid + side) >> 1
+			ch[1][i] = (mid - side) >> 1
+		}
+	}
+}
+
+// unexpected turns a mid-frame EOF into io.ErrUnexpectedEOF so truncation is reported.
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// bitReader reads big-endian bit fields from a byte stream.
+type bitReader struct {
+	r   io.ByteReader
+	cur uint64
+	n   uint
+}
+
+// read returns the next n (at most 32) bits as an unsigned value.
+func (b *bitReader) read(n uint) (uint64, error) {
+	for b.n < n {
+		c, err := b.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		b.cur = b.cur<<8 | uint64(c)
+		b.n += 8
+	}
+	b.n -= n
+	v := b.cur >> b.n & (1<<n - 1)
+	b.cur &= 1<<b.n - 1
+	return v, nil
+}
+
+// signed returns the next n bits as a two's complement value.
+func (b *bitReader) signed(n int) (int32, error) {
+	if n == 0 {
+		return 0, nil
+	}
+	v, err := b.read(uint(n))
+	if err != nil {
+		return 0, err
+	}
+	return int32(int64(v<<(64-uint(n))) >> (64 - uint(n))), nil
+}
+
+// unary counts zero bits up to and including the next one bit.
+func (b *bitReader) unary() (int, error) {
+	count := 0
+	for {
+		if b.n == 0 {
+			c, err := b.r.ReadByte()
+			if err != nil {
+				return 0, err
+			}
+			b.cur, b.n = uint64(c), 8
+		}
+		if b.cur == 0 {
+			count += int(b.n)
+			b.n = 0
+			continue
+		}
+		zeros := b.n - uint(bits.Len64(b.cur))
+		count += int(zeros)
+		b.n -= zeros + 1
+		b.cur &= 1<<b.n - 1
+		return count, nil
+	}
+}
+
+// align drops bits up to the next byte boundary.
+func (b *bitReader) align() {
+	b.n -= b.n % 8
+	b.cur &= 1<<b.n - 1
+}
+
+// syncFrame consumes the frame sync code along with the reserved and blocking strategy bits,
+// skipping any garbage before it. Trailing bytes after the last frame end the stream cleanly.
+func (b *bitReader) syncFrame() error {
+	var prev uint64
+	for {
+		c, err := b.read(8)
+		if err != nil {
+			return err
+		}
+		if prev == 0xff && c&0xfe == 0xf8 {
+			return nil
+		}
+		prev = c
+	}
+}
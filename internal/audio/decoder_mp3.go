@@ -0,0 +1,155 @@
+package audio
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/hajimehoshi/go-mp3"
+	"gowav/pkg/utils"
+)
+
+// mp3Decoder decodes MPEG-1/2 Layer III through go-mp3.
+type mp3Decoder struct{}
+
+func (mp3Decoder) Name() string { return "mp3" }
+
+// Sniff accepts an ID3 tag or a bare MPEG audio frame sync.
+func (mp3Decoder) Sniff(header []byte) bool {
+	if utils.HasMagic(header, "mp3") {
+		return true
+	}
+	return len(header) >= 2 && header[0] == 0xff && header[1]&0xe0 == 0xe0
+}
+
+func (mp3Decoder) Decode(r io.ReadSeeker) (PCMSource, error) {
+	// Given a seekable source, go-mp3 reads every frame header up front to find the length,
+	// which for a stream still downloading means waiting for all of it.
+	if sr, ok := r.(*streamReader); ok {
+		if _, done := sr.s.complete(); !done {
+			return newMP3Stream(sr)
+		}
+	}
+	dec, err := mp3.NewDecoder(r)
+	if err != nil {
+		return nil, err
+	}
+	return &mp3Source{dec: dec}, nil
+}
+
+// mp3Source adapts a go-mp3 decoder, which always yields 16-bit stereo, to PCMSource.
+type mp3Source struct {
+	dec *mp3.Decoder
+}
+
+func (s *mp3Source) Read(p []byte) (int, error) {
+	return s.dec.Read(p)
+}
+
+func (s *mp3Source) SampleRate() int {
+	return s.dec.SampleRate()
+}
+
+func (s *mp3Source) Channels() int {
+	return 2
+}
+
+func (s *mp3Source) Length() int64 {
+	n := s.dec.Length()
+	if n < 0 {
+		return -1
+	}
+	return n / int64(bytesPerSample*s.Channels())
+}
+
+func (s *mp3Source) SeekFrame(frame int64) error {
+	if frame < 0 {
+		frame = 0
+	}
+	_, err := s.dec.Seek(frame*int64(bytesPerSample*s.Channels()), io.SeekStart)
+	return err
+}
+
+// mp3Stream decodes an MP3 that is still downloading, front to back. Its length is unknown,
+// and seeking starts a new decoder at a byte offset estimated from the bit rate so far.
+type mp3Stream struct {
+	r   *streamReader
+	dec *mp3.Decoder
+	// start is the offset of the first frame. The current decoder began at byte offset
+	// from, playing frame, and has returned read bytes of PCM since.
+	start, from int64
+	frame, read int64
+	// bytesPerFrame is the number of file bytes per PCM frame measured before the last seek.
+	bytesPerFrame float64
+}
+
+func newMP3Stream(r *streamReader) (PCMSource, error) {
+	if err := skipID3v2(r); err != nil {
+		return nil, err
+	}
+	start, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	s := &mp3Stream{r: r, start: start}
+	if err := s.open(start, 0); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// open starts a new decoder at byte offset off, which is taken to play frame.
+func (s *mp3Stream) open(off, frame int64) error {
+	if _, err := s.r.Seek(off, io.SeekStart); err != nil {
+		return err
+	}
+	// Hide Seek so the decoder doesn't scan the whole stream.
+	dec, err := mp3.NewDecoder(struct{ io.Reader }{s.r})
+	if err != nil {
+		return err
+	}
+	s.dec, s.from, s.frame, s.read = dec, off, frame, 0
+	return nil
+}
+
+func (s *mp3Stream) Read(p []byte) (int, error) {
+	n, err := s.dec.Read(p)
+	s.read += int64(n)
+	return n, err
+}
+
+func (s *mp3Stream) SampleRate() int {
+	return s.dec.SampleRate()
+}
+
+func (s *mp3Stream) Channels() int {
+	return 2
+}
+
+func (s *mp3Stream) Length() int64 {
+	return -1
+}
+
+// position is the frame the next read starts at.
+func (s *mp3Stream) position() int64 {
+	return s.frame + s.read/int64(bytesPerSample*s.Channels())
+}
+
+func (s *mp3Stream) SeekFrame(frame int64) error {
+	if frame < 0 {
+		frame = 0
+	}
+	if frame == s.position() {
+		// Nothing to do; after an interrupted read the decoder finds the next frame itself.
+		return nil
+	}
+	if decoded := s.position() - s.frame; decoded > 0 {
+		s.bytesPerFrame = float64(s.r.pos-s.from) / float64(decoded)
+	}
+	if frame == 0 {
+		return s.open(s.start, 0)
+	}
+	if s.bytesPerFrame <= 0 {
+		return fmt.Errorf("cannot seek before any audio has played")
+	}
+	return s.open(s.start+int64(float64(frame)*s.bytesPerFrame), frame)
+}
@@ -0,0 +1,130 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// rawFormat describes the sample encoding of an uncompressed WAV or AIFF data chunk.
+type rawFormat struct {
+	sampleRate int
+	channels   int
+	bits       int
+	float      bool
+	bigEndian  bool
+	// unsigned8 is set for WAV, whose 8-bit samples are offset binary.
+	unsigned8 bool
+}
+
+// maxFormatChunk bounds the size of a WAV fmt or AIFF COMM chunk read into memory. Real
+// ones are well under 100 bytes; the AIFC compression name adds at most 256.
+const maxFormatChunk = 1024
+
+// blockAlign is the number of bytes in one interleaved frame.
+func (f rawFormat) blockAlign() int {
+	return (f.bits + 7) / 8 * f.channels
+}
+
+func (f rawFormat) validate() error {
+	if f.channels <= 0 || f.sampleRate <= 0 {
+		return fmt.Errorf("invalid format: %d Hz, %d channels", f.sampleRate, f.channels)
+	}
+	switch {
+	case f.float && (f.bits == 32 || f.bits == 64):
+	case !f.float && f.bits >= 8 && f.bits <= 32:
+	default:
+		return fmt.Errorf("unsupported sample format: %d-bit (float=%v)", f.bits, f.float)
+	}
+	return nil
+}
+
+// sample decodes one sample from b, which holds exactly (bits+7)/8 bytes.
+func (f rawFormat) sample(b []byte) int16 {
+	var order binary.ByteOrder = binary.LittleEndian
+	if f.bigEndian {
+		order = binary.BigEndian
+	}
+
+	if f.float {
+		if f.bits == 64 {
+			return clipFloat(math.Float64frombits(order.Uint64(b)))
+		}
+		return clipFloat(float64(math.Float32frombits(order.Uint32(b))))
+	}
+
+	if len(b) == 1 {
+		if f.unsigned8 {
+			return int16(int(b[0])-128) << 8
+		}
+		return int16(int8(b[0])) << 8
+	}
+
+	// Assemble the integer most-significant byte first, then sign-extend from the container width.
+	var v uint32
+	for i := range b {
+		idx := i
+		if !f.bigEndian {
+			idx = len(b) - 1 - i
+		}
+		v = v<<8 | uint32(b[idx])
+	}
+	width := len(b) * 8
+	signed := int32(v<<uint(32-width)) >> uint(32-width)
+	return scaleInt(signed, width)
+}
+
+// newRawSource serves the data chunk at r's current position. dataSize is the
+// chunk length in bytes, or -1 to read until EOF.
+func newRawSource(r io.ReadSeeker, f rawFormat, dataSize int64) (*blockSource, error) {
+	align := f.blockAlign()
+	width := align / f.channels
+
+	dataStart, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+
+	length := int64(-1)
+	if dataSize >= 0 {
+		length = dataSize / int64(align)
+	}
+	var position int64
+
+	const framesPerBlock = 4096
+	buf := make([]byte, framesPerBlock*align)
+	out := make([]int16, framesPerBlock*f.channels)
+
+	return &blockSource{
+		sampleRate: f.sampleRate,
+		channels:   f.channels,
+		length:     length,
+		next: func() ([]int16, error) {
+			want := int64(framesPerBlock)
+			if length >= 0 && length-position < want {
+				want = length - position
+			}
+			if want <= 0 {
+				return nil, io.EOF
+			}
+			n, err := io.ReadFull(r, buf[:want*int64(align)])
+			if err == io.ErrUnexpectedEOF {
+				err = io.EOF
+			}
+			frames := n / align
+			position += int64(frames)
+			for i := 0; i < frames*f.channels; i++ {
+				out[i] = f.sample(buf[i*width : (i+1)*width])
+			}
+			return out[:frames*f.channels], err
+		},
+		seek: func(frame int64) (int64, error) {
+			if _, err := r.Seek(dataStart+frame*int64(align), io.SeekStart); err != nil {
+				return 0, err
+			}
+			position = frame
+			return frame, nil
+		},
+	}, nil
+}
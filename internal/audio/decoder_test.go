@@ -0,0 +1,606 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// bitWriter packs big-endian bit fields, the way FLAC lays them out.
+type bitWriter struct {
+	buf []byte
+	cur byte
+	n   uint
+}
+
+func (w *bitWriter) write(v uint64, n uint) {
+	for i := n; i > 0; i-- {
+		w.cur = w.cur<<1 | byte(v>>(i-1)&1)
+		if w.n++; w.n == 8 {
+			w.buf = append(w.buf, w.cur)
+			w.cur, w.n = 0, 0
+		}
+	}
+}
+
+func (w *bitWriter) signed(v int32, n uint) {
+	w.write(uint64(v)&(1<<n-1), n)
+}
+
+func (w *bitWriter) align() {
+	for w.n != 0 {
+		w.write(0, 1)
+	}
+}
+
+// residual writes r as a single Rice partition with parameter k.
+func (w *bitWriter) residual(r []int32, k uint) {
+	w.write(0, 2) // 4-bit Rice parameters
+	w.write(0, 4) // partition order 0
+	w.write(uint64(k), 4)
+	for _, v := range r {
+		u := uint32(v<<1 ^ v>>31)
+		for q := u >> k; q > 0; q-- {
+			w.write(0, 1)
+		}
+		w.write(1, 1)
+		w.write(uint64(u)&(1<<k-1), k)
+	}
+}
+
+// flacSubframe encodes one channel of a frame.
+type flacSubframe func(w *bitWriter, x []int32, bps uint)
+
+func constantSubframe(w *bitWriter, x []int32, bps uint) {
+	w.write(0, 8)
+	w.signed(x[0], bps)
+}
+
+func verbatimSubframe(w *bitWriter, x []int32, bps uint) {
+	w.write(1<<1, 8)
+	for _, v := range x {
+		w.signed(v, bps)
+	}
+}
+
+// fixedSubframe uses the fixed predictor of the given order, whose residual is the
+// order-th difference of the signal.
+func fixedSubframe(order int) flacSubframe {
+	return func(w *bitWriter, x []int32, bps uint) {
+		w.write(uint64(8+order)<<1, 8)
+		for _, v := range x[:order] {
+			w.signed(v, bps)
+		}
+		r := append([]int32(nil), x...)
+		for o := 0; o < order; o++ {
+			for i := len(r) - 1; i > o; i-- {
+				r[i] -= r[i-1]
+			}
+		}
+		w.residual(r[order:], 6)
+	}
+}
+
+// lpcSubframe predicts x[i] as the sum of coeffs[j]*x[i-j-1], shifted right by shift.
+func lpcSubframe(coeffs []int32, precision uint, shift int32) flacSubframe {
+	return func(w *bitWriter, x []int32, bps uint) {
+		order := len(coeffs)
+		w.write(uint64(32+order-1)<<1, 8)
+		for _, v := range x[:order] {
+			w.signed(v, bps)
+		}
+		w.write(uint64(precision-1), 4)
+		w.signed(shift, 5)
+		for _, c := range coeffs {
+			w.signed(c, precision)
+		}
+		var r []int32
+		for i := order; i < len(x); i++ {
+			var sum int64
+			for j, c := range coeffs {
+				sum += int64(c) * int64(x[i-j-1])
+			}
+			r = append(r, x[i]-int32(sum>>shift))
+		}
+		w.residual(r, 6)
+	}
+}
+
+// flacFrame is one frame of the test file: its channel assignment and how each of its two
+// channels is coded.
+type flacFrame struct {
+	samples    int
+	assignment uint64
+	sub        [2]flacSubframe
+}
+
+func crc8(data []byte) byte {
+	var crc byte
+	for _, b := range data {
+		crc ^= b
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+func crc16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x8005
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// testFLAC encodes 16-bit stereo left and right as a FLAC file with a seek point per frame.
+func testFLAC(left, right []int32, frames []flacFrame) []byte {
+	var (
+		body   []byte
+		points []flacSeekPoint
+		pos    int
+	)
+	for n, f := range frames {
+		l, r := left[pos:pos+f.samples], right[pos:pos+f.samples]
+		ch := [2][]int32{l, r}
+		bps := [2]uint{16, 16}
+		side := make([]int32, f.samples)
+		for i := range side {
+			side[i] = l[i] - r[i]
+		}
+		switch f.assignment {
+		case flacLeftSide:
+			ch[1], bps[1] = side, 17
+		case flacSideRight:
+			ch[0], bps[0] = side, 17
+		case flacMidSide:
+			mid := make([]int32, f.samples)
+			for i := range mid {
+				mid[i] = (l[i] + r[i]) >> 1
+			}
+			ch[0], ch[1], bps[1] = mid, side, 17
+		}
+
+		w := &bitWriter{}
+		w.write(0xfff8, 16)
+		w.write(7, 4) // explicit 16-bit block size
+		w.write(0, 4) // sample rate from STREAMINFO
+		w.write(f.assignment, 4)
+		w.write(4, 3) // 16 bits per sample
+		w.write(0, 1)
+		w.write(uint64(n), 8)
+		w.write(uint64(f.samples-1), 16)
+		w.write(uint64(crc8(w.buf)), 8)
+		for c := range ch {
+			f.sub[c](w, ch[c], bps[c])
+		}
+		w.align()
+		w.write(uint64(crc16(w.buf)), 16)
+
+		points = append(points, flacSeekPoint{sample: uint64(pos), offset: int64(len(body))})
+		body = append(body, w.buf...)
+		pos += f.samples
+	}
+
+	info := &bitWriter{}
+	info.write(0, 16)   // minimum block size, unused
+	info.write(256, 16) // maximum block size
+	info.write(0, 48)   // frame sizes unknown
+	info.write(44100, 20)
+	info.write(1, 3)  // two channels
+	info.write(15, 5) // 16 bits per sample
+	info.write(uint64(pos), 36)
+	info.write(0, 64) // MD5, unchecked
+	info.write(0, 64)
+
+	table := &bitWriter{}
+	for i, p := range points {
+		table.write(p.sample, 64)
+		table.write(uint64(p.offset), 64)
+		table.write(uint64(frames[i].samples), 16)
+	}
+
+	out := []byte("fLaC")
+	out = append(out, 0, 0, 0, byte(len(info.buf)))
+	out = append(out, info.buf...)
+	out = append(out, 0x80|3, 0, byte(len(table.buf)>>8), byte(len(table.buf)))
+	out = append(out, table.buf...)
+	return append(out, body...)
+}
+
+// flacTestSignal returns two channels of tones with a constant opening, and the file
+// encoding them with every subframe type and stereo mode.
+func flacTestSignal() (left, right []int32, file []byte) {
+	const n = 4*256 + 100
+	left, right = make([]int32, n), make([]int32, n)
+	for i := range left {
+		left[i] = int32(12000 * math.Sin(2*math.Pi*440*float64(i)/44100))
+		right[i] = int32(8000*math.Sin(2*math.Pi*660*float64(i)/44100)) + int32(i%3)
+		if i < 256 {
+			right[i] = -1234
+		}
+	}
+	frames := []flacFrame{
+		{256, 1, [2]flacSubframe{verbatimSubframe, constantSubframe}},
+		{256, flacLeftSide, [2]flacSubframe{fixedSubframe(2), verbatimSubframe}},
+		{256, flacSideRight, [2]flacSubframe{fixedSubframe(1), lpcSubframe([]int32{4, -2}, 4, 1)}},
+		{256, flacMidSide, [2]flacSubframe{fixedSubframe(3), fixedSubframe(0)}},
+		{100, 1, [2]flacSubframe{fixedSubframe(4), lpcSubframe([]int32{3, -3, 1}, 3, 0)}},
+	}
+	return left, right, testFLAC(left, right, frames)
+}
+
+// interleave returns the 16-bit little-endian PCM bytes of left and right from frame start on.
+func interleave(left, right []int32, start int) []byte {
+	var out []byte
+	for i := start; i < len(left); i++ {
+		out = binary.LittleEndian.AppendUint16(out, uint16(left[i]))
+		out = binary.LittleEndian.AppendUint16(out, uint16(right[i]))
+	}
+	return out
+}
+
+func TestFLACDecodesToPCM(t *testing.T) {
+	left, right, file := flacTestSignal()
+	src, name, err := DecodeBytes(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "flac" || src.SampleRate() != 44100 || src.Channels() != 2 || src.Length() != int64(len(left)) {
+		t.Fatalf("got %s, %d Hz, %d channels, %d frames", name, src.SampleRate(), src.Channels(), src.Length())
+	}
+
+	got, err := io.ReadAll(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := interleave(left, right, 0)
+	if len(got) != len(want) {
+		t.Fatalf("decoded %d bytes, want %d", len(got), len(want))
+	}
+	for i := 0; i < len(want); i += 4 {
+		if !bytes.Equal(got[i:i+4], want[i:i+4]) {
+			t.Fatalf("frame %d = % x, want % x", i/4, got[i:i+4], want[i:i+4])
+		}
+	}
+}
+
+func TestFLACSeeksWithSeekTable(t *testing.T) {
+	left, right, file := flacTestSignal()
+	src, _, err := DecodeBytes(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Frame 600 lies inside the third FLAC frame, so decoding restarts at its seek point.
+	for _, frame := range []int{600, 256, 0, 1100} {
+		if err := src.SeekFrame(int64(frame)); err != nil {
+			t.Fatal(err)
+		}
+		got, err := io.ReadAll(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, interleave(left, right, frame)) {
+			t.Errorf("after seeking to %d, decoded %d bytes that differ from the source", frame, len(got))
+		}
+	}
+}
+
+func TestFLACReportsTruncation(t *testing.T) {
+	_, _, file := flacTestSignal()
+	src, _, err := DecodeBytes(file[:len(file)-50])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadAll(src); err != io.ErrUnexpectedEOF {
+		t.Errorf("reading a truncated file returned %v, want io.ErrUnexpectedEOF", err)
+	}
+}
+
+func TestWAVRejectsOversizedFormatChunk(t *testing.T) {
+	wav := []byte("RIFF\x00\x00\x00\x00WAVEfmt \xff\xff\xff\xff")
+	_, _, err := DecodeBytes(wav)
+	if err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("DecodeBytes = %v, want a chunk too large error", err)
+	}
+}
+
+// testSamples are 16-bit values that every format can carry exactly, extremes included.
+var testSamples = []int16{0, 768, -1024, 32512, -32768, 5120}
+
+// encodeInts stores each sample at the given byte width, filling the bits below 16 with
+// noise that converting back to 16 bits has to drop.
+func encodeInts(samples []int16, width int, order binary.ByteOrder) []byte {
+	var out []byte
+	for _, s := range samples {
+		v := uint32(int32(s)) << 16
+		if width > 2 {
+			v |= 0x7f7f
+		}
+		b := make([]byte, 4)
+		binary.BigEndian.PutUint32(b, v)
+		b = b[:width]
+		if order == binary.LittleEndian {
+			for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+				b[i], b[j] = b[j], b[i]
+			}
+		}
+		out = append(out, b...)
+	}
+	return out
+}
+
+// encodeFloats stores samples as 32- or 64-bit floats in [-1, 1), with one value past
+// full scale that has to be clipped.
+func encodeFloats(samples []int16, width int, order binary.AppendByteOrder) ([]byte, []int16) {
+	var out []byte
+	want := append([]int16(nil), samples...)
+	values := make([]float64, len(samples))
+	for i, s := range samples {
+		values[i] = float64(s) / 32768
+	}
+	values[len(values)-1], want[len(want)-1] = 1.5, 32767
+	for _, v := range values {
+		if width == 8 {
+			out = order.AppendUint64(out, math.Float64bits(v))
+		} else {
+			out = order.AppendUint32(out, math.Float32bits(float32(v)))
+		}
+	}
+	return out, want
+}
+
+// testWAVFile wraps data in a RIFF/WAVE file, after a padded odd-sized chunk the decoder
+// has to skip. The fmt chunk is WAVE_FORMAT_EXTENSIBLE when extensible is set.
+func testWAVFile(tag uint16, channels, bits int, extensible bool, data []byte) []byte {
+	fmtChunk := binary.LittleEndian.AppendUint16(nil, tag)
+	if extensible {
+		fmtChunk = binary.LittleEndian.AppendUint16(nil, wavFormatExtensible)
+	}
+	align := (bits + 7) / 8 * channels
+	fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, uint16(channels))
+	fmtChunk = binary.LittleEndian.AppendUint32(fmtChunk, 44100)
+	fmtChunk = binary.LittleEndian.AppendUint32(fmtChunk, uint32(44100*align))
+	fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, uint16(align))
+	fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, uint16(bits))
+	if extensible {
+		fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, 22)
+		fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, uint16(bits))
+		fmtChunk = binary.LittleEndian.AppendUint32(fmtChunk, 0)
+		fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, tag)
+		fmtChunk = append(fmtChunk, "\x00\x00\x00\x00\x10\x00\x80\x00\x00\xaa\x00\x38\x9b\x71"...)
+	}
+
+	var body []byte
+	for _, c := range []struct {
+		id   string
+		data []byte
+	}{{"fmt ", fmtChunk}, {"LIST", []byte("odd")}, {"data", data}} {
+		body = append(body, c.id...)
+		body = binary.LittleEndian.AppendUint32(body, uint32(len(c.data)))
+		body = append(body, c.data...)
+		if len(c.data)%2 == 1 {
+			body = append(body, 0)
+		}
+	}
+	out := append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(4+len(body)))...)
+	out = append(out, "WAVE"...)
+	return append(out, body...)
+}
+
+// extended encodes a whole number as the 80-bit float AIFF gives its sample rate in.
+func extended(v uint64) []byte {
+	e := bits.Len64(v) - 1
+	b := binary.BigEndian.AppendUint16(nil, uint16(16383+e))
+	return binary.BigEndian.AppendUint64(b, v<<(63-e))
+}
+
+// testAIFFFile wraps data in an AIFF file, or an AIFF-C file when compression is set.
+func testAIFFFile(compression string, channels, bits int, data []byte) []byte {
+	frames := len(data) / ((bits + 7) / 8 * channels)
+	comm := binary.BigEndian.AppendUint16(nil, uint16(channels))
+	comm = binary.BigEndian.AppendUint32(comm, uint32(frames))
+	comm = binary.BigEndian.AppendUint16(comm, uint16(bits))
+	comm = append(comm, extended(44100)...)
+	kind := "AIFF"
+	var body []byte
+	if compression != "" {
+		kind = "AIFC"
+		comm = append(comm, compression...)
+		comm = append(comm, 0, 0) // empty compression name, padded
+		body = append(body, "FVER\x00\x00\x00\x04\xa2\x80\x51\x40"...)
+	}
+	body = append(body, "COMM"...)
+	body = binary.BigEndian.AppendUint32(body, uint32(len(comm)))
+	body = append(body, comm...)
+	body = append(body, "SSND"...)
+	body = binary.BigEndian.AppendUint32(body, uint32(8+len(data)))
+	body = append(body, make([]byte, 8)...) // offset and block size
+	body = append(body, data...)
+
+	out := append([]byte("FORM"), binary.BigEndian.AppendUint32(nil, uint32(4+len(body)))...)
+	out = append(out, kind...)
+	return append(out, body...)
+}
+
+func TestDecodeUncompressed(t *testing.T) {
+	le, be := binary.LittleEndian, binary.BigEndian
+	unsigned8 := make([]byte, len(testSamples))
+	for i, s := range testSamples {
+		unsigned8[i] = byte(s>>8) + 128
+	}
+	float32LE, clipped := encodeFloats(testSamples, 4, le)
+	float64LE, _ := encodeFloats(testSamples, 8, le)
+	float32BE, _ := encodeFloats(testSamples, 4, be)
+
+	tests := []struct {
+		name     string
+		format   string
+		file     []byte
+		channels int
+		want     []int16
+	}{
+		{"wav 8-bit", "wav", testWAVFile(wavFormatPCM, 1, 8, false, unsigned8), 1, testSamples},
+		{"wav 16-bit stereo", "wav", testWAVFile(wavFormatPCM, 2, 16, false, encodeInts(testSamples, 2, le)), 2, testSamples},
+		{"wav 24-bit", "wav", testWAVFile(wavFormatPCM, 1, 24, false, encodeInts(testSamples, 3, le)), 1, testSamples},
+		{"wav 32-bit", "wav", testWAVFile(wavFormatPCM, 1, 32, false, encodeInts(testSamples, 4, le)), 1, testSamples},
+		{"wav extensible 24-bit", "wav", testWAVFile(wavFormatPCM, 2, 24, true, encodeInts(testSamples, 3, le)), 2, testSamples},
+		{"wav float32", "wav", testWAVFile(wavFormatFloat, 1, 32, false, float32LE), 1, clipped},
+		{"wav float64", "wav", testWAVFile(wavFormatFloat, 2, 64, false, float64LE), 2, clipped},
+		{"aiff 16-bit", "aiff", testAIFFFile("", 2, 16, encodeInts(testSamples, 2, be)), 2, testSamples},
+		{"aiff 24-bit", "aiff", testAIFFFile("", 1, 24, encodeInts(testSamples, 3, be)), 1, testSamples},
+		{"aifc NONE 32-bit", "aiff", testAIFFFile("NONE", 1, 32, encodeInts(testSamples, 4, be)), 1, testSamples},
+		{"aifc sowt", "aiff", testAIFFFile("sowt", 1, 16, encodeInts(testSamples, 2, le)), 1, testSamples},
+		{"aifc fl32", "aiff", testAIFFFile("fl32", 1, 32, float32BE), 1, clipped},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, name, err := DecodeBytes(tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			frames := int64(len(tt.want) / tt.channels)
+			if name != tt.format || src.SampleRate() != 44100 || src.Channels() != tt.channels || src.Length() != frames {
+				t.Fatalf("got %s, %d Hz, %d channels, %d frames", name, src.SampleRate(), src.Channels(), src.Length())
+			}
+			pcm, err := io.ReadAll(src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]int16, len(pcm)/2)
+			for i := range got {
+				got[i] = int16(binary.LittleEndian.Uint16(pcm[2*i:]))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("samples = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeRejectsUnsupportedFormats(t *testing.T) {
+	for name, file := range map[string][]byte{
+		"wav a-law":      testWAVFile(6, 1, 8, false, []byte{1, 2}),
+		"wav 12-bit":     testWAVFile(wavFormatFloat, 1, 12, false, []byte{1, 2}),
+		"aifc ima4":      testAIFFFile("ima4", 1, 16, []byte{1, 2}),
+		"wav no data":    testWAVFile(wavFormatPCM, 1, 16, false, nil)[:36],
+		"aiff truncated": testAIFFFile("", 1, 16, []byte{1, 2})[:20],
+	} {
+		if _, _, err := DecodeBytes(file); err == nil {
+			t.Errorf("%s: decoded without an error", name)
+		}
+	}
+}
+
+// TestBlockSourceSeekSkipsWithinBlock seeks a source whose decoder can only restart at
+// block boundaries, so the frames before the target have to be skipped.
+func TestBlockSourceSeekSkipsWithinBlock(t *testing.T) {
+	const (
+		total = 1000
+		block = 64
+	)
+	var next int
+	src := &blockSource{
+		sampleRate: 8000,
+		channels:   2,
+		length:     total,
+		next: func() ([]int16, error) {
+			if next >= total {
+				return nil, io.EOF
+			}
+			var out []int16
+			for f := next; f < min(next+block, total); f++ {
+				out = append(out, int16(f), int16(-f))
+			}
+			next += block
+			return out, nil
+		},
+		seek: func(frame int64) (int64, error) {
+			next = int(frame) / block * block
+			return int64(next), nil
+		},
+	}
+
+	for _, tt := range []struct{ seek, first int }{{300, 300}, {64, 64}, {-5, 0}, {999, 999}, {5000, total}} {
+		if err := src.SeekFrame(int64(tt.seek)); err != nil {
+			t.Fatal(err)
+		}
+		pcm, err := io.ReadAll(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := (total - tt.first) * 4; len(pcm) != want {
+			t.Errorf("after seeking to %d: read %d bytes, want %d", tt.seek, len(pcm), want)
+			continue
+		}
+		if len(pcm) > 0 {
+			if l, r := int16(binary.LittleEndian.Uint16(pcm)), int16(binary.LittleEndian.Uint16(pcm[2:])); l != int16(tt.first) || r != -int16(tt.first) {
+				t.Errorf("after seeking to %d: first frame is (%d, %d)", tt.seek, l, r)
+			}
+		}
+	}
+}
+
+func TestReadFileMetadata(t *testing.T) {
+	_, _, flac := flacTestSignal()
+	unstated := append([]byte(nil), flac...)
+	// Zero STREAMINFO's 36-bit sample count, which leaves the length to be estimated.
+	unstated[21] &= 0xf0
+	copy(unstated[22:26], []byte{0, 0, 0, 0})
+
+	const tagSize = 3000
+	tag := []byte{'I', 'D', '3', 3, 0, 0,
+		byte(tagSize >> 21 & 0x7f), byte(tagSize >> 14 & 0x7f), byte(tagSize >> 7 & 0x7f), byte(tagSize & 0x7f)}
+	tag = append(tag, make([]byte, tagSize)...)
+
+	tests := []struct {
+		name   string
+		data   []byte
+		format string
+		want   time.Duration
+	}{
+		{"wav", testWAV(8000, 8000), "wav", time.Second},
+		{"wav behind a large ID3 tag", append(tag, testWAV(8000, 4000)...), "wav", 500 * time.Millisecond},
+		{"flac", flac, "flac", framesToDuration(4*256+100, 44100)},
+		{"flac without a stated length", unstated, "flac", framesToDuration(4*256+100, 44100)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "track")
+			if err := os.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			md, err := ReadFileMetadata(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if md.Format != tt.format || md.Duration != tt.want || md.FileSize != int64(len(tt.data)) {
+				t.Errorf("got %s, %v, %d bytes; want %s, %v, %d bytes",
+					md.Format, md.Duration, md.FileSize, tt.format, tt.want, len(tt.data))
+			}
+		})
+	}
+
+	if _, err := ReadFileMetadata(filepath.Join(t.TempDir(), "missing.wav")); err == nil {
+		t.Error("reading a missing file succeeded")
+	}
+}
@@ -0,0 +1,114 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"gowav/pkg/utils"
+)
+
+// WAVE format tags from the fmt chunk.
+const (
+	wavFormatPCM        = 0x0001
+	wavFormatFloat      = 0x0003
+	wavFormatExtensible = 0xfffe
+)
+
+// wavDecoder decodes RIFF/WAVE files holding integer PCM or IEEE float samples.
+type wavDecoder struct{}
+
+func (wavDecoder) Name() string { return "wav" }
+
+func (wavDecoder) Sniff(header []byte) bool {
+	return utils.HasMagic(header, "wav") && len(header) >= 12 && string(header[8:12]) == "WAVE"
+}
+
+func (wavDecoder) Decode(r io.ReadSeeker) (PCMSource, error) {
+	if err := skipID3v2(r); err != nil {
+		return nil, err
+	}
+
+	riff := make([]byte, 12)
+	if _, err := io.ReadFull(r, riff); err != nil {
+		return nil, fmt.Errorf("read RIFF header: %w", err)
+	}
+	if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("not a RIFF/WAVE file")
+	}
+
+	var (
+		format  rawFormat
+		haveFmt bool
+	)
+	chunk := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return nil, fmt.Errorf("no data chunk found")
+		}
+		id := string(chunk[0:4])
+		size := int64(binary.LittleEndian.Uint32(chunk[4:8]))
+
+		switch id {
+		case "fmt ":
+			if size > maxFormatChunk {
+				return nil, fmt.Errorf("fmt chunk too large (%d bytes)", size)
+			}
+			body := make([]byte, size)
+			if _, err := io.ReadFull(r, body); err != nil {
+				return nil, fmt.Errorf("read fmt chunk: %w", err)
+			}
+			f, err := parseWAVFormat(body)
+			if err != nil {
+				return nil, err
+			}
+			format, haveFmt = f, true
+			if size%2 == 1 {
+				r.Seek(1, io.SeekCurrent)
+			}
+
+		case "data":
+			if !haveFmt {
+				return nil, fmt.Errorf("data chunk before fmt chunk")
+			}
+			// Streamed WAVs often leave the size as 0 or 0xFFFFFFFF; read to EOF then.
+			if size == 0 || size == 0xffffffff {
+				size = -1
+			}
+			return newRawSource(r, format, size)
+
+		default:
+			if _, err := r.Seek(size+size%2, io.SeekCurrent); err != nil {
+				return nil, fmt.Errorf("skip %q chunk: %w", id, err)
+			}
+		}
+	}
+}
+
+// parseWAVFormat reads the fields of a WAVE fmt chunk.
+func parseWAVFormat(body []byte) (rawFormat, error) {
+	if len(body) < 16 {
+		return rawFormat{}, fmt.Errorf("fmt chunk too short")
+	}
+	tag := binary.LittleEndian.Uint16(body[0:2])
+	f := rawFormat{
+		channels:   int(binary.LittleEndian.Uint16(body[2:4])),
+		sampleRate: int(binary.LittleEndian.Uint32(body[4:8])),
+		bits:       int(binary.LittleEndian.Uint16(body[14:16])),
+		unsigned8:  true,
+	}
+
+	// WAVE_FORMAT_EXTENSIBLE carries the real format tag at the start of its sub-format GUID.
+	if tag == wavFormatExtensible && len(body) >= 26 {
+		tag = binary.LittleEndian.Uint16(body[24:26])
+	}
+
+	switch tag {
+	case wavFormatPCM:
+	case wavFormatFloat:
+		f.float = true
+	default:
+		return rawFormat{}, fmt.Errorf("unsupported WAVE format tag 0x%04x", tag)
+	}
+	return f, f.validate()
+}
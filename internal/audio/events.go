@@ -0,0 +1,180 @@
+package audio
+
+import (
+	"context"
+	"gowav/pkg/viz"
+	"sync"
+	"time"
+)
+
+// EventKind says what happened in an Event.
+type EventKind int
+
+const (
+	// EventLoadStarted is sent when a file or URL starts loading.
+	EventLoadStarted EventKind = iota
+	// EventProgress reports progress of the current load or analysis stage.
+	EventProgress
+	// EventMetadataReady is sent when a load has finished and the metadata can be read.
+	EventMetadataReady
+	// EventAnalysisStage is sent when analysis moves on to a new stage, such as beat detection.
+	EventAnalysisStage
+	// EventVizReady is sent when the visualization for Event.Mode has been built.
+	EventVizReady
+	// EventError is sent when a load or analysis fails; Status.Err holds the error.
+	EventError
+	// EventCancelled is sent when a running load or analysis is cancelled.
+	EventCancelled
+	// EventMetadataChanged is sent when the loaded track's metadata changes, such as when
+	// a live station moves on to another song.
+	EventMetadataChanged
+)
+
+// Event is a change in the Processor's status, as delivered to subscribers.
+type Event struct {
+	Kind EventKind
+	// Status is the processor status right after the event.
+	Status ProcessingStatus
+	// Mode is the visualization an EventVizReady is for.
+	Mode viz.ViewMode
+}
+
+// eventBuffer is the number of events a subscriber can fall behind by before progress
+// updates are dropped. Other events are always kept.
+const eventBuffer = 64
+
+// progressInterval limits how often EventProgress is sent; the status itself is always current.
+const progressInterval = 50 * time.Millisecond
+
+// Subscribe returns a channel that receives the Processor's events, and a function that
+// ends the subscription and closes the channel. A subscriber that falls behind never holds
+// up loading; if it falls far behind it misses progress updates, but no other events.
+func (p *Processor) Subscribe() (<-chan Event, func()) {
+	s := &subscriber{
+		ch:   make(chan Event),
+		wake: make(chan struct{}, 1),
+		done: make(chan struct{}),
+	}
+	go s.forward()
+
+	p.subMu.Lock()
+	p.subscribers[s] = struct{}{}
+	p.subMu.Unlock()
+
+	return s.ch, func() {
+		p.subMu.Lock()
+		defer p.subMu.Unlock()
+		if _, ok := p.subscribers[s]; ok {
+			delete(p.subscribers, s)
+			close(s.done)
+		}
+	}
+}
+
+// subscriber queues the events for one Subscribe channel and hands them on as the
+// channel is read, so that sending never waits for the reader.
+type subscriber struct {
+	ch    chan Event
+	mu    sync.Mutex
+	queue []Event
+	// wake is signalled when an event is queued; done is closed when the subscription ends.
+	wake chan struct{}
+	done chan struct{}
+}
+
+// push queues ev. A progress update replaces one still waiting at the end of the queue,
+// and is dropped if the queue is full; every other event is kept.
+func (s *subscriber) push(ev Event) {
+	s.mu.Lock()
+	n := len(s.queue)
+	switch {
+	case ev.Kind != EventProgress:
+		s.queue = append(s.queue, ev)
+	case n > 0 && s.queue[n-1].Kind == EventProgress:
+		s.queue[n-1] = ev
+	case n < eventBuffer:
+		s.queue = append(s.queue, ev)
+	default:
+		logDebug("Dropped a progress event for a slow subscriber")
+	}
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// forward delivers the queued events in order until the subscription ends, then closes ch.
+func (s *subscriber) forward() {
+	defer close(s.ch)
+	for {
+		s.mu.Lock()
+		if len(s.queue) == 0 {
+			s.mu.Unlock()
+			select {
+			case <-s.wake:
+				continue
+			case <-s.done:
+				return
+			}
+		}
+		ev := s.queue[0]
+		s.queue = s.queue[1:]
+		s.mu.Unlock()
+
+		select {
+		case s.ch <- ev:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// publish makes st the current status and sends an event to the subscribers. work is the
+// context of the load or analysis reporting it, if any; once that work has been cancelled
+// or replaced by newer work, its updates are dropped.
+func (p *Processor) publish(work context.Context, kind EventKind, st ProcessingStatus) {
+	p.publishEvent(work, Event{Kind: kind, Status: st})
+}
+
+func (p *Processor) publishEvent(work context.Context, ev Event) {
+	// Holding subMu throughout keeps events in the same order as the status changes.
+	p.subMu.Lock()
+	defer p.subMu.Unlock()
+
+	p.mu.Lock()
+	if work != nil && work != p.work {
+		p.mu.Unlock()
+		return
+	}
+	p.status = ev.Status
+	if ev.Kind == EventProgress {
+		// Keep the status current, but don't flood subscribers with every chunk read.
+		if ev.Status.Progress < 1 && time.Since(p.lastProgress) < progressInterval {
+			p.mu.Unlock()
+			return
+		}
+		p.lastProgress = time.Now()
+	}
+	p.mu.Unlock()
+	p.send(ev)
+}
+
+// announce sends an event of the given kind with the current status, leaving it as it is.
+func (p *Processor) announce(kind EventKind) {
+	p.subMu.Lock()
+	defer p.subMu.Unlock()
+
+	p.mu.RLock()
+	ev := Event{Kind: kind, Status: p.status}
+	p.mu.RUnlock()
+	p.send(ev)
+}
+
+// send queues ev for the subscribers. It must be called with p.subMu held.
+func (p *Processor) send(ev Event) {
+	for s := range p.subscribers {
+		s.push(ev)
+	}
+}
@@ -1,15 +1,17 @@
 package audio
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"gowav/internal/cache"
 	"io"
-	"net/http"
 	"os"
 	"time"
 )
 
 // loadFromFile reads bytes from a local file, updating progress in the Processor status.
-func (p *Processor) loadFromFile(path string, cancelChan chan struct{}) ([]byte, error) {
+func (p *Processor) loadFromFile(ctx context.Context, path string) ([]byte, error) {
 	startTime := time.Now()
 	file, err := os.Open(path)
 	if err != nil {
@@ -28,10 +30,8 @@ func (p *Processor) loadFromFile(path string, cancelChan chan struct{}) ([]byte,
 	readStart := time.Now()
 
 	for {
-		select {
-		case <-cancelChan:
-			return nil, fmt.Errorf("cancelled")
-		default:
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("read cancelled: %w", err)
 		}
 
 		n, err := file.Read(buf)
@@ -45,8 +45,7 @@ func (p *Processor) loadFromFile(path string, cancelChan chan struct{}) ([]byte,
 				remainingBytes := info.Size() - totalRead
 				eta := time.Duration(float64(remainingBytes)/bytesPerSec) * time.Second
 
-				p.mu.Lock()
-				p.status = ProcessingStatus{
+				p.publish(ctx, EventProgress, ProcessingStatus{
 					State:       StateLoading,
 					Message:     fmt.Sprintf("Loading file... (ETA: %s)", formatETA(eta)),
 					Progress:    float64(totalRead) / float64(info.Size()),
@@ -54,14 +53,10 @@ func (p *Processor) loadFromFile(path string, cancelChan chan struct{}) ([]byte,
 					StartTime:   readStart,
 					BytesLoaded: totalRead,
 					TotalBytes:  info.Size(),
-				}
-				p.mu.Unlock()
+				})
 			}
 		}
 
-		// Brief sleep to let the UI update progress
-		time.Sleep(40 * time.Millisecond)
-
 		if err == io.EOF {
 			break
 		}
@@ -75,83 +70,119 @@ func (p *Processor) loadFromFile(path string, cancelChan chan struct{}) ([]byte,
 	return data, nil
 }
 
-// loadFromURL downloads bytes from a given URL, updating progress in the Processor status.
-func (p *Processor) loadFromURL(url string, cancelChan chan struct{}) ([]byte, error) {
-	startTime := time.Now()
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-	}
+// loadFromURL starts streaming a URL and waits until enough of it has downloaded to read
+// the metadata and start playing. The rest downloads in the background. A live broadcast
+// comes back as a Radio instead, which goes on receiving until it is closed.
+func (p *Processor) loadFromURL(ctx context.Context, url string) (*Stream, *Radio, *Metadata, error) {
+	p.mu.RLock()
+	client := p.httpClient
+	p.mu.RUnlock()
 
-	req, err := http.NewRequest("GET", url, nil)
+	st, radio, err := openURL(ctx, client, url)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, nil, nil, err
 	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to download: %w", err)
+	if radio != nil {
+		md, err := p.tuneIn(ctx, radio)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return nil, radio, md, nil
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("server returned %d", resp.StatusCode)
+	// Closing the stream on cancellation also wakes any read waiting on it below.
+	stop := context.AfterFunc(ctx, st.Close)
+	fail := func(err error) (*Stream, *Radio, *Metadata, error) {
+		stop()
+		st.Close()
+		return nil, nil, nil, err
 	}
 
-	data := make([]byte, 0)
-	buf := make([]byte, 32*1024)
-	var totalRead int64
 	readStart := time.Now()
-
-	contentLength := resp.ContentLength
-
 	for {
-		select {
-		case <-cancelChan:
-			return nil, fmt.Errorf("cancelled")
-		default:
+		ready, err := st.waitBuffered(ctx, streamStartBuffer, progressInterval)
+		if err != nil {
+			return fail(err)
 		}
+		status := st.Status()
+		target := int64(streamStartBuffer)
+		if status.Size >= 0 && status.Size < target {
+			target = status.Size
+		}
+		progress := 1.0
+		if target > 0 && status.Downloaded < target {
+			progress = float64(status.Downloaded) / float64(target)
+		}
+		p.publish(ctx, EventProgress, ProcessingStatus{
+			State:       StateLoading,
+			Message:     "Buffering...",
+			Progress:    progress,
+			CanCancel:   true,
+			StartTime:   readStart,
+			BytesLoaded: status.Downloaded,
+			TotalBytes:  target,
+		})
+		if ready {
+			break
+		}
+	}
 
-		n, err := resp.Body.Read(buf)
-		if n > 0 {
-			data = append(data, buf[:n]...)
-			totalRead += int64(n)
-
-			if contentLength > 0 {
-				elapsed := time.Since(readStart)
-				if elapsed > 0 {
-					bytesPerSec := float64(totalRead) / elapsed.Seconds()
-					remainingBytes := contentLength - totalRead
-					eta := time.Duration(float64(remainingBytes)/bytesPerSec) * time.Second
-
-					p.mu.Lock()
-					p.status = ProcessingStatus{
-						State:       StateLoading,
-						Message:     fmt.Sprintf("Downloading... (ETA: %s)", formatETA(eta)),
-						Progress:    float64(totalRead) / float64(contentLength),
-						CanCancel:   true,
-						StartTime:   readStart,
-						BytesLoaded: totalRead,
-						TotalBytes:  contentLength,
-					}
-					p.mu.Unlock()
-				}
-			}
+	md, err := streamMetadata(st)
+	if err != nil {
+		if ctx.Err() != nil {
+			return fail(fmt.Errorf("download cancelled: %w", ctx.Err()))
 		}
+		return fail(fmt.Errorf("metadata extraction failed: %w", err))
+	}
+	if !stop() {
+		// Cancelled just now; the stream is already closed.
+		return nil, nil, nil, fmt.Errorf("download cancelled: %w", ctx.Err())
+	}
+	logDebug("URL %s buffered in %v, streaming the rest", url, time.Since(readStart))
+	return st, nil, md, nil
+}
 
-		// Let the UI update in between chunk reads
-		time.Sleep(40 * time.Millisecond)
+// tuneIn waits for a live station's first audio and reads its metadata. The Radio is
+// closed if that fails.
+func (p *Processor) tuneIn(ctx context.Context, radio *Radio) (*Metadata, error) {
+	p.publish(ctx, EventProgress, ProcessingStatus{
+		State:     StateLoading,
+		Message:   "Tuning in...",
+		CanCancel: true,
+		StartTime: time.Now(),
+	})
+
+	// Closing the radio on cancellation wakes the decoder waiting for audio.
+	stop := context.AfterFunc(ctx, radio.Close)
+	md, err := radioMetadata(radio)
+	if !stop() {
+		return nil, fmt.Errorf("download cancelled: %w", ctx.Err())
+	}
+	if err != nil {
+		radio.Close()
+		return nil, fmt.Errorf("metadata extraction failed: %w", err)
+	}
+	return md, nil
+}
 
-		if err == io.EOF {
-			break
-		}
+// waitForStream waits for a stream to finish downloading before it is analysed, showing
+// the download's progress as an analysis stage.
+func (p *Processor) waitForStream(ctx context.Context, st *Stream) ([]byte, error) {
+	for {
+		data, done, err := st.waitComplete(ctx, progressInterval)
 		if err != nil {
-			return nil, fmt.Errorf("download error: %w", err)
+			return nil, err
 		}
+		if done {
+			return data, nil
+		}
+		status := st.Status()
+		progress := 0.0
+		if status.Size > 0 {
+			progress = float64(status.Downloaded) / float64(status.Size)
+		}
+		p.updateAnalysisProgress(ctx, progress, "Waiting for the download to finish...")
 	}
-
-	totalLoadTime := time.Since(startTime)
-	logDebug("URL %s downloaded in %v (size=%d bytes)", url, totalLoadTime, totalRead)
-	return data, nil
 }
 
 // formatETA is a helper that turns a duration into a human-friendly ETA string (e.g. "10 seconds").
@@ -167,3 +198,34 @@ func formatETA(d time.Duration) string {
 	}
 	return fmt.Sprintf("%.0f seconds", sec)
 }
+
+// cachedCopy returns the path of url's cached copy, if the cache has one.
+func (p *Processor) cachedCopy(url string) (string, bool) {
+	p.mu.RLock()
+	c := p.cache
+	p.mu.RUnlock()
+	if c == nil {
+		return url, false
+	}
+	if path, ok := c.Lookup(url); ok {
+		logDebug("Loading %s from the cache", url)
+		return path, true
+	}
+	return url, false
+}
+
+// storeInCache adds a streamed file to the cache once it has downloaded. A download that
+// is stopped early, because another track was loaded, isn't stored.
+func storeInCache(c *cache.Cache, url string, st *Stream, md *Metadata) {
+	data, err := st.Bytes(context.Background())
+	if err != nil {
+		return
+	}
+	title := md.Title
+	if md.Artist != "" && title != "" {
+		title = md.Artist + " - " + title
+	}
+	if _, err := c.Put(url, title, bytes.NewReader(data), false); err != nil {
+		logDebug("Failed to cache %s: %v", url, err)
+	}
+}
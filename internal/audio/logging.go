@@ -4,28 +4,51 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 )
 
-var logFile *os.File
+var (
+	logMu   sync.Mutex
+	logFile *os.File
+	// logDir overrides the default ~/.gowav/logs when set.
+	logDir string
+)
+
+// SetLogDir changes where debug logs are written. The next message opens a new file there.
+func SetLogDir(dir string) {
+	logMu.Lock()
+	defer logMu.Unlock()
+	if dir == logDir {
+		return
+	}
+	logDir = dir
+	if logFile != nil {
+		logFile.Close()
+		logFile = nil
+	}
+}
 
-// initLogging opens a timestamped log file in the user’s home directory (~/.gowav/logs).
+// initLogging opens a timestamped log file in the log directory (~/.gowav/logs by default).
+// The caller must hold logMu.
 func initLogging() error {
 	if logFile != nil {
 		return nil
 	}
 
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return err
+	dir := logDir
+	if dir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		dir = filepath.Join(homeDir, ".gowav", "logs")
 	}
-
-	logDir := filepath.Join(homeDir, ".gowav", "logs")
-	if err := os.MkdirAll(logDir, 0755); err != nil {
+	if err := os.MkdirAll(dir, 0755); err != nil {
 		return err
 	}
 
-	logPath := filepath.Join(logDir, fmt.Sprintf("gowav_%s.log",
+	logPath := filepath.Join(dir, fmt.Sprintf("gowav_%s.log",
 		time.Now().Format("2006-01-02_15-04-05")))
 
 	f, err := os.OpenFile(logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
@@ -39,6 +62,8 @@ func initLogging() error {
 
 // logDebug writes a debug-level message to the log file, if it’s successfully opened.
 func logDebug(format string, args ...interface{}) {
+	logMu.Lock()
+	defer logMu.Unlock()
 	if err := initLogging(); err != nil {
 		return
 	}
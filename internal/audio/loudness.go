@@ -0,0 +1,47 @@
+package audio
+
+import (
+	"fmt"
+	"math"
+)
+
+// MinLevel is the lowest level reported, in dBFS: the noise floor of 16-bit audio.
+const MinLevel = -96.0
+
+// Loudness summarises the level of a decoded track, in dBFS.
+type Loudness struct {
+	// Peak is the highest absolute sample over all channels.
+	Peak float64
+	// RMS is the root mean square over all samples of all channels.
+	RMS float64
+}
+
+// AnalyzeLoudness measures the peak and RMS level of the decoded Channels.
+// AnalyzeWaveform must have run first.
+func (m *Model) AnalyzeLoudness() (Loudness, error) {
+	if len(m.Channels) == 0 || len(m.Channels[0]) == 0 {
+		return Loudness{}, fmt.Errorf("no decoded audio")
+	}
+
+	var peak, sum float64
+	var n int
+	for _, ch := range m.Channels {
+		for _, v := range ch {
+			peak = math.Max(peak, math.Abs(v))
+			sum += v * v
+		}
+		n += len(ch)
+	}
+	return Loudness{
+		Peak: toDBFS(peak),
+		RMS:  toDBFS(math.Sqrt(sum / float64(n))),
+	}, nil
+}
+
+// toDBFS converts a linear level, where 1 is full scale, to dBFS no lower than MinLevel.
+func toDBFS(level float64) float64 {
+	if level <= 0 {
+		return MinLevel
+	}
+	return math.Max(20*math.Log10(level), MinLevel)
+}
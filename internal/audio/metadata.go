@@ -5,11 +5,12 @@ import (
 	"fmt"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/dhowden/tag"
-	"github.com/hajimehoshi/go-mp3"
 	"image"
 	"image/jpeg"
 	"image/png"
 	"io"
+	"os"
+	"strconv"
 	"strings"
 	"time"
 	"unicode/utf8"
@@ -37,6 +38,7 @@ type Metadata struct {
 	TSRC        string
 	EncodedBy   string
 	ReleaseDate string
+	Format      string
 	Duration    time.Duration
 	BitRate     int
 	SampleRate  int
@@ -46,57 +48,179 @@ type Metadata struct {
 	ArtworkMIME string
 	ArtworkSize image.Point
 	Artwork     image.Image
+	// ArtworkData is the embedded image as stored in the file, before decoding.
+	ArtworkData []byte
 	BPM         string
 	Lyrics      string
 	RawTags     map[string]interface{}
+	// Live is set for a live broadcast, which has no duration; Station is its name.
+	Live    bool
+	Station string
 }
 
-// ExtractMetadata reads tags (e.g. ID3) and basic audio info (duration, sample rate, etc.) from raw MP3 data.
+// ExtractMetadata reads tags (e.g. ID3 or Vorbis comments) and basic audio info (duration, sample rate, etc.)
+// from raw file data in any format with a registered Decoder.
 func ExtractMetadata(data []byte) (*Metadata, error) {
-	reader := bytes.NewReader(data)
-	m, err := tag.ReadFrom(reader)
+	props, err := extractAudioProperties(data)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read metadata: %w", err)
+		return nil, fmt.Errorf("failed to read audio stream: %w", err)
 	}
+	return readTags(bytes.NewReader(data), props, int64(len(data))), nil
+}
+
+// probeSize is how much of a local file, after any ID3v2 tag, is decoded to estimate the
+// duration of a format that doesn't state its length up front.
+const probeSize = 1 << 20
+
+// ReadFileMetadata reads tags and audio properties from the file at path without loading
+// all of it. Formats that don't state their length get a duration estimated from the
+// start of the file, as for a stream still downloading.
+func ReadFileMetadata(path string) (*Metadata, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+
+	header := make([]byte, 10)
+	n, err := f.ReadAt(header, 0)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	probe := int64(id3v2Len(header[:n]) + probeSize)
+	if probe > size {
+		probe = size
+	}
+	prefix := make([]byte, probe)
+	n, err = f.ReadAt(prefix, 0)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	prefix = prefix[:n]
 
+	d, err := DetectDecoder(prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audio stream: %w", err)
+	}
+	src, err := d.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audio stream: %s: %w", d.Name(), err)
+	}
+	props := AudioProperties{Format: d.Name(), SampleRate: src.SampleRate(), Channels: src.Channels()}
+	if props.SampleRate <= 0 {
+		return nil, fmt.Errorf("invalid sample rate %d", props.SampleRate)
+	}
+	if frames := src.Length(); frames >= 0 {
+		props.Duration = framesToDuration(frames, props.SampleRate)
+	} else {
+		props.Duration = estimateDuration(prefix, size)
+	}
+	if secs := props.Duration.Seconds(); secs > 0 {
+		props.BitRate = int(float64(size*8) / secs / 1000)
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return readTags(f, props, size), nil
+}
+
+// streamMetadata reads tags and audio properties from a Stream that may still be downloading.
+// Formats that don't state their length up front get a duration estimated from the bit
+// rate of what has arrived so far.
+func streamMetadata(st *Stream) (*Metadata, error) {
+	src, format, err := st.decode()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audio stream: %w", err)
+	}
+	props := AudioProperties{Format: format, SampleRate: src.SampleRate(), Channels: src.Channels()}
+	if props.SampleRate <= 0 {
+		return nil, fmt.Errorf("invalid sample rate %d", props.SampleRate)
+	}
+
+	size := st.Status().Size
+	if frames := src.Length(); frames >= 0 {
+		props.Duration = framesToDuration(frames, props.SampleRate)
+	} else if size > 0 {
+		props.Duration = estimateDuration(st.prefix(), size)
+	}
+	if secs := props.Duration.Seconds(); secs > 0 && size > 0 {
+		props.BitRate = int(float64(size*8) / secs / 1000)
+	}
+	return readTags(st.NewReader(), props, size), nil
+}
+
+// radioMetadata describes a live station from the start of its stream and what it has said
+// about itself. Title and Artist follow the song playing; see setStreamTitle.
+func radioMetadata(r *Radio) (*Metadata, error) {
+	src, format, err := r.decode()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audio stream: %w", err)
+	}
+	info := r.Info()
+	md := &Metadata{
+		Format:     format,
+		BitRate:    info.BitRate,
+		SampleRate: src.SampleRate(),
+		Channels:   src.Channels(),
+		Genre:      info.Genre,
+		Live:       true,
+		Station:    info.Name,
+	}
+	md.setStreamTitle(info.Title)
+	return md, nil
+}
+
+// setStreamTitle sets Title and Artist from a live stream's "Artist - Title".
+func (m *Metadata) setStreamTitle(title string) {
+	if artist, song, ok := strings.Cut(title, " - "); ok {
+		m.Artist, m.Title = strings.TrimSpace(artist), strings.TrimSpace(song)
+	} else {
+		m.Artist, m.Title = "", title
+	}
+}
+
+// estimateDuration scales the duration of the start of a file, prefix, up to its full size.
+func estimateDuration(prefix []byte, size int64) time.Duration {
+	tagSize := id3v2Size(prefix)
+	props, err := extractAudioProperties(prefix)
+	if err != nil || props.Duration <= 0 || len(prefix) <= tagSize {
+		return 0
+	}
+	return time.Duration(float64(props.Duration) * float64(size-int64(tagSize)) / float64(len(prefix)-tagSize))
+}
+
+// readTags builds the Metadata for a file of the given size from its properties and the
+// tags read from r.
+func readTags(r io.ReadSeeker, props AudioProperties, size int64) *Metadata {
 	metadata := &Metadata{
-		Title:       tryDecode(m.Title()),
-		Artist:      tryDecode(m.Artist()),
-		Album:       tryDecode(m.Album()),
-		Year:        m.Year(),
-		Genre:       tryDecode(m.Genre()),
-		FileSize:    int64(len(data)),
-		AlbumArtist: tryDecode(m.AlbumArtist()),
-	}
-
-	// Attempt to decode as MP3 to get sample rate, approximate duration, etc.
-	reader.Seek(0, io.SeekStart)
-	decoder, err := mp3.NewDecoder(reader)
-	if err == nil {
-		var totalPCMFrames int64
-		buf := make([]byte, 8192)
-		for {
-			n, readErr := decoder.Read(buf)
-			if n > 0 {
-				// 4 bytes per stereo frame (16-bit left + 16-bit right)
-				totalPCMFrames += int64(n / 4)
-			}
-			if readErr == io.EOF {
-				break
-			}
-			if readErr != nil {
-				break
-			}
-		}
-		sampleRate := decoder.SampleRate()
-		if totalPCMFrames > 0 && sampleRate > 0 {
-			metadata.Duration = time.Duration(float64(totalPCMFrames) / float64(sampleRate) * float64(time.Second))
-			metadata.SampleRate = sampleRate
-			metadata.Channels = 2
-			metadata.BitRate = int(float64(len(data)*8) / metadata.Duration.Seconds() / 1000)
-		}
+		Format:     props.Format,
+		Duration:   props.Duration,
+		BitRate:    props.BitRate,
+		SampleRate: props.SampleRate,
+		Channels:   props.Channels,
+		FileSize:   max(size, 0),
 	}
 
+	m, err := tag.ReadFrom(r)
+	if err != nil {
+		// Plain WAV and AIFF files usually carry no tags the tag reader understands.
+		logDebug("No tags read (%s): %v", props.Format, err)
+		return metadata
+	}
+
+	metadata.Title = tryDecode(m.Title())
+	metadata.Artist = tryDecode(m.Artist())
+	metadata.Album = tryDecode(m.Album())
+	metadata.Year = m.Year()
+	metadata.Genre = tryDecode(m.Genre())
+	metadata.AlbumArtist = tryDecode(m.AlbumArtist())
+
 	// If Raw() is not nil, we can read specific ID3 frames/tags.
 	if rawTags := m.Raw(); rawTags != nil {
 		metadata.RawTags = rawTags
@@ -113,7 +237,7 @@ func ExtractMetadata(data []byte) (*Metadata, error) {
 			logDebug("Found APIC tag, type: %T", apicData)
 			switch pic := apicData.(type) {
 			case tag.Picture:
-				logDebug("Processing tag.Picture: MIMEType=%s, Type=%d, Description=%s, DataLen=%d",
+				logDebug("Processing tag.Picture: MIMEType=%s, Type=%s, Description=%s, DataLen=%d",
 					pic.MIMEType, pic.Type, pic.Description, len(pic.Data))
 				if len(pic.Data) > 0 {
 					if err := extractAndSetArtwork(metadata, pic.Data, pic.MIMEType); err != nil {
@@ -122,7 +246,7 @@ func ExtractMetadata(data []byte) (*Metadata, error) {
 				}
 			case *tag.Picture:
 				if pic != nil {
-					logDebug("Processing *tag.Picture: MIMEType=%s, Type=%d, Description=%s, DataLen=%d",
+					logDebug("Processing *tag.Picture: MIMEType=%s, Type=%s, Description=%s, DataLen=%d",
 						pic.MIMEType, pic.Type, pic.Description, len(pic.Data))
 					if len(pic.Data) > 0 {
 						if err := extractAndSetArtwork(metadata, pic.Data, pic.MIMEType); err != nil {
@@ -160,8 +284,42 @@ func ExtractMetadata(data []byte) (*Metadata, error) {
 			logDebug("No APIC tag found in metadata")
 		}
 	}
+	// Vorbis comments and MP4 atoms have no TRCK/TPOS frames.
+	if metadata.Track == "" {
+		if n, _ := m.Track(); n > 0 {
+			metadata.Track = strconv.Itoa(n)
+		}
+	}
 
-	return metadata, nil
+	// FLAC and Ogg keep pictures outside the raw ID3 frames.
+	if !metadata.HasArtwork {
+		if pic := m.Picture(); pic != nil && len(pic.Data) > 0 {
+			if err := extractAndSetArtwork(metadata, pic.Data, pic.MIMEType); err != nil {
+				logDebug("Failed to extract artwork from picture block: %v", err)
+			}
+		}
+	}
+
+	return metadata
+}
+
+// fillMissing copies title, artist, album, duration and station from hint where m has none.
+func (m *Metadata) fillMissing(hint *Metadata) {
+	if m.Title == "" {
+		m.Title = hint.Title
+	}
+	if m.Artist == "" {
+		m.Artist = hint.Artist
+	}
+	if m.Album == "" {
+		m.Album = hint.Album
+	}
+	if m.Duration == 0 {
+		m.Duration = hint.Duration
+	}
+	if m.Station == "" {
+		m.Station = hint.Station
+	}
 }
 
 // BuildLoadInfo returns a “partial table” of metadata, plus optional artwork info if large enough.
@@ -206,6 +364,9 @@ func (m *Metadata) renderTable(width int, includeRaw bool, includeArtworkMeta bo
 	sep := "├" + strings.Repeat("─", headerWidth) + "┤\n"
 	b.WriteString(sep)
 
+	if m.Station != "" {
+		writeInfoSection(b, "Station", m.Station, headerWidth)
+	}
 	writeInfoSection(b, "Title", m.Title, headerWidth)
 	writeInfoSection(b, "Artist", m.Artist, headerWidth)
 	writeInfoSection(b, "Album", m.Album, headerWidth)
@@ -237,11 +398,18 @@ func (m *Metadata) renderTable(width int, includeRaw bool, includeArtworkMeta bo
 		strings.Repeat(" ", headerWidth-tPad-len(techTitle)) + "│\n")
 	b.WriteString(sep)
 
-	writeInfoSection(b, "Duration", formatDuration(m.Duration), headerWidth)
+	writeInfoSection(b, "Format", strings.ToUpper(m.Format), headerWidth)
+	if m.Live {
+		writeInfoSection(b, "Duration", "Live", headerWidth)
+	} else {
+		writeInfoSection(b, "Duration", formatDuration(m.Duration), headerWidth)
+	}
 	writeInfoSection(b, "Bit Rate", fmt.Sprintf("%d kb/s", m.BitRate), headerWidth)
 	writeInfoSection(b, "Sample Rate", fmt.Sprintf("%d Hz", m.SampleRate), headerWidth)
 	writeInfoSection(b, "Channels", fmt.Sprintf("%d", m.Channels), headerWidth)
-	writeInfoSection(b, "File Size", formatFileSize(m.FileSize), headerWidth)
+	if !m.Live {
+		writeInfoSection(b, "File Size", formatFileSize(m.FileSize), headerWidth)
+	}
 
 	if includeArtworkMeta && m.HasArtwork {
 		b.WriteString(sep)
@@ -377,6 +545,7 @@ func extractAndSetArtwork(metadata *Metadata, data []byte, mimeType string) erro
 	}
 
 	metadata.Artwork = img
+	metadata.ArtworkData = imgData
 	metadata.HasArtwork = true
 	if mimeType != "" {
 		metadata.ArtworkMIME = mimeType
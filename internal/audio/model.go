@@ -1,7 +1,7 @@
 package audio
 
 import (
-	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"math"
@@ -9,12 +9,13 @@ import (
 	"sync"
 	"time"
 
-	"github.com/hajimehoshi/go-mp3"
 	"gonum.org/v1/gonum/dsp/fourier"
 )
 
 // Model represents the raw PCM data plus FFT outputs and beat/onset analysis results.
 type Model struct {
+	// Channels holds the decoded samples of each channel in [-1, 1]; RawData is their mono mix.
+	Channels   [][]float64
 	RawData    []float64
 	SampleRate int
 
@@ -51,85 +52,105 @@ func (m *Model) SetParameters(windowSize, hopSize, fftSize int) {
 	m.fftSize = fftSize
 }
 
-// decodeMP3ToPCM converts MP3 bytes to a mono float64 slice.
-func decodeMP3ToPCM(
-	mp3Bytes []byte,
+// decodeChannels drains a PCMSource into one float64 slice per channel.
+func decodeChannels(
+	ctx context.Context,
+	src PCMSource,
 	progressFn func(float64),
-	cancelChan chan struct{},
-) ([]float64, int, error) {
+) ([][]float64, error) {
 
-	reader := bytes.NewReader(mp3Bytes)
-	dec, err := mp3.NewDecoder(reader)
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to init mp3 decoder: %w", err)
-	}
-
-	sampleRate := dec.SampleRate() // often 44100 or 48000
-	const bytesPerSample = 2
-	const channels = 2
-	frameSize := bytesPerSample * channels
+	channels := src.Channels()
+	frameBytes := frameSize(src)
+	totalFrames := src.Length()
 
-	var pcm []float64
-	totalSize := int64(len(mp3Bytes))
-	var totalRead int64
+	pcm := make([][]float64, channels)
+	if totalFrames > 0 {
+		for ch := range pcm {
+			pcm[ch] = make([]float64, 0, totalFrames)
+		}
+	}
 
-	buf := make([]byte, 8192)
+	buf := make([]byte, 8192-8192%frameBytes)
+	var pending int
 	for {
-		select {
-		case <-cancelChan:
-			return nil, 0, fmt.Errorf("decode cancelled")
-		default:
-		}
-
-		n, readErr := dec.Read(buf)
-		if n > 0 {
-			frames := n / frameSize
-			for i := 0; i < frames; i++ {
-				left := int16(buf[i*4+0]) | (int16(buf[i*4+1]) << 8)
-				right := int16(buf[i*4+2]) | (int16(buf[i*4+3]) << 8)
-				mono := float64(left+right) * 0.5
-				mono /= 32768.0
-				pcm = append(pcm, mono)
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("decode cancelled: %w", err)
+		}
+
+		n, readErr := src.Read(buf[pending:])
+		n += pending
+		frames := n / frameBytes
+		for i := 0; i < frames; i++ {
+			frame := buf[i*frameBytes : (i+1)*frameBytes]
+			for ch := 0; ch < channels; ch++ {
+				pcm[ch] = append(pcm[ch], sampleToFloat(frame[ch*bytesPerSample], frame[ch*bytesPerSample+1]))
 			}
-			totalRead += int64(n)
+		}
+		// Keep a trailing partial frame for the next read.
+		pending = copy(buf, buf[frames*frameBytes:n])
 
-			if progressFn != nil && totalSize > 0 {
-				fraction := float64(totalRead) / float64(totalSize)
-				if fraction > 1.0 {
-					fraction = 1.0
-				}
-				progressFn(fraction)
+		if progressFn != nil && totalFrames > 0 {
+			fraction := float64(len(pcm[0])) / float64(totalFrames)
+			if fraction > 1.0 {
+				fraction = 1.0
 			}
+			progressFn(fraction)
 		}
 
 		if readErr == io.EOF {
 			break
 		}
 		if readErr != nil {
-			return nil, 0, fmt.Errorf("decode mp3 read error: %w", readErr)
+			return nil, fmt.Errorf("decode read error: %w", readErr)
 		}
 	}
 
-	return pcm, sampleRate, nil
+	return pcm, nil
+}
+
+// mixToMono averages the channels sample by sample.
+func mixToMono(channels [][]float64) []float64 {
+	if len(channels) == 1 {
+		return channels[0]
+	}
+	mono := make([]float64, len(channels[0]))
+	for _, ch := range channels {
+		for i, v := range ch {
+			mono[i] += v
+		}
+	}
+	scale := 1 / float64(len(channels))
+	for i := range mono {
+		mono[i] *= scale
+	}
+	return mono
 }
 
-// AnalyzeWaveform decodes MP3 data into RawData.
+// AnalyzeWaveform decodes the file bytes into Channels and RawData using the same PCMSource as playback.
 func (m *Model) AnalyzeWaveform(
-	mp3Bytes []byte,
+	ctx context.Context,
+	fileBytes []byte,
 	progressFn func(float64),
-	cancelChan chan struct{},
 ) error {
 
 	startTime := time.Now()
 
-	pcmSamples, sr, err := decodeMP3ToPCM(mp3Bytes, func(frac float64) {
+	src, err := NewPCMSource(fileBytes)
+	if err != nil {
+		return fmt.Errorf("decode error: %w", err)
+	}
+	sr := src.SampleRate()
+
+	channels, err := decodeChannels(ctx, src, func(frac float64) {
 		if progressFn != nil {
 			progressFn(frac * 0.95)
 		}
-	}, cancelChan)
+	})
 	if err != nil {
 		return fmt.Errorf("decode error: %w", err)
 	}
+	pcmSamples := mixToMono(channels)
+	m.Channels = channels
 	m.RawData = pcmSamples
 	m.SampleRate = sr
 
@@ -137,17 +158,23 @@ func (m *Model) AnalyzeWaveform(
 		progressFn(1.0)
 	}
 
-	logDebug("AnalyzeWaveform: decoded PCM has %d samples at sr=%d (%.2f sec)",
-		len(pcmSamples), sr, float64(len(pcmSamples))/float64(sr))
+	logDebug("AnalyzeWaveform: decoded PCM has %d samples x %d channels at sr=%d (%.2f sec)",
+		len(pcmSamples), len(channels), sr, float64(len(pcmSamples))/float64(sr))
 	logDebug("Waveform analysis took %v", time.Since(startTime))
 	return nil
 }
 
 // AnalyzeSpectrum runs a short-time FFT over RawData, populating FFTData + FreqBands.
+// If it fails or is cancelled, those and the spectral features are left empty.
 func (m *Model) AnalyzeSpectrum(
+	ctx context.Context,
 	progressFn func(float64),
-	cancelChan chan struct{},
-) error {
+) (err error) {
+	defer func() {
+		if err != nil {
+			m.FFTData, m.SpectralFlux, m.PeakFrequencies, m.RMSEnergy = nil, nil, nil, nil
+		}
+	}()
 
 	if m.SampleRate <= 0 {
 		return fmt.Errorf("invalid sample rate (%d)", m.SampleRate)
@@ -179,7 +206,7 @@ func (m *Model) AnalyzeSpectrum(
 	// Start parallel workers
 	for i := 0; i < numCPU; i++ {
 		wg.Add(1)
-		go m.fftWorker(realFFT, windowChan, &wg, progressFn, cancelChan, errChan, numWindows)
+		go m.fftWorker(ctx, realFFT, windowChan, &wg, progressFn, errChan, numWindows)
 	}
 
 	// Feed window indices
@@ -187,31 +214,26 @@ func (m *Model) AnalyzeSpectrum(
 		defer close(windowChan)
 		for w := 0; w < numWindows; w++ {
 			select {
-			case <-cancelChan:
+			case <-ctx.Done():
 				return
-			default:
-				windowChan <- w
+			case windowChan <- w:
 			}
 		}
 	}()
 
-	done := make(chan struct{})
-	go func() {
-		wg.Wait()
-		close(done)
-	}()
-
-	// Wait for completion or cancellation
+	// Workers stop at the next window once cancelled; wait for them so none writes after we return.
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("analysis cancelled: %w", err)
+	}
 	select {
-	case <-done:
-	case <-cancelChan:
-		return fmt.Errorf("analysis cancelled")
 	case err := <-errChan:
 		return err
+	default:
 	}
 
 	// Compute spectral features
-	if err := m.calculateSpectralFeatures(cancelChan, progressFn); err != nil {
+	if err := m.calculateSpectralFeatures(ctx, progressFn); err != nil {
 		return err
 	}
 
@@ -229,11 +251,11 @@ func (m *Model) initFrequencyBands() {
 
 // fftWorker applies a Hanning window, runs FFT, and stores amplitude results for a subset of frames.
 func (m *Model) fftWorker(
+	ctx context.Context,
 	realFFT *fourier.FFT,
 	windowChan chan int,
 	wg *sync.WaitGroup,
 	progressFn func(float64),
-	cancelChan chan struct{},
 	errChan chan error,
 	totalWindows int,
 ) {
@@ -244,14 +266,8 @@ func (m *Model) fftWorker(
 	logDebug("fftWorker started. totalWindows=%d", totalWindows)
 
 	for windowIdx := range windowChan {
-		select {
-		case <-cancelChan:
-			select {
-			case errChan <- fmt.Errorf("cancelled"):
-			default:
-			}
+		if ctx.Err() != nil {
 			return
-		default:
 		}
 
 		startSample := windowIdx * m.hopSize
@@ -292,7 +308,7 @@ func (m *Model) fftWorker(
 }
 
 // calculateSpectralFeatures extracts flux, peak frequencies, and RMS energy from the FFT slices.
-func (m *Model) calculateSpectralFeatures(cancelChan chan struct{}, progressFn func(float64)) error {
+func (m *Model) calculateSpectralFeatures(ctx context.Context, progressFn func(float64)) error {
 	logDebug("calculateSpectralFeatures: starting for %d frames", len(m.FFTData))
 
 	numFrames := len(m.FFTData)
@@ -301,10 +317,8 @@ func (m *Model) calculateSpectralFeatures(cancelChan chan struct{}, progressFn f
 	m.RMSEnergy = make([]float64, numFrames)
 
 	for i := 0; i < numFrames; i++ {
-		select {
-		case <-cancelChan:
-			return fmt.Errorf("cancelled")
-		default:
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("spectral analysis cancelled: %w", err)
 		}
 		if i > 0 {
 			m.SpectralFlux[i] = m.calculateFlux(m.FFTData[i], m.FFTData[i-1])
@@ -352,20 +366,26 @@ func (m *Model) calculateRMSEnergy(spectrum []float64) float64 {
 }
 
 // AnalyzeBeats calls AnalyzeSpectrum if needed, then processes onsets to estimate tempo and refine beat info.
+// If beat detection fails or is cancelled, the beat data is left empty; a finished spectrum is kept.
 func (m *Model) AnalyzeBeats(
+	ctx context.Context,
 	progressFn func(float64),
-	cancelChan chan struct{},
-) error {
+) (err error) {
+	defer func() {
+		if err != nil {
+			m.BeatData, m.BeatOnsets, m.EstimatedTempo = nil, nil, 0
+		}
+	}()
 
 	if m.SampleRate <= 0 {
 		return fmt.Errorf("invalid sample rate: %d", m.SampleRate)
 	}
 	if len(m.FFTData) == 0 {
-		err := m.AnalyzeSpectrum(func(frac float64) {
+		err := m.AnalyzeSpectrum(ctx, func(frac float64) {
 			if progressFn != nil {
 				progressFn(frac * 0.6)
 			}
-		}, cancelChan)
+		})
 		if err != nil {
 			return err
 		}
@@ -379,17 +399,17 @@ func (m *Model) AnalyzeBeats(
 	m.BeatData = make([]float64, numFrames)
 	m.BeatOnsets = make([]bool, numFrames)
 
-	if err := m.calculateOnsetFunction(progressFn, cancelChan); err != nil {
+	if err := m.calculateOnsetFunction(ctx, progressFn); err != nil {
 		return err
 	}
 
-	return m.detectBeats(progressFn, cancelChan)
+	return m.detectBeats(ctx, progressFn)
 }
 
 // calculateOnsetFunction uses a rolling approach to detect transient energy for the beat envelope.
 func (m *Model) calculateOnsetFunction(
+	ctx context.Context,
 	progressFn func(float64),
-	cancelChan chan struct{},
 ) error {
 
 	numFrames := len(m.FFTData)
@@ -400,7 +420,6 @@ func (m *Model) calculateOnsetFunction(
 	}
 
 	var wg sync.WaitGroup
-	errChan := make(chan error, numCPU)
 
 	for i := 0; i < numCPU; i++ {
 		start := i * chunkSize
@@ -417,11 +436,8 @@ func (m *Model) calculateOnsetFunction(
 			hPos := 0
 
 			for idx := s; idx < e; idx++ {
-				select {
-				case <-cancelChan:
-					errChan <- fmt.Errorf("cancelled")
+				if ctx.Err() != nil {
 					return
-				default:
 				}
 				var energy float64
 				for freq := 0; freq < len(m.FFTData[idx]); freq++ {
@@ -456,30 +472,15 @@ func (m *Model) calculateOnsetFunction(
 		}(start, end)
 	}
 
-	waitDone := make(chan struct{})
-	go func() {
-		wg.Wait()
-		close(waitDone)
-	}()
-
-	select {
-	case <-waitDone:
-		select {
-		case e := <-errChan:
-			return e
-		default:
-		}
-	case <-cancelChan:
-		return fmt.Errorf("cancelled")
-	case e := <-errChan:
-		return e
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("onset detection cancelled: %w", err)
 	}
-
 	return nil
 }
 
 // detectBeats uses a basic interval histogram approach to guess BPM, then refines onsets.
-func (m *Model) detectBeats(progressFn func(float64), cancelChan chan struct{}) error {
+func (m *Model) detectBeats(ctx context.Context, progressFn func(float64)) error {
 	intervals := make([]float64, 0, len(m.BeatOnsets)/2)
 	lastBeat := -1
 
@@ -522,7 +523,9 @@ func (m *Model) detectBeats(progressFn func(float64), cancelChan chan struct{})
 		} else {
 			m.EstimatedTempo = 120.0
 		}
-		m.refineBeatDetection(progressFn, cancelChan)
+		if err := m.refineBeatDetection(ctx); err != nil {
+			return err
+		}
 	} else {
 		m.EstimatedTempo = 120.0
 	}
@@ -534,11 +537,11 @@ func (m *Model) detectBeats(progressFn func(float64), cancelChan chan struct{})
 }
 
 // refineBeatDetection tries to align onsets to a consistent BPM for a more stable “beat” visualization.
-func (m *Model) refineBeatDetection(progressFn func(float64), cancelChan chan struct{}) {
+func (m *Model) refineBeatDetection(ctx context.Context) error {
 	framesPerBeat := (60.0 / m.EstimatedTempo) *
 		(float64(m.SampleRate) / float64(m.hopSize))
 	if framesPerBeat <= 0 {
-		return
+		return nil
 	}
 
 	searchWindow := int(framesPerBeat * 0.1)
@@ -553,15 +556,13 @@ func (m *Model) refineBeatDetection(progressFn func(float64), cancelChan chan st
 		}
 	}
 	if firstBeat < 0 {
-		return
+		return nil
 	}
 
 	expectedPos := float64(firstBeat)
 	for expectedPos < float64(len(m.BeatOnsets)) {
-		select {
-		case <-cancelChan:
-			return
-		default:
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("beat detection cancelled: %w", err)
 		}
 
 		pos := int(math.Round(expectedPos))
@@ -593,6 +594,7 @@ func (m *Model) refineBeatDetection(progressFn func(float64), cancelChan chan st
 	}
 
 	m.BeatOnsets = refined
+	return nil
 }
 
 // calculateLocalThreshold returns a local average + standard deviation threshold for peak detection.
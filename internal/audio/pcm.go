@@ -0,0 +1,35 @@
+package audio
+
+// bytesPerSample is the size of one signed 16-bit PCM sample as produced by every PCMSource.
+const bytesPerSample = 2
+
+// PCMSource is a decoded audio stream of interleaved, little-endian signed 16-bit samples.
+// Both the Player and the analysis Model pull their audio from a PCMSource.
+type PCMSource interface {
+	// Read fills p with whole or partial PCM frames, returning io.EOF at the end of the stream.
+	Read(p []byte) (int, error)
+	// SampleRate is the number of frames per second.
+	SampleRate() int
+	// Channels is the number of interleaved samples per frame.
+	Channels() int
+	// Length is the total number of frames in the stream, or -1 if unknown.
+	Length() int64
+	// SeekFrame moves the read position to the given frame, counted from the start of the stream.
+	SeekFrame(frame int64) error
+}
+
+// NewPCMSource detects the format of the raw file bytes and decodes them into a PCMSource.
+func NewPCMSource(data []byte) (PCMSource, error) {
+	src, _, err := DecodeBytes(data)
+	return src, err
+}
+
+// frameSize returns the number of bytes in one interleaved frame of src.
+func frameSize(src PCMSource) int {
+	return bytesPerSample * src.Channels()
+}
+
+// sampleToFloat converts a little-endian signed 16-bit sample to the range [-1, 1).
+func sampleToFloat(lo, hi byte) float64 {
+	return float64(int16(uint16(lo)|uint16(hi)<<8)) / 32768.0
+}
@@ -3,8 +3,10 @@ package audio
 import (
 	"fmt"
 	"github.com/hajimehoshi/oto"
+	"io"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -17,79 +19,119 @@ const (
 	StatePaused
 )
 
+// outputBufferSize is the size in bytes of the oto output buffer.
+const outputBufferSize = 4096
+
+// MaxVolume is the highest software gain, in percent of the decoded level.
+const MaxVolume = 150
+
 // Player holds the audio playback context and position/duration information.
 type Player struct {
 	mutex       sync.Mutex
 	context     *oto.Context
 	player      *oto.Player
+	source      PCMSource
 	state       PlaybackState
-	buffer      []byte
-	position    time.Duration
 	duration    time.Duration
 	sampleRate  int
 	numChannels int
-	lastUpdate  time.Time
+
+	// live is set while the source is a live broadcast: it has no duration and can't seek.
+	live bool
+
+	// written is the source frame index reached by the frames handed to the output so far.
+	// startFrame is where the current run of output began (track start, seek or resume);
+	// the reported position never falls behind it while the device buffer refills.
+	written    atomic.Int64
+	startFrame int64
+
+	// ended receives a value whenever a track plays through to its end.
+	ended chan struct{}
+
+	volume int
+	muted  bool
+	// gain is the effective volume in percent, read by the pump without taking the mutex.
+	gain atomic.Int32
+
+	pumpStop chan struct{}
+	pumpDone chan struct{}
 }
 
-// NewPlayer creates a Player with default sampleRate=44100, stereo.
+// NewPlayer creates a stopped Player. The output format is taken from the first source played.
 func NewPlayer() *Player {
-	return &Player{
-		state:       StateStopped,
-		lastUpdate:  time.Now(),
-		sampleRate:  44100,
-		numChannels: 2,
+	p := &Player{
+		state:  StateStopped,
+		volume: 100,
+		ended:  make(chan struct{}, 1),
 	}
+	p.gain.Store(100)
+	return p
 }
 
-// Play writes the provided data to the Oto player. If already playing, does nothing.
-func (p *Player) Play(data []byte) error {
+// Play starts streaming the given PCM source to the audio device from its current read position.
+// If already playing, does nothing.
+func (p *Player) Play(src PCMSource) error {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 
 	if p.state == StatePlaying {
 		return nil
 	}
+	if src == nil {
+		return fmt.Errorf("no audio source")
+	}
 
-	if p.context == nil {
-		ctx, err := oto.NewContext(p.sampleRate, p.numChannels, 2, 4096)
-		if err != nil {
-			return fmt.Errorf("failed to create audio context: %w", err)
-		}
-		p.context = ctx
+	p.stopPump()
+	p.closeOutput()
+	if err := p.ensureContext(src.SampleRate(), src.Channels()); err != nil {
+		return err
 	}
 
-	// If resuming from paused, skip re-buffer. Otherwise, create new Oto player.
-	if p.state != StatePaused {
-		if p.player != nil {
-			p.player.Close()
-		}
-		p.player = p.context.NewPlayer()
-		p.buffer = data
-		_, err := p.player.Write(data)
-		if err != nil {
-			return fmt.Errorf("failed to write to player: %w", err)
-		}
+	// Drop an end notice left over from a previous track nobody was waiting on.
+	select {
+	case <-p.ended:
+	default:
 	}
 
-	p.state = StatePlaying
-	p.lastUpdate = time.Now()
+	p.source = src
+	live, ok := src.(liveSource)
+	p.live = ok && live.Live()
+	if length := src.Length(); length >= 0 {
+		p.duration = framesToDuration(length, src.SampleRate())
+	} else if p.live {
+		p.duration = 0
+	}
+	p.written.Store(0)
+	p.startFrame = 0
+	p.startPump()
+	return nil
+}
+
+// Resume continues a paused source from where it stopped.
+func (p *Player) Resume() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.state != StatePaused || p.source == nil {
+		return fmt.Errorf("nothing to resume")
+	}
+	p.startFrame = p.written.Load()
+	p.startPump()
 	return nil
 }
 
-// Pause halts playback but retains the current track position for potential resume.
+// Pause halts playback but retains the source, output and position for a later Resume.
+// The oto player stays open and plays out what it has buffered, so the position
+// is everything written so far.
 func (p *Player) Pause() error {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 
-	if p.state != StatePlaying || p.player == nil {
+	if p.state != StatePlaying {
 		return nil
 	}
 
-	p.updatePosition()
-	if p.player != nil {
-		p.player.Close()
-		p.player = nil
-	}
+	p.stopPump()
 	p.state = StatePaused
 	return nil
 }
@@ -99,14 +141,283 @@ func (p *Player) Stop() error {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 
+	p.stopPump()
+	p.closeOutput()
+	p.source = nil
+	p.state = StateStopped
+	p.written.Store(0)
+	p.startFrame = 0
+	return nil
+}
+
+// Seek moves playback to the given offset from the start of the track, keeping the
+// current playing or paused state. Audio already buffered for the old position is dropped.
+func (p *Player) Seek(pos time.Duration) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.source == nil || p.state == StateStopped {
+		return fmt.Errorf("nothing is playing")
+	}
+	if p.live {
+		return errLive
+	}
+	if pos < 0 {
+		pos = 0
+	}
+
+	frame := int64(pos.Seconds() * float64(p.sampleRate))
+	if length := p.source.Length(); length >= 0 && frame > length {
+		frame = length
+	}
+
+	wasPlaying := p.state == StatePlaying
+	p.stopPump()
+	p.closeOutput()
+
+	if err := p.source.SeekFrame(frame); err != nil {
+		p.state = StatePaused
+		return fmt.Errorf("seek failed: %w", err)
+	}
+	p.written.Store(frame)
+	p.startFrame = frame
+
+	if wasPlaying {
+		p.startPump()
+	}
+	return nil
+}
+
+// ensureContext (re)creates the oto context when the output format changes.
+// Oto allows only one live context, so the old one is closed first.
+func (p *Player) ensureContext(sampleRate, channels int) error {
+	if sampleRate <= 0 || channels <= 0 {
+		return fmt.Errorf("invalid output format: %d Hz, %d channels", sampleRate, channels)
+	}
+	if p.context != nil && p.sampleRate == sampleRate && p.numChannels == channels {
+		return nil
+	}
+	if p.context != nil {
+		p.context.Close()
+		p.context = nil
+	}
+
+	ctx, err := oto.NewContext(sampleRate, channels, bytesPerSample, outputBufferSize)
+	if err != nil {
+		return fmt.Errorf("failed to create audio context: %w", err)
+	}
+	p.context = ctx
+	p.sampleRate = sampleRate
+	p.numChannels = channels
+	return nil
+}
+
+// startPump starts copying PCM from the source into the oto player, opening one if needed.
+// Must be called with the mutex held.
+func (p *Player) startPump() {
+	if p.player == nil {
+		p.player = p.context.NewPlayer()
+	}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	p.pumpStop = stop
+	p.pumpDone = done
+
+	src, out := p.source, p.player
+	go func() {
+		finished := p.pump(src, out, stop)
+		close(done)
+		if finished {
+			p.finish(stop)
+		}
+	}()
+
+	p.state = StatePlaying
+}
+
+// finish marks the track as played through and announces it on ended, unless the
+// pump identified by stop was already stopped or replaced by a seek or new track.
+func (p *Player) finish(stop chan struct{}) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.pumpStop != stop {
+		return
+	}
+	p.pumpStop = nil
+	p.pumpDone = nil
+	p.closeOutput()
+	p.source = nil
+	p.state = StateStopped
+
+	select {
+	case p.ended <- struct{}{}:
+	default:
+	}
+}
+
+// Ended delivers a value each time a track finishes playing on its own.
+// Stop, Seek and starting another track do not trigger it.
+func (p *Player) Ended() <-chan struct{} {
+	return p.ended
+}
+
+// interruptible is implemented by sources whose reads can wait on the network. While
+// interrupted, such a read fails at once instead of waiting.
+type interruptible interface {
+	setInterrupted(on bool)
+}
+
+// liveSource is implemented by sources that may be live broadcasts, which have no
+// duration and can't seek.
+type liveSource interface {
+	Live() bool
+}
+
+// stopPump stops the copy goroutine and waits for it to exit. Must be called with the mutex held.
+func (p *Player) stopPump() {
+	if p.pumpStop != nil {
+		close(p.pumpStop)
+		// A pump waiting for a stream to download has to be woken to see the stop.
+		if src, ok := p.source.(interruptible); ok {
+			src.setInterrupted(true)
+			defer src.setInterrupted(false)
+		}
+		<-p.pumpDone
+		p.pumpStop = nil
+		p.pumpDone = nil
+	}
+}
+
+// closeOutput closes the oto player, discarding whatever it still has buffered.
+// Must be called with the mutex held and the pump stopped.
+func (p *Player) closeOutput() {
 	if p.player != nil {
 		p.player.Close()
 		p.player = nil
 	}
-	p.buffer = nil
-	p.state = StateStopped
-	p.position = 0
-	return nil
+}
+
+// pump copies PCM frames from src to out, scaled by the gain, until the source ends or
+// stop is closed. It reports whether the source ran out, after letting the output
+// buffer play out; a source that fails, such as a download that couldn't be resumed,
+// counts as ending there. It touches only the atomic fields of p, never the mutex.
+func (p *Player) pump(src PCMSource, out *oto.Player, stop <-chan struct{}) bool {
+	frameBytes := src.Channels() * bytesPerSample
+	buf := make([]byte, outputBufferSize)
+	for {
+		select {
+		case <-stop:
+			return false
+		default:
+		}
+
+		// Reading whole buffers keeps 16-bit samples aligned for the gain stage.
+		n, err := io.ReadFull(src, buf)
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		if n > 0 {
+			applyGain(buf[:n], int(p.gain.Load()))
+			if _, werr := out.Write(buf[:n]); werr != nil {
+				logDebug("Player write failed: %v", werr)
+				return false
+			}
+			p.written.Add(int64(n / frameBytes))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			select {
+			case <-stop:
+				return false
+			default:
+			}
+			logDebug("Player decode failed: %v", err)
+			break
+		}
+	}
+
+	drain := framesToDuration(int64(outputBufferSize/frameBytes), src.SampleRate())
+	select {
+	case <-stop:
+		return false
+	case <-time.After(drain):
+		return true
+	}
+}
+
+// applyGain scales little-endian 16-bit samples in place by percent/100, clipping at full scale.
+func applyGain(buf []byte, percent int) {
+	if percent == 100 {
+		return
+	}
+	for i := 0; i+1 < len(buf); i += 2 {
+		v := int(int16(uint16(buf[i])|uint16(buf[i+1])<<8)) * percent / 100
+		if v > 32767 {
+			v = 32767
+		} else if v < -32768 {
+			v = -32768
+		}
+		buf[i] = byte(v)
+		buf[i+1] = byte(uint16(v) >> 8)
+	}
+}
+
+// SetVolume sets the software gain in percent, clamped to 0..MaxVolume, and returns the value applied.
+func (p *Player) SetVolume(percent int) int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if percent < 0 {
+		percent = 0
+	}
+	if percent > MaxVolume {
+		percent = MaxVolume
+	}
+	p.volume = percent
+	p.updateGain()
+	return percent
+}
+
+// GetVolume returns the volume in percent, regardless of mute.
+func (p *Player) GetVolume() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.volume
+}
+
+// SetMuted silences or restores output without forgetting the volume.
+func (p *Player) SetMuted(muted bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.muted = muted
+	p.updateGain()
+}
+
+// IsMuted reports whether output is muted.
+func (p *Player) IsMuted() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.muted
+}
+
+// updateGain publishes the effective gain to the pump. Must be called with the mutex held.
+func (p *Player) updateGain() {
+	if p.muted {
+		p.gain.Store(0)
+		return
+	}
+	p.gain.Store(int32(p.volume))
+}
+
+// volumeLabel is the short volume indicator shown beside the track bar.
+func (p *Player) volumeLabel() string {
+	if p.muted {
+		return "muted"
+	}
+	return fmt.Sprintf("vol %d%%", p.volume)
 }
 
 // GetState returns whether the player is playing, paused, or stopped.
@@ -120,19 +431,31 @@ func (p *Player) GetState() PlaybackState {
 func (p *Player) GetPosition() time.Duration {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
+	return p.currentPosition()
+}
+
+// currentPosition converts the frames written to the device into a playback time,
+// holding back the frames still queued in its buffer. Must be called with the mutex held.
+func (p *Player) currentPosition() time.Duration {
+	if p.sampleRate <= 0 {
+		return 0
+	}
+	frames := p.written.Load()
 	if p.state == StatePlaying {
-		p.updatePosition()
+		frames -= int64(outputBufferSize / (p.numChannels * bytesPerSample))
+		if frames < p.startFrame {
+			frames = p.startFrame
+		}
 	}
-	return p.position
+	return framesToDuration(frames, p.sampleRate)
 }
 
-// updatePosition accumulates how long we've been playing since lastUpdate.
-func (p *Player) updatePosition() {
-	if p.state == StatePlaying {
-		elapsed := time.Since(p.lastUpdate)
-		p.position += elapsed
-		p.lastUpdate = time.Now()
+// framesToDuration converts a frame count at the given sample rate to a duration.
+func framesToDuration(frames int64, sampleRate int) time.Duration {
+	if sampleRate <= 0 {
+		return 0
 	}
+	return time.Duration(frames) * time.Second / time.Duration(sampleRate)
 }
 
 // SetDuration allows the Player to show the correct total track length for UI displays.
@@ -149,6 +472,14 @@ func (p *Player) GetDuration() time.Duration {
 	return p.duration
 }
 
+// IsLive reports whether the source is a live broadcast, whose position is the time
+// listened so far rather than a point in a track.
+func (p *Player) IsLive() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.live
+}
+
 // RenderTrackBar draws a simple text-based “progress bar” for the track’s current position.
 func (p *Player) RenderTrackBar(width int) string {
 	p.mutex.Lock()
@@ -158,13 +489,19 @@ func (p *Player) RenderTrackBar(width int) string {
 		return ""
 	}
 
-	p.updatePosition()
-	progress := float64(p.position) / float64(p.duration)
+	position := p.currentPosition()
+	if p.live {
+		return fmt.Sprintf("\r● LIVE  %s  %s", formatDuration(position), p.volumeLabel())
+	}
+	progress := 0.0
+	if p.duration > 0 {
+		progress = float64(position) / float64(p.duration)
+	}
 	if progress > 1.0 {
 		progress = 1.0
 	}
 
-	barWidth := width - 20
+	barWidth := width - 30
 	if barWidth < 1 {
 		barWidth = 1
 	}
@@ -187,16 +524,9 @@ func (p *Player) RenderTrackBar(width int) string {
 		}
 	}
 
-	posStr := formatDuration(p.position)
+	posStr := formatDuration(position)
 	durStr := formatDuration(p.duration)
-	bar.WriteString(fmt.Sprintf("] %s/%s", posStr, durStr))
+	bar.WriteString(fmt.Sprintf("] %s/%s  %s", posStr, durStr, p.volumeLabel()))
 
 	return bar.String()
 }
-
-// RefreshPosition updates the player's position if it is playing.
-func (p *Player) RefreshPosition() {
-	p.mutex.Lock()
-	defer p.mutex.Unlock()
-	p.updatePosition()
-}
@@ -1,8 +1,12 @@
 package audio
 
 import (
+	"context"
 	"fmt"
+	"gowav/internal/cache"
+	"gowav/pkg/utils"
 	"gowav/pkg/viz"
+	"net/http"
 	"strings"
 	"sync"
 	"time"
@@ -26,6 +30,10 @@ type ProcessingStatus struct {
 	StartTime   time.Time
 	BytesLoaded int64
 	TotalBytes  int64
+	// Cached is set once a URL has loaded from its cached copy instead of the network.
+	Cached bool
+	// Err is set when the last load or analysis failed.
+	Err error
 }
 
 // Processor is responsible for loading audio data, extracting metadata, running analysis, and managing visualizations.
@@ -33,113 +41,271 @@ type Processor struct {
 	mu sync.RWMutex
 
 	currentFile []byte
-	metadata    *Metadata
-	audioModel  *Model
-
-	vizManager     *viz.Manager
-	analysisDone   bool
-	analysisCancel chan struct{}
+	// stream is set when the track is a URL, which may still be downloading; currentFile
+	// is filled in once it has. radio is set instead for a live broadcast, which has no
+	// file to analyse.
+	stream     *Stream
+	radio      *Radio
+	metadata   *Metadata
+	audioModel *Model
+
+	vizManager    *viz.Manager
+	channelLayout viz.ChannelLayout
+	analysisDone  bool
+
+	// work is the context of the running load or analysis, and cancelWork cancels it.
+	// Both are nil when nothing is running.
+	work       context.Context
+	cancelWork context.CancelFunc
 
 	status      ProcessingStatus
 	analyzedFor map[viz.ViewMode]bool
 	vizCache    map[viz.ViewMode]bool
+
+	// httpClient fetches URLs passed to LoadFile. cache, if set, serves URLs fetched
+	// before and keeps those streamed now.
+	httpClient *http.Client
+	cache      *cache.Cache
+
+	// windowSize, hopSize and fftSize override the analysis defaults when non-zero.
+	windowSize, hopSize, fftSize int
+
+	// subscribers receive the status changes as events; see Subscribe.
+	subMu        sync.Mutex
+	subscribers  map[*subscriber]struct{}
+	lastProgress time.Time
+	// stage is the analysis stage last announced with EventAnalysisStage.
+	stage string
 }
 
 // NewProcessor creates a Processor with a fresh Viz Manager and no current track loaded.
 func NewProcessor() *Processor {
 	return &Processor{
-		vizManager:     viz.NewManager(),
-		analyzedFor:    make(map[viz.ViewMode]bool),
-		vizCache:       make(map[viz.ViewMode]bool),
-		analysisCancel: make(chan struct{}),
+		vizManager:  viz.NewManager(),
+		analyzedFor: make(map[viz.ViewMode]bool),
+		vizCache:    make(map[viz.ViewMode]bool),
+		httpClient:  defaultHTTPClient(),
+		subscribers: make(map[*subscriber]struct{}),
 	}
 }
 
-// LoadFile asynchronously loads (and decodes) an audio file or URL.
-func (p *Processor) LoadFile(path string) error {
+// defaultHTTPClient bounds only the wait for response headers, since a streamed
+// download can take as long as the track. It also accepts SHOUTcast v1 stations.
+func defaultHTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = 30 * time.Second
+	utils.AcceptICY(transport)
+	return &http.Client{Transport: transport}
+}
+
+// SetHTTPClient replaces the client used to download URLs, e.g. with one that adds credentials.
+func (p *Processor) SetHTTPClient(client *http.Client) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.httpClient = client
+}
+
+// SetCache makes URLs load from c when it has them, and stores the ones it doesn't once
+// they have downloaded. A nil c turns caching off.
+func (p *Processor) SetCache(c *cache.Cache) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cache = c
+}
+
+// SetAnalysisParameters sets the FFT window, hop and transform sizes used by the next analysis.
+func (p *Processor) SetAnalysisParameters(windowSize, hopSize, fftSize int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.windowSize, p.hopSize, p.fftSize = windowSize, hopSize, fftSize
+}
+
+// SetColorScheme changes the visualization colors.
+func (p *Processor) SetColorScheme(scheme viz.ColorScheme) {
+	p.vizManager.SetColorScheme(scheme)
+}
+
+// beginWork cancels the running load or analysis, if any, and starts a new one bounded by
+// parent. The caller must hold p.mu.
+func (p *Processor) beginWork(parent context.Context) context.Context {
+	if p.cancelWork != nil {
+		p.cancelWork()
+	}
+	p.work, p.cancelWork = context.WithCancel(parent)
+	return p.work
+}
+
+// endWork releases the context of finished work, unless newer work has replaced it.
+func (p *Processor) endWork(work context.Context) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.work == work {
+		p.cancelWork()
+		p.work, p.cancelWork = nil, nil
+	}
+}
+
+// LoadFile asynchronously loads (and decodes) an audio file or URL. The load carries on
+// in the background after LoadFile returns, until it finishes or ctx is done.
+func (p *Processor) LoadFile(ctx context.Context, path string) error {
+	return p.LoadFileWithHint(ctx, path, nil)
+}
+
+// LoadFileWithHint is LoadFile with metadata known in advance, such as from a search result.
+// The hint fills in whatever the file's own tags leave empty.
+func (p *Processor) LoadFileWithHint(ctx context.Context, path string, hint *Metadata) error {
 	logDebug("Starting to load file: %s", path)
-	p.CancelProcessing()
 
 	p.mu.Lock()
+	work := p.beginWork(ctx)
+	p.closeDownload()
 	p.currentFile = nil
 	p.metadata = nil
 	p.audioModel = nil
 	p.analyzedFor = make(map[viz.ViewMode]bool)
 	p.vizCache = make(map[viz.ViewMode]bool)
+	p.mu.Unlock()
 
-	p.status = ProcessingStatus{
+	p.publish(work, EventLoadStarted, ProcessingStatus{
 		State:     StateLoading,
 		Message:   "Loading file...",
 		Progress:  0,
 		CanCancel: true,
 		StartTime: time.Now(),
-	}
-	cancelChan := p.analysisCancel
-	p.mu.Unlock()
+	})
 
 	go func() {
-		var fileData []byte
-		var err error
-
-		if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
-			fileData, err = p.loadFromURL(path, cancelChan)
+		defer p.endWork(work)
+
+		var (
+			fileData []byte
+			stream   *Stream
+			radio    *Radio
+			md       *Metadata
+			err      error
+		)
+		message := "File loaded successfully"
+		isURL := strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+		file, cached := path, false
+		if isURL {
+			file, cached = p.cachedCopy(path)
+		}
+		if isURL && !cached {
+			stream, radio, md, err = p.loadFromURL(work, path)
+			if err != nil {
+				p.setLoadError(work, fmt.Errorf("load failed: %w", err))
+				return
+			}
+			message = "Streaming; the rest downloads in the background"
+			if radio != nil {
+				message = "Tuned in to a live stream"
+			}
 		} else {
-			fileData, err = p.loadFromFile(path, cancelChan)
+			fileData, err = p.loadFromFile(work, file)
+			if err != nil {
+				p.setLoadError(work, fmt.Errorf("load failed: %w", err))
+				return
+			}
+			md, err = ExtractMetadata(fileData)
+			if err != nil {
+				p.setLoadError(work, fmt.Errorf("metadata extraction failed: %w", err))
+				return
+			}
+			if cached {
+				message = "Loaded the cached copy; nothing to download"
+			}
 		}
-
-		if err != nil {
-			p.setLoadError(fmt.Sprintf("Load failed: %v", err))
-			return
+		if hint != nil {
+			md.fillMissing(hint)
 		}
 
-		md, err := ExtractMetadata(fileData)
-		if err != nil {
-			p.setLoadError(fmt.Sprintf("Metadata extraction failed: %v", err))
+		p.mu.Lock()
+		if work != p.work {
+			// Cancelled, or another file started loading meanwhile.
+			p.mu.Unlock()
+			if stream != nil {
+				stream.Close()
+			}
+			if radio != nil {
+				radio.Close()
+			}
 			return
 		}
-
-		p.mu.Lock()
 		p.currentFile = fileData
+		p.stream = stream
+		p.radio = radio
 		p.metadata = md
+		if radio != nil {
+			radio.OnTitle(func(title string) { p.setStreamTitle(radio, title) })
+		}
+		if stream != nil && p.cache != nil {
+			go storeInCache(p.cache, path, stream, md)
+		}
 		p.audioModel = nil
 		p.analysisDone = false
-		p.status = ProcessingStatus{
+		p.mu.Unlock()
+
+		p.publish(work, EventMetadataReady, ProcessingStatus{
 			State:    StateIdle,
-			Message:  "File loaded successfully",
+			Message:  message,
 			Progress: 1.0,
-		}
-		p.mu.Unlock()
+			Cached:   cached,
+		})
 	}()
 
 	return nil
 }
 
-// SwitchVisualization either returns a cached visualization or triggers analysis creation in a background goroutine.
-func (p *Processor) SwitchVisualization(mode viz.ViewMode) (string, error) {
-	p.mu.RLock()
+// SetChannelLayout chooses how the waveform and density views split channels.
+// Views built for a different layout are dropped so the next switch rebuilds them.
+func (p *Processor) SetChannelLayout(layout viz.ChannelLayout) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if layout == p.channelLayout {
+		return
+	}
+	p.channelLayout = layout
+	delete(p.vizCache, viz.WaveformMode)
+	delete(p.vizCache, viz.DensityMode)
+}
+
+// SwitchVisualization either returns a cached visualization or triggers analysis creation in a
+// background goroutine, bounded by ctx. Cancelling the analysis keeps the views already built.
+func (p *Processor) SwitchVisualization(ctx context.Context, mode viz.ViewMode) (string, error) {
+	p.mu.Lock()
 	if p.status.State == StateAnalyzing {
 		msg := p.status.Message
-		p.mu.RUnlock()
+		p.mu.Unlock()
 		return "", fmt.Errorf("analysis in progress: %s", msg)
 	}
-	if len(p.currentFile) == 0 {
-		p.mu.RUnlock()
+	if p.radio != nil {
+		p.mu.Unlock()
+		return "", fmt.Errorf("visualizations need the whole track, which a live stream doesn't have")
+	}
+	if len(p.currentFile) == 0 && p.stream == nil {
+		p.mu.Unlock()
 		return "", fmt.Errorf("no audio data available")
 	}
 	if p.vizCache[mode] {
 		// Already have that visualization
 		err := p.vizManager.SetMode(mode)
-		p.mu.RUnlock()
+		p.mu.Unlock()
 		if err != nil {
 			return "", err
 		}
 		return fmt.Sprintf("Switched to %s visualization", getModeName(mode)), nil
 	}
-	p.mu.RUnlock()
+	work := p.beginWork(ctx)
+	p.mu.Unlock()
+
+	// Mark the analysis as started before returning, so callers waiting on the status see it.
+	p.startStage(work, fmt.Sprintf("Analyzing for %s visualization...", getModeName(mode)))
 
 	// Otherwise, run analysis + build the visualization in background
 	go func() {
-		if err := p.analyzeAndCreateVisualization(mode); err != nil {
+		defer p.endWork(work)
+		if err := p.analyzeAndCreateVisualization(work, mode); err != nil {
 			logDebug("analyzeAndCreateVisualization failed for %v: %v", mode, err)
 		}
 	}()
@@ -148,39 +314,83 @@ func (p *Processor) SwitchVisualization(mode viz.ViewMode) (string, error) {
 }
 
 // analyzeAndCreateVisualization runs the needed analysis steps and attaches a Visualization to the Manager.
-func (p *Processor) analyzeAndCreateVisualization(mode viz.ViewMode) error {
+func (p *Processor) analyzeAndCreateVisualization(work context.Context, mode viz.ViewMode) error {
 	p.mu.Lock()
 	if p.audioModel == nil {
 		p.audioModel = NewModel(p.metadata.SampleRate)
+		if p.windowSize > 0 && p.hopSize > 0 && p.fftSize > 0 {
+			p.audioModel.SetParameters(p.windowSize, p.hopSize, p.fftSize)
+		}
 		logDebug("Created new audio model with sample rate: %d", p.metadata.SampleRate)
 	}
-
-	p.status = ProcessingStatus{
-		State:     StateAnalyzing,
-		Message:   fmt.Sprintf("Analyzing for %s visualization...", getModeName(mode)),
-		Progress:  0,
-		CanCancel: true,
-		StartTime: time.Now(),
-	}
 	currentFile := p.currentFile
-	cancelChan := p.analysisCancel
+	stream := p.stream
+	// Analyse a copy: a cancelled run may still be unwinding, and must not touch the model
+	// the next run works on. Each step fills fresh slices, so the copy shares nothing written.
+	base := p.audioModel
+	model := *base
 	p.mu.Unlock()
 
+	if currentFile == nil && stream != nil {
+		data, err := p.waitForStream(work, stream)
+		if err != nil {
+			p.setError(work, fmt.Errorf("analysis failed: %w", err))
+			return err
+		}
+		currentFile = data
+	}
+
 	startAll := time.Now()
-	err := p.runRequiredAnalysis(mode, currentFile, cancelChan)
+	err := p.runRequiredAnalysis(work, mode, &model, currentFile)
+	p.keepModel(work, base, &model)
 	if err != nil {
-		p.setError(fmt.Sprintf("analysis failed: %v", err))
+		p.setError(work, fmt.Errorf("analysis failed: %w", err))
 		return err
 	}
 	logDebug("%s completed in %v", getModeName(mode), time.Since(startAll))
 
+	if err := p.buildVisualization(work, mode); err != nil {
+		p.setError(work, err)
+		return err
+	}
+	p.publishEvent(work, Event{
+		Kind: EventVizReady,
+		Mode: mode,
+		Status: ProcessingStatus{
+			State:    StateIdle,
+			Message:  fmt.Sprintf("%s visualization ready", getModeName(mode)),
+			Progress: 1.0,
+		},
+	})
+	return nil
+}
+
+// keepModel makes the analysed copy m the model, so later views reuse its results. A run
+// cancelled by the user keeps the steps it finished; one replaced by a newer run, or by
+// loading another track, is dropped.
+func (p *Processor) keepModel(work context.Context, base, m *Model) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.audioModel == base && (p.work == work || p.work == nil) {
+		p.audioModel = m
+	}
+}
+
+// buildVisualization creates the visualization for mode from the analysis results and makes it current.
+// It fails if work was cancelled or replaced meanwhile, e.g. by loading another file.
+func (p *Processor) buildVisualization(work context.Context, mode viz.ViewMode) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
+	if err := work.Err(); err != nil {
+		return fmt.Errorf("%s visualization cancelled: %w", getModeName(mode), err)
+	}
+
 	var visualization viz.Visualization
 	switch mode {
 	case viz.WaveformMode:
-		visualization = viz.CreateWaveformViz(p.audioModel.RawData, p.audioModel.SampleRate)
+		tracks := viz.SplitChannels(p.audioModel.RawData, p.audioModel.Channels, p.channelLayout)
+		visualization = viz.CreateMultiTrackWaveformViz(tracks, p.audioModel.SampleRate)
 	case viz.SpectrogramMode:
 		visualization = viz.NewSpectrogramViz(p.audioModel.FFTData, p.audioModel.FreqBands, p.audioModel.SampleRate)
 	case viz.TempoMode:
@@ -192,11 +402,10 @@ func (p *Processor) analyzeAndCreateVisualization(mode viz.ViewMode) error {
 	case viz.BeatMapMode:
 		visualization = viz.NewBeatViz(p.audioModel.BeatData, p.audioModel.BeatOnsets, p.audioModel.EstimatedTempo, p.audioModel.SampleRate)
 	case viz.DensityMode:
-		visualization = viz.NewDensityViz(p.audioModel.RawData, p.audioModel.SampleRate)
+		tracks := viz.SplitChannels(p.audioModel.RawData, p.audioModel.Channels, p.channelLayout)
+		visualization = viz.NewMultiTrackDensityViz(tracks, p.audioModel.SampleRate)
 	default:
-		err := fmt.Errorf("unknown visualization mode: %v", mode)
-		p.setError(err.Error())
-		return err
+		return fmt.Errorf("unknown visualization mode: %v", mode)
 	}
 
 	// Update the actual track duration, in case our analysis discovered more accurate info
@@ -211,98 +420,91 @@ func (p *Processor) analyzeAndCreateVisualization(mode viz.ViewMode) error {
 	p.vizCache[mode] = true
 
 	if err := p.vizManager.SetMode(mode); err != nil {
-		p.setError(fmt.Sprintf("SetMode failed: %v", err))
-		return err
-	}
-
-	p.status = ProcessingStatus{
-		State:    StateIdle,
-		Message:  fmt.Sprintf("%s visualization ready", getModeName(mode)),
-		Progress: 1.0,
+		return fmt.Errorf("SetMode failed: %w", err)
 	}
 	logDebug("Created %s visualization with duration %v", getModeName(mode), p.metadata.Duration)
 	return nil
 }
 
 // runRequiredAnalysis checks which analysis steps are necessary for the requested visualization.
-func (p *Processor) runRequiredAnalysis(mode viz.ViewMode, file []byte, cancelChan chan struct{}) error {
+func (p *Processor) runRequiredAnalysis(work context.Context, mode viz.ViewMode, m *Model, file []byte) error {
 	progressFn := func(progress float64, msg string) {
-		p.updateAnalysisProgress(progress, msg)
+		p.updateAnalysisProgress(work, progress, msg)
 	}
 
 	switch mode {
 	case viz.WaveformMode:
-		if len(p.audioModel.RawData) == 0 {
-			if err := p.audioModel.AnalyzeWaveform(file, func(f float64) {
+		if len(m.RawData) == 0 {
+			if err := m.AnalyzeWaveform(work, file, func(f float64) {
 				progressFn(f, "Analyzing waveform...")
-			}, cancelChan); err != nil {
+			}); err != nil {
 				return err
 			}
 		}
 	case viz.SpectrogramMode:
-		if len(p.audioModel.RawData) == 0 {
-			if err := p.audioModel.AnalyzeWaveform(file, func(f float64) {
+		if len(m.RawData) == 0 {
+			if err := m.AnalyzeWaveform(work, file, func(f float64) {
 				progressFn(0.3*f, "Analyzing waveform...")
-			}, cancelChan); err != nil {
+			}); err != nil {
 				return err
 			}
 		}
-		if p.audioModel.FFTData == nil {
-			if err := p.audioModel.AnalyzeSpectrum(func(f float64) {
+		if m.FFTData == nil {
+			if err := m.AnalyzeSpectrum(work, func(f float64) {
 				progressFn(0.3+0.7*f, "Computing frequency analysis...")
-			}, cancelChan); err != nil {
+			}); err != nil {
 				return err
 			}
 		}
 	case viz.TempoMode:
-		if len(p.audioModel.RawData) == 0 {
-			if err := p.audioModel.AnalyzeWaveform(file, func(f float64) {
+		if len(m.RawData) == 0 {
+			if err := m.AnalyzeWaveform(work, file, func(f float64) {
 				progressFn(0.3*f, "Analyzing waveform...")
-			}, cancelChan); err != nil {
+			}); err != nil {
 				return err
 			}
 		}
-		if p.audioModel.FFTData == nil {
-			if err := p.audioModel.AnalyzeSpectrum(func(f float64) {
+		if m.FFTData == nil {
+			if err := m.AnalyzeSpectrum(work, func(f float64) {
 				progressFn(0.3+0.3*f, "Computing frequency analysis...")
-			}, cancelChan); err != nil {
+			}); err != nil {
 				return err
 			}
 		}
-		if len(p.audioModel.BeatData) == 0 {
-			if err := p.audioModel.AnalyzeBeats(func(f float64) {
+		if len(m.BeatData) == 0 {
+			if err := m.AnalyzeBeats(work, func(f float64) {
 				progressFn(0.6+0.4*f, "Detecting beats...")
-			}, cancelChan); err != nil {
+			}); err != nil {
 				return err
 			}
 		}
 	case viz.BeatMapMode:
-		if len(p.audioModel.RawData) == 0 {
-			if err := p.audioModel.AnalyzeWaveform(file, func(f float64) {
+		if len(m.RawData) == 0 {
+			if err := m.AnalyzeWaveform(work, file, func(f float64) {
 				progressFn(0.3*f, "Analyzing waveform...")
-			}, cancelChan); err != nil {
+			}); err != nil {
 				return err
 			}
 		}
-		if p.audioModel.FFTData == nil {
-			if err := p.audioModel.AnalyzeSpectrum(func(f float64) {
+		if m.FFTData == nil {
+			if err := m.AnalyzeSpectrum(work, func(f float64) {
 				progressFn(0.3+0.3*f, "Computing frequency analysis...")
-			}, cancelChan); err != nil {
+			}); err != nil {
 				return err
 			}
 		}
-		if len(p.audioModel.BeatData) == 0 {
-			if err := p.audioModel.AnalyzeBeats(func(f float64) {
+		if len(m.BeatData) == 0 {
+			if err := m.AnalyzeBeats(work, func(f float64) {
 				progressFn(0.6+0.4*f, "Detecting beats...")
-			}, cancelChan); err != nil {
+			}); err != nil {
 				return err
 			}
 		}
 	case viz.DensityMode:
-		if len(p.audioModel.RawData) == 0 {
-			if err := p.audioModel.AnalyzeWaveform(file, func(f float64) {
+		if len(m.RawData) == 0 {
+			if err := m.AnalyzeWaveform(work, file, func(f float64) {
 				progressFn(f, "Analyzing waveform...")
-			}, cancelChan); err != nil {
+			}); err != nil {
 				return err
 			}
 		}
@@ -312,11 +514,22 @@ func (p *Processor) runRequiredAnalysis(mode viz.ViewMode, file []byte, cancelCh
 	return nil
 }
 
-// updateAnalysisProgress modifies the processor status to show progress in the UI.
-func (p *Processor) updateAnalysisProgress(progress float64, message string) {
+// startStage marks the start of analysis, announcing it as a new stage.
+func (p *Processor) startStage(work context.Context, message string) {
 	p.mu.Lock()
-	defer p.mu.Unlock()
+	p.stage = message
+	p.mu.Unlock()
+	p.publish(work, EventAnalysisStage, ProcessingStatus{
+		State:     StateAnalyzing,
+		Message:   message,
+		CanCancel: true,
+		StartTime: time.Now(),
+	})
+}
 
+// updateAnalysisProgress modifies the processor status to show progress in the UI. A new
+// message starts a new stage and is announced as such; otherwise the update is progress.
+func (p *Processor) updateAnalysisProgress(work context.Context, progress float64, message string) {
 	if progress < 0 {
 		progress = 0
 	}
@@ -324,7 +537,16 @@ func (p *Processor) updateAnalysisProgress(progress float64, message string) {
 		progress = 1
 	}
 
-	elapsed := time.Since(p.status.StartTime)
+	p.mu.Lock()
+	startTime := p.status.StartTime
+	kind := EventProgress
+	if message != p.stage {
+		p.stage = message
+		kind = EventAnalysisStage
+	}
+	p.mu.Unlock()
+
+	elapsed := time.Since(startTime)
 	var eta string
 	if progress > 0 && progress < 1 {
 		totalEstimate := elapsed.Seconds() / progress
@@ -334,13 +556,13 @@ func (p *Processor) updateAnalysisProgress(progress float64, message string) {
 		eta = "calculating..."
 	}
 
-	p.status = ProcessingStatus{
+	p.publish(work, kind, ProcessingStatus{
 		State:     StateAnalyzing,
 		Message:   fmt.Sprintf("%s (ETA: %s)", message, eta),
 		Progress:  progress,
 		CanCancel: true,
-		StartTime: p.status.StartTime,
-	}
+		StartTime: startTime,
+	})
 }
 
 // GetVisualization renders the current visualization or shows loading progress if not ready.
@@ -362,6 +584,20 @@ func (p *Processor) GetVisualization() string {
 	return p.vizManager.Render()
 }
 
+// ExportVisualization renders the current visualization for saving, or fails if none is ready yet.
+func (p *Processor) ExportVisualization() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch {
+	case p.status.State == StateLoading || p.status.State == StateAnalyzing:
+		return "", fmt.Errorf("visualization not ready: %s", p.status.Message)
+	case len(p.vizCache) == 0:
+		return "", fmt.Errorf("no visualization yet (use 'viz <type>' first)")
+	}
+	return p.vizManager.Render(), nil
+}
+
 // HandleVisualizationInput processes keys for panning or zooming the current visualization, etc.
 func (p *Processor) HandleVisualizationInput(key string) bool {
 	p.mu.Lock()
@@ -422,28 +658,117 @@ func (p *Processor) GetMetadata() *Metadata {
 	return p.metadata
 }
 
-// GetCurrentFile returns the raw bytes of the loaded audio file.
+// GetCurrentFile returns the raw bytes of the loaded audio file, or nil while a stream is
+// still downloading.
 func (p *Processor) GetCurrentFile() []byte {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.currentFile == nil && p.stream != nil {
+		if data, done := p.stream.complete(); done {
+			p.currentFile = data
+		}
+	}
+	return p.currentFile
+}
+
+// OpenSource opens a PCMSource for playing the loaded track. A stream can start playing
+// while it is still downloading.
+func (p *Processor) OpenSource() (PCMSource, error) {
+	if data := p.GetCurrentFile(); data != nil {
+		return NewPCMSource(data)
+	}
+	p.mu.RLock()
+	stream, radio := p.stream, p.radio
+	p.mu.RUnlock()
+	var (
+		src PCMSource
+		err error
+	)
+	switch {
+	case stream != nil:
+		src, _, err = stream.decode()
+	case radio != nil:
+		src, _, err = radio.decode()
+	default:
+		return nil, fmt.Errorf("no track loaded")
+	}
+	return src, err
+}
+
+// StreamStatus reports the download of the loaded track, if it is a stream or a live broadcast.
+func (p *Processor) StreamStatus() (StreamStatus, bool) {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
-	return p.currentFile
+	switch {
+	case p.stream != nil:
+		return p.stream.Status(), true
+	case p.radio != nil:
+		return p.radio.Status(), true
+	}
+	return StreamStatus{}, false
 }
 
-// CancelProcessing stops any ongoing analysis or file loading by closing the cancel channel.
-func (p *Processor) CancelProcessing() {
+// setStreamTitle shows the song a live station has moved on to in the metadata.
+func (p *Processor) setStreamTitle(radio *Radio, title string) {
+	p.mu.Lock()
+	if p.radio != radio || p.metadata == nil {
+		p.mu.Unlock()
+		return
+	}
+	// Replace rather than change the metadata, which readers may be holding on to.
+	md := *p.metadata
+	md.setStreamTitle(title)
+	p.metadata = &md
+	p.mu.Unlock()
+
+	p.announce(EventMetadataChanged)
+}
+
+// Close cancels any work and stops downloading the loaded track.
+func (p *Processor) Close() {
+	p.CancelProcessing()
 	p.mu.Lock()
 	defer p.mu.Unlock()
+	p.closeDownload()
+}
 
-	if p.analysisCancel != nil {
-		close(p.analysisCancel)
+// closeDownload stops downloading the loaded track, if it is a stream or a live broadcast.
+// It must be called with p.mu held.
+func (p *Processor) closeDownload() {
+	if p.stream != nil {
+		p.stream.Close()
+		p.stream = nil
+	}
+	if p.radio != nil {
+		p.radio.Close()
+		p.radio = nil
 	}
-	p.analysisCancel = make(chan struct{})
+}
 
-	p.status = ProcessingStatus{
+// CancelProcessing stops any ongoing analysis or file loading by cancelling its context.
+// Visualizations that were already built stay available.
+func (p *Processor) CancelProcessing() {
+	p.mu.Lock()
+	running := p.status.State == StateLoading || p.status.State == StateAnalyzing
+	if p.cancelWork != nil {
+		p.cancelWork()
+		p.work, p.cancelWork = nil, nil
+	}
+	p.mu.Unlock()
+
+	st := ProcessingStatus{
 		State:    StateIdle,
 		Message:  "Processing cancelled",
 		Progress: 0,
 	}
+	if !running {
+		// Nothing to announce; just reset the status.
+		p.mu.Lock()
+		p.status = st
+		p.mu.Unlock()
+		return
+	}
+	p.publish(nil, EventCancelled, st)
 }
 
 // getModeName returns a string for each known ViewMode to display in UI messages.
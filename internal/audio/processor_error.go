@@ -1,57 +1,34 @@
 package audio
 
-// setLoadError changes the processor status to an error state during file loading.
-func (p *Processor) setLoadError(msg string) {
-	p.mu.Lock()
-	defer p.mu.Unlock()
+import (
+	"context"
+	"errors"
+)
 
-	logDebug("Load error: %s", msg)
-	p.status = ProcessingStatus{
+// setLoadError changes the processor status to an error state during file loading.
+func (p *Processor) setLoadError(work context.Context, err error) {
+	logDebug("Load error: %v", err)
+	p.publish(work, errorKind(err), ProcessingStatus{
 		State:   StateIdle,
-		Message: msg,
-	}
+		Message: err.Error(),
+		Err:     err,
+	})
 }
 
 // setError changes the processor status to an error state at any stage of processing or analysis.
-func (p *Processor) setError(msg string) {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-
-	logDebug("Error: %s", msg)
-	p.status = ProcessingStatus{
+func (p *Processor) setError(work context.Context, err error) {
+	logDebug("Error: %v", err)
+	p.publish(work, errorKind(err), ProcessingStatus{
 		State:   StateIdle,
-		Message: msg,
-	}
+		Message: err.Error(),
+		Err:     err,
+	})
 }
 
-// setStatus updates the processor status with a general message and a new state.
-func (p *Processor) setStatus(state ProcessingState, msg string) {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-
-	logDebug("Status update: [%v] %s", state, msg)
-	p.status = ProcessingStatus{
-		State:   state,
-		Message: msg,
-	}
-}
-
-// updateProgressWithMessage updates processor status, including a progress float, for example during file reading.
-func (p *Processor) updateProgressWithMessage(state ProcessingState, msg string, progress float64) {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-
-	if progress < 0 {
-		progress = 0
-	}
-	if progress > 1 {
-		progress = 1
-	}
-
-	p.status = ProcessingStatus{
-		State:     state,
-		Message:   msg,
-		Progress:  progress,
-		CanCancel: true,
+// errorKind tells a failure from work that was cancelled, e.g. by its caller's context.
+func errorKind(err error) EventKind {
+	if errors.Is(err, context.Canceled) {
+		return EventCancelled
 	}
+	return EventError
 }
@@ -0,0 +1,117 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+	"gowav/pkg/viz"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestProcessorRestartedAnalysisKeepsItsResults(t *testing.T) {
+	p := NewProcessor()
+	defer p.Close()
+	p.currentFile = testWAV(8000, 8000*20)
+	p.metadata = &Metadata{SampleRate: 8000, Duration: 20 * time.Second}
+
+	events, unsubscribe := p.Subscribe()
+	defer unsubscribe()
+
+	// Each cancelled run may still be finishing while the next one starts.
+	for i := 0; i < 5; i++ {
+		if _, err := p.SwitchVisualization(context.Background(), viz.TempoMode); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Duration(i) * time.Millisecond)
+		p.CancelProcessing()
+	}
+	if _, err := p.SwitchVisualization(context.Background(), viz.TempoMode); err != nil {
+		t.Fatal(err)
+	}
+
+	timeout := time.After(10 * time.Second)
+	for ready := false; !ready; {
+		select {
+		case ev := <-events:
+			ready = ev.Kind == EventVizReady && ev.Mode == viz.TempoMode
+		case <-timeout:
+			t.Fatal("the tempo visualization was never built")
+		}
+	}
+
+	// Give cancelled runs time to unwind; none may clear the finished analysis.
+	time.Sleep(100 * time.Millisecond)
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if m := p.audioModel; m == nil || len(m.FFTData) == 0 || len(m.BeatData) == 0 {
+		t.Error("the analysis results were lost")
+	}
+}
+
+func TestSlowSubscriberKeepsEvents(t *testing.T) {
+	p := NewProcessor()
+	defer p.Close()
+	events, unsubscribe := p.Subscribe()
+	defer unsubscribe()
+
+	// Nothing reads the events until all have been sent, which must not wait for the reader.
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		for i := 0; i < 10; i++ {
+			p.publish(nil, EventProgress, ProcessingStatus{Progress: 1, Message: fmt.Sprint("early ", i)})
+		}
+		for i := 0; i < 2*eventBuffer; i++ {
+			p.publish(nil, EventAnalysisStage, ProcessingStatus{Message: fmt.Sprint("stage ", i)})
+			p.publish(nil, EventProgress, ProcessingStatus{Progress: 1})
+		}
+		p.publish(nil, EventMetadataReady, ProcessingStatus{})
+		p.publish(nil, EventError, ProcessingStatus{})
+		p.publish(nil, EventCancelled, ProcessingStatus{})
+	}()
+	select {
+	case <-sent:
+	case <-time.After(5 * time.Second):
+		t.Fatal("publishing waited for the subscriber")
+	}
+
+	var stages, progress int
+	var got []EventKind
+	for len(got) < 3 {
+		select {
+		case ev := <-events:
+			switch ev.Kind {
+			case EventProgress:
+				// Updates waiting together collapse into the latest.
+				if progress == 0 && ev.Status.Message != "early 9" {
+					t.Errorf("first progress = %q, want the latest of the early ones", ev.Status.Message)
+				}
+				progress++
+			case EventAnalysisStage:
+				if want := fmt.Sprint("stage ", stages); ev.Status.Message != want {
+					t.Errorf("stage event %q, want %q", ev.Status.Message, want)
+				}
+				stages++
+			default:
+				got = append(got, ev.Kind)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("events stopped after %v", got)
+		}
+	}
+	if stages != 2*eventBuffer {
+		t.Errorf("received %d stage events, want %d", stages, 2*eventBuffer)
+	}
+	if progress > eventBuffer {
+		t.Errorf("received %d progress events, want at most %d", progress, eventBuffer)
+	}
+	if want := []EventKind{EventMetadataReady, EventError, EventCancelled}; !reflect.DeepEqual(got, want) {
+		t.Errorf("final events %v, want %v", got, want)
+	}
+
+	unsubscribe()
+	if _, ok := <-events; ok {
+		t.Error("the channel is still open after unsubscribing")
+	}
+}
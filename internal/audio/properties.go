@@ -1,35 +1,44 @@
 package audio
 
 import (
+	"fmt"
 	"io"
 	"time"
-
-	"github.com/hajimehoshi/go-mp3"
 )
 
 // AudioProperties holds basic format details like duration or sample rate.
 type AudioProperties struct {
+	Format     string
 	Duration   time.Duration
 	SampleRate int
 	Channels   int
 	BitRate    int
 }
 
-// extractAudioProperties attempts to read an MP3 stream to find duration, sample rate, and so forth.
-func extractAudioProperties(reader io.ReadSeeker) (AudioProperties, error) {
+// extractAudioProperties opens the registered decoder for data to find its format, duration, sample rate, and so forth.
+func extractAudioProperties(data []byte) (AudioProperties, error) {
 	props := AudioProperties{}
-	dec, err := mp3.NewDecoder(reader)
-	if err == nil {
-		props.SampleRate = dec.SampleRate()
-		props.Channels = 2
+	src, format, err := DecodeBytes(data)
+	if err != nil {
+		return props, err
+	}
+	props.Format = format
+	props.SampleRate = src.SampleRate()
+	props.Channels = src.Channels()
+	if props.SampleRate <= 0 {
+		return props, fmt.Errorf("invalid sample rate %d", props.SampleRate)
+	}
 
-		var totalPCMFrames int64
+	// Most containers state their length up front; otherwise count the decoded frames.
+	frames := src.Length()
+	if frames < 0 {
+		frames = 0
+		frameBytes := frameSize(src)
 		buf := make([]byte, 8192)
+		var total int64
 		for {
-			n, readErr := dec.Read(buf)
-			if n > 0 {
-				totalPCMFrames += int64(n / 4)
-			}
+			n, readErr := src.Read(buf)
+			total += int64(n)
 			if readErr == io.EOF {
 				break
 			}
@@ -37,9 +46,13 @@ func extractAudioProperties(reader io.ReadSeeker) (AudioProperties, error) {
 				return props, readErr
 			}
 		}
-		durSeconds := float64(totalPCMFrames) / float64(props.SampleRate)
-		props.Duration = time.Duration(durSeconds * float64(time.Second))
-		return props, nil
+		frames = total / int64(frameBytes)
+	}
+
+	durSeconds := float64(frames) / float64(props.SampleRate)
+	props.Duration = time.Duration(durSeconds * float64(time.Second))
+	if durSeconds > 0 {
+		props.BitRate = int(float64(len(data)*8) / durSeconds / 1000)
 	}
 	return props, nil
 }
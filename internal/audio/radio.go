@@ -0,0 +1,620 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hajimehoshi/go-mp3"
+)
+
+const (
+	// radioBuffer bounds the audio a Radio keeps. Audio further behind the newest is
+	// dropped, so a long pause skips ahead rather than growing without end.
+	radioBuffer = 1 << 20
+	// radioLead is how far behind the newest audio a new reader starts.
+	radioLead = 64 << 10
+	// radioHead is how much of each connection is kept for telling the format.
+	radioHead = 512
+)
+
+// errLive is returned when seeking in a live stream.
+var errLive = errors.New("can't seek in a live stream")
+
+// errSkipped is returned by a radio read that skipped audio, because the station was
+// reconnected or the reader fell too far behind. The decoder has to start afresh.
+var errSkipped = errors.New("live stream skipped ahead")
+
+// RadioInfo describes a live station, from its icy-* headers and metadata.
+type RadioInfo struct {
+	Name    string
+	Genre   string
+	BitRate int
+	// Title is the song now playing, usually "Artist - Title".
+	Title string
+}
+
+// Radio receives a live broadcast, such as an Icecast or Shoutcast station. It strips
+// the interleaved ICY metadata, keeps a window of recent audio for its readers and
+// reconnects when the connection drops.
+type Radio struct {
+	url    string
+	client *http.Client
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu   sync.Mutex
+	cond *sync.Cond
+	// buf holds the audio from offset base on. Each connection starts a session at offset
+	// start; head holds the session's first bytes.
+	buf     []byte
+	base    int64
+	session int
+	start   int64
+	head    []byte
+	// body is the response being read; closing it makes run reconnect, when rejoining is set.
+	body      io.Closer
+	rejoining bool
+	info      RadioInfo
+	onTitle   func(string)
+	// waiting is set while a reader waits for audio; readPos is where the last read ended.
+	waiting bool
+	readPos int64
+	retries int
+	err     error
+}
+
+// startRadio starts receiving a live stream whose first response, requested with
+// ICY metadata, is resp. It runs until ctx is done.
+func startRadio(client *http.Client, url string, ctx context.Context, cancel context.CancelFunc, resp *http.Response) *Radio {
+	r := &Radio{url: url, client: client, ctx: ctx, cancel: cancel}
+	r.cond = sync.NewCond(&r.mu)
+	r.connected(resp)
+	go r.run(resp)
+	return r
+}
+
+// Close disconnects from the station.
+func (r *Radio) Close() {
+	r.cancel()
+}
+
+// Info returns what the station has said about itself and the song playing.
+func (r *Radio) Info() RadioInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.info
+}
+
+// OnTitle sets a function called with the new title whenever the song changes.
+func (r *Radio) OnTitle(fn func(title string)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onTitle = fn
+}
+
+// Status reports the state of the connection in the terms used for downloads.
+func (r *Radio) Status() StreamStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	end := r.end()
+	return StreamStatus{
+		Size:       -1,
+		Downloaded: end,
+		Ahead:      end - max(r.readPos, r.base),
+		Waiting:    r.waiting,
+		Retries:    r.retries,
+		Live:       true,
+		Err:        r.err,
+	}
+}
+
+// end is the offset just past the newest audio. It must be called with r.mu held.
+func (r *Radio) end() int64 {
+	return r.base + int64(len(r.buf))
+}
+
+// run receives the stream, reconnecting whenever a connection ends. A station never
+// ends by itself, so only errors that repeat without any audio in between give up.
+func (r *Radio) run(resp *http.Response) {
+	failures := 0
+	for {
+		var err error
+		if resp == nil {
+			resp, err = r.connect()
+		}
+		progressed := false
+		if err == nil {
+			progressed, err = r.receive(resp)
+			resp = nil
+		}
+		if r.ctx.Err() != nil {
+			r.finish(fmt.Errorf("stream cancelled: %w", r.ctx.Err()))
+			return
+		}
+
+		r.mu.Lock()
+		rejoined := r.rejoining
+		r.rejoining = false
+		r.mu.Unlock()
+		if rejoined {
+			continue
+		}
+
+		if progressed {
+			failures = 0
+		}
+		var perm permanentError
+		if errors.As(err, &perm) || failures >= streamRetries {
+			r.finish(err)
+			return
+		}
+		failures++
+
+		r.mu.Lock()
+		r.retries++
+		r.mu.Unlock()
+		logDebug("Radio %s: %v; reconnecting (retry %d)", r.url, err, failures)
+
+		select {
+		case <-r.ctx.Done():
+		case <-time.After(time.Duration(failures) * streamRetryDelay):
+		}
+	}
+}
+
+// connect requests the stream again after a connection ended, and starts a session for it.
+func (r *Radio) connect() (*http.Response, error) {
+	resp, err := requestStream(r.ctx, r.client, r.url)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		err := fmt.Errorf("server returned %d", resp.StatusCode)
+		if resp.StatusCode < 500 {
+			return nil, permanentError{err}
+		}
+		return nil, err
+	}
+	r.connected(resp)
+	return resp, nil
+}
+
+// receive reads one connection until it ends. progressed reports whether any audio arrived.
+func (r *Radio) receive(resp *http.Response) (progressed bool, err error) {
+	defer func() {
+		resp.Body.Close()
+		r.mu.Lock()
+		r.body = nil
+		r.mu.Unlock()
+	}()
+
+	interval, _ := strconv.Atoi(resp.Header.Get("Icy-Metaint"))
+	body := &icyReader{r: resp.Body, interval: interval, left: interval, onMeta: r.setMeta}
+
+	buf := make([]byte, streamChunk)
+	for {
+		n, err := body.Read(buf)
+		if n > 0 {
+			progressed = true
+			r.store(buf[:n])
+		}
+		if err == io.EOF {
+			return progressed, fmt.Errorf("stream ended: %w", io.ErrUnexpectedEOF)
+		}
+		if err != nil {
+			return progressed, fmt.Errorf("stream error: %w", err)
+		}
+	}
+}
+
+// connected starts a session for a new connection and takes in what its headers say
+// about the station.
+func (r *Radio) connected(resp *http.Response) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.body = resp.Body
+	if r.head == nil || len(r.head) > 0 {
+		r.newSession()
+	}
+	if name := resp.Header.Get("Icy-Name"); name != "" {
+		r.info.Name = tryDecode(name)
+	}
+	if genre := resp.Header.Get("Icy-Genre"); genre != "" {
+		r.info.Genre = tryDecode(genre)
+	}
+	if br, err := strconv.Atoi(resp.Header.Get("Icy-Br")); err == nil {
+		r.info.BitRate = br
+	}
+}
+
+// newSession makes the audio from now on a session of its own, dropping what hasn't
+// been read of the previous one. It must be called with r.mu held.
+func (r *Radio) newSession() {
+	r.base = r.end()
+	r.buf = r.buf[:0]
+	r.start = r.base
+	r.head = make([]byte, 0, radioHead)
+	r.session++
+	r.cond.Broadcast()
+}
+
+// store adds audio that has arrived and wakes waiting readers.
+func (r *Radio) store(b []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if n := min(radioHead-len(r.head), len(b)); n > 0 {
+		r.head = append(r.head, b[:n]...)
+	}
+	r.buf = append(r.buf, b...)
+	if over := len(r.buf) - radioBuffer; over > 0 {
+		// Drop a quarter of the buffer at a time, so the copying stays cheap.
+		drop := over + radioBuffer/4
+		r.buf = append(r.buf[:0], r.buf[drop:]...)
+		r.base += int64(drop)
+	}
+	r.cond.Broadcast()
+}
+
+// setMeta handles an ICY metadata block, announcing a new song title.
+func (r *Radio) setMeta(meta string) {
+	title, ok := parseStreamTitle(meta)
+	if !ok {
+		return
+	}
+	title = tryDecode(title)
+
+	r.mu.Lock()
+	changed := title != r.info.Title
+	r.info.Title = title
+	onTitle := r.onTitle
+	r.mu.Unlock()
+
+	if changed && onTitle != nil {
+		onTitle(title)
+	}
+}
+
+// rejoin drops the buffered audio and reconnects, for a reader whose decoder can only
+// start at the beginning of a stream.
+func (r *Radio) rejoin() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.head) == 0 {
+		// Nothing has arrived since the last connection; it is still the start of a stream.
+		return
+	}
+	r.newSession()
+	if r.body != nil {
+		r.rejoining = true
+		r.body.Close()
+	}
+}
+
+// finish ends reception for good.
+func (r *Radio) finish(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	logDebug("Radio %s stopped: %v", r.url, err)
+	r.err = err
+	r.cond.Broadcast()
+}
+
+// decode opens a PCMSource over the stream, in the format its first bytes show.
+func (r *Radio) decode() (PCMSource, string, error) {
+	r.mu.Lock()
+	for len(r.head) < radioHead && r.err == nil {
+		r.cond.Wait()
+	}
+	head, err := r.head, r.err
+	r.mu.Unlock()
+	if err != nil {
+		return nil, "", err
+	}
+
+	d, err := DetectDecoder(head)
+	if err != nil {
+		return nil, "", err
+	}
+	src := &radioSource{r: r.newReader(), format: d.Name()}
+	if err := src.restart(); err != nil {
+		return nil, d.Name(), fmt.Errorf("%s: %w", d.Name(), err)
+	}
+	src.sampleRate, src.channels = src.dec.SampleRate(), src.dec.Channels()
+	return src, d.Name(), nil
+}
+
+// newReader returns a reader that starts a little behind the newest audio, or at the start
+// of the session if that is closer.
+func (r *Radio) newReader() *radioReader {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &radioReader{r: r, session: r.session, pos: max(r.start, r.base, r.end()-radioLead)}
+}
+
+// radioReader reads a Radio's audio from its own position, waiting for more to arrive.
+type radioReader struct {
+	r       *Radio
+	session int
+	pos     int64
+	// lastErr is the error of the last read that failed, so a decoder's error can be traced
+	// back to it. Only the reading goroutine uses it.
+	lastErr error
+	// interrupted makes a read that would wait fail with errInterrupted instead. Guarded by r.mu.
+	interrupted bool
+}
+
+func (rr *radioReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	r := rr.r
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for {
+		if rr.session != r.session || rr.pos < r.base {
+			rr.session = r.session
+			rr.pos = max(rr.pos, r.start, r.base)
+			rr.lastErr = errSkipped
+			return 0, errSkipped
+		}
+		if rr.pos < r.end() {
+			r.waiting = false
+			n := copy(p, r.buf[rr.pos-r.base:])
+			rr.pos += int64(n)
+			r.readPos = rr.pos
+			return n, nil
+		}
+		if r.err != nil {
+			rr.lastErr = r.err
+			return 0, r.err
+		}
+		if rr.interrupted {
+			rr.lastErr = errInterrupted
+			return 0, errInterrupted
+		}
+		r.waiting = true
+		r.cond.Wait()
+	}
+}
+
+// setInterrupted turns interruption of waiting reads on or off, waking any read waiting now.
+func (rr *radioReader) setInterrupted(on bool) {
+	rr.r.mu.Lock()
+	defer rr.r.mu.Unlock()
+	rr.interrupted = on
+	if on {
+		rr.r.waiting = false
+	}
+	rr.r.cond.Broadcast()
+}
+
+// radioSource is the PCMSource of a live stream. It has no length and can't seek. When the
+// audio skips, or a read is interrupted mid-frame, it starts a new decoder.
+type radioSource struct {
+	r      *radioReader
+	format string
+	dec    PCMSource
+	// sampleRate and channels are those of the first decoder; a station doesn't change them.
+	sampleRate, channels int
+}
+
+// restart opens a new decoder where the reader is. A format that can't start mid-stream
+// has the station send its stream from the top again.
+func (s *radioSource) restart() error {
+	s.dec = nil
+	for tries := 0; ; {
+		s.r.lastErr = nil
+		dec, err := openLive(s.format, s.r)
+		if err == nil {
+			s.dec = dec
+			return nil
+		}
+		switch s.r.lastErr {
+		case nil:
+		case errSkipped:
+			// Reconnected meanwhile; try again from where the reader skipped to.
+			continue
+		default:
+			// Interrupted, or the station is gone.
+			return s.r.lastErr
+		}
+		if tries++; tries > 2 {
+			return err
+		}
+		logDebug("Radio decoder failed (%v); rejoining the stream", err)
+		s.r.r.rejoin()
+	}
+}
+
+// openLive opens a decoder for a live stream in format over r.
+func openLive(format string, r io.Reader) (PCMSource, error) {
+	switch format {
+	case "mp3":
+		// go-mp3 finds the next frame by itself, so it can start anywhere.
+		dec, err := mp3.NewDecoder(r)
+		if err != nil {
+			return nil, err
+		}
+		return &mp3Source{dec: dec}, nil
+	case "ogg":
+		return newVorbisSource(r)
+	default:
+		return nil, fmt.Errorf("%s is not supported for live streams", format)
+	}
+}
+
+func (s *radioSource) Read(p []byte) (int, error) {
+	for {
+		if s.dec == nil {
+			if err := s.restart(); err != nil {
+				return 0, err
+			}
+		}
+		n, err := s.dec.Read(p)
+		if err == nil {
+			return n, nil
+		}
+		// Whatever the decoder was in the middle of is lost; the next read starts afresh.
+		s.dec = nil
+		if s.r.lastErr != nil && s.r.lastErr != errSkipped {
+			return n, s.r.lastErr
+		}
+		if s.r.lastErr == nil {
+			logDebug("Radio decoder stopped (%v); restarting", err)
+		}
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+func (s *radioSource) SampleRate() int {
+	return s.sampleRate
+}
+
+func (s *radioSource) Channels() int {
+	return s.channels
+}
+
+func (s *radioSource) Length() int64 {
+	return -1
+}
+
+func (s *radioSource) SeekFrame(frame int64) error {
+	return errLive
+}
+
+// Live reports that the source is a live broadcast.
+func (s *radioSource) Live() bool {
+	return true
+}
+
+func (s *radioSource) setInterrupted(on bool) {
+	s.r.setInterrupted(on)
+}
+
+// icyReader strips the metadata a server interleaves with the audio when asked to with
+// "Icy-MetaData: 1". After every interval bytes of audio comes a length byte n, then n*16
+// bytes of text such as "StreamTitle='Artist - Title';", padded with zeros.
+type icyReader struct {
+	r        io.Reader
+	interval int
+	// left is the audio before the next metadata block.
+	left   int
+	onMeta func(string)
+}
+
+func (r *icyReader) Read(p []byte) (int, error) {
+	if r.interval <= 0 {
+		return r.r.Read(p)
+	}
+	if r.left == 0 {
+		if err := r.readMeta(); err != nil {
+			return 0, err
+		}
+		r.left = r.interval
+	}
+	if len(p) > r.left {
+		p = p[:r.left]
+	}
+	n, err := r.r.Read(p)
+	r.left -= n
+	return n, err
+}
+
+func (r *icyReader) readMeta() error {
+	var size [1]byte
+	if _, err := io.ReadFull(r.r, size[:]); err != nil {
+		return err
+	}
+	if size[0] == 0 {
+		return nil
+	}
+	block := make([]byte, int(size[0])*16)
+	if _, err := io.ReadFull(r.r, block); err != nil {
+		return fmt.Errorf("reading stream metadata: %w", io.ErrUnexpectedEOF)
+	}
+	r.onMeta(string(bytes.TrimRight(block, "\x00")))
+	return nil
+}
+
+// parseStreamTitle returns the StreamTitle of an ICY metadata block. Titles may contain
+// quotes, so the value runs up to the "';" that ends it.
+func parseStreamTitle(meta string) (string, bool) {
+	const key = "StreamTitle='"
+	i := strings.Index(meta, key)
+	if i < 0 {
+		return "", false
+	}
+	title := meta[i+len(key):]
+	if end := strings.Index(title, "';"); end >= 0 {
+		title = title[:end]
+	} else {
+		title = strings.TrimSuffix(title, "'")
+	}
+	return strings.TrimSpace(title), true
+}
+
+// requestStream requests url from the start, asking a live stream to include ICY metadata.
+func requestStream(ctx context.Context, client *http.Client, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, permanentError{fmt.Errorf("failed to create request: %w", err)}
+	}
+	req.Header.Set("Icy-MetaData", "1")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download: %w", err)
+	}
+	return resp, nil
+}
+
+// isLive reports whether resp is a live broadcast rather than a file. Icecast and
+// Shoutcast servers mark their streams with icy-* headers.
+func isLive(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusOK {
+		return false
+	}
+	for _, h := range []string{"Icy-Metaint", "Icy-Name", "Icy-Br", "Icy-Genre"} {
+		if resp.Header.Get(h) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// openURL requests url and starts receiving it: as a live Radio if the server says it is
+// a broadcast, otherwise as a Stream. The request is abandoned if ctx ends first; after
+// that the returned Stream or Radio has to be closed.
+func openURL(ctx context.Context, client *http.Client, url string) (*Stream, *Radio, error) {
+	dctx, cancel := context.WithCancel(context.Background())
+	stop := context.AfterFunc(ctx, cancel)
+	resp, err := requestStream(dctx, client, url)
+	if !stop() {
+		if err == nil {
+			resp.Body.Close()
+		}
+		return nil, nil, fmt.Errorf("download cancelled: %w", ctx.Err())
+	}
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	if isLive(resp) {
+		return nil, startRadio(client, url, dctx, cancel, resp), nil
+	}
+	return startStream(client, url, dctx, cancel, resp), nil, nil
+}
@@ -0,0 +1,236 @@
+package audio
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// icyBlock encodes text as an ICY metadata block: a length byte in units of 16, then the
+// text padded with zeros.
+func icyBlock(text string) []byte {
+	n := (len(text) + 15) / 16
+	block := make([]byte, 1+n*16)
+	block[0] = byte(n)
+	copy(block[1:], text)
+	return block
+}
+
+// icyBody interleaves audio with metadata blocks every interval bytes, announcing the
+// titles in turn and then nothing.
+func icyBody(audio []byte, interval int, titles ...string) []byte {
+	var b bytes.Buffer
+	for i := 0; i < len(audio); i += interval {
+		b.Write(audio[i:min(i+interval, len(audio))])
+		if len(titles) > 0 {
+			b.Write(icyBlock("StreamTitle='" + titles[0] + "';StreamUrl='';"))
+			titles = titles[1:]
+		} else {
+			b.WriteByte(0)
+		}
+	}
+	return b.Bytes()
+}
+
+// serveStation serves a live stream. Each request gets the next body in turn; once the
+// bodies run out, requests fail with 503.
+func serveStation(t *testing.T, interval int, bodies ...[]byte) *httptest.Server {
+	t.Helper()
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Icy-MetaData") != "1" {
+			t.Errorf("request without Icy-MetaData: 1")
+		}
+		mu.Lock()
+		if len(bodies) == 0 {
+			mu.Unlock()
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body := bodies[0]
+		bodies = bodies[1:]
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "audio/mpeg")
+		w.Header().Set("Icy-Name", "Test FM")
+		w.Header().Set("Icy-Br", "128")
+		w.Header().Set("Icy-Metaint", strconv.Itoa(interval))
+		w.Write(body)
+		w.(http.Flusher).Flush()
+		// The connection drops here, as it would mid-broadcast.
+		panic(http.ErrAbortHandler)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRadioStripsMetadata(t *testing.T) {
+	audio := testFile(5000)
+	srv := serveStation(t, 1000, icyBody(audio, 1000, "Artist - One", "Artist - Two"))
+
+	st, radio, err := openURL(context.Background(), srv.Client(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st != nil || radio == nil {
+		t.Fatal("openURL didn't recognise a live stream")
+	}
+	defer radio.Close()
+
+	got := make([]byte, len(audio))
+	if _, err := io.ReadFull(radio.newReader(), got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, audio) {
+		t.Fatal("audio differs from what was sent")
+	}
+	info := radio.Info()
+	if info.Name != "Test FM" || info.BitRate != 128 || info.Title != "Artist - Two" {
+		t.Errorf("Info() = %+v", info)
+	}
+	if status := radio.Status(); !status.Live || status.Size != -1 {
+		t.Errorf("Status() = %+v", status)
+	}
+}
+
+func TestRadioReconnects(t *testing.T) {
+	first, second := testFile(3000), bytes.Repeat([]byte{0x42}, 3000)
+	srv := serveStation(t, 1000, icyBody(first, 1000), icyBody(second, 1000))
+
+	_, radio, err := openURL(context.Background(), srv.Client(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer radio.Close()
+
+	r := radio.newReader()
+	got := make([]byte, len(first))
+	if _, err := io.ReadFull(r, got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, first) {
+		t.Fatal("first connection's audio differs")
+	}
+	// The next connection is a new session: the reader skips to its start.
+	if _, err := r.Read(got); !errors.Is(err, errSkipped) {
+		t.Fatalf("read after reconnecting: %v, want errSkipped", err)
+	}
+	if _, err := io.ReadFull(r, got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, second) {
+		t.Fatal("second connection's audio differs")
+	}
+	if status := radio.Status(); status.Retries < 1 {
+		t.Errorf("Retries = %d, want at least 1", status.Retries)
+	}
+}
+
+func TestRadioAcceptsShoutcastV1(t *testing.T) {
+	audio := testFile(3000)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if _, err := http.ReadRequest(bufio.NewReader(conn)); err != nil {
+			return
+		}
+		// SHOUTcast v1 answers with its own status line rather than an HTTP one.
+		fmt.Fprint(conn, "ICY 200 OK\r\nicy-name:Old School FM\r\nicy-metaint:1000\r\ncontent-type:audio/mpeg\r\n\r\n")
+		conn.Write(icyBody(audio, 1000, "Artist - Song"))
+	}()
+
+	_, radio, err := openURL(context.Background(), defaultHTTPClient(), "http://"+ln.Addr().String()+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if radio == nil {
+		t.Fatal("openURL didn't recognise a SHOUTcast v1 stream")
+	}
+	defer radio.Close()
+
+	got := make([]byte, len(audio))
+	if _, err := io.ReadFull(radio.newReader(), got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, audio) {
+		t.Fatal("audio differs from what was sent")
+	}
+	if info := radio.Info(); info.Name != "Old School FM" || info.Title != "Artist - Song" {
+		t.Errorf("Info() = %+v", info)
+	}
+}
+
+func TestOpenURLStreamsFiles(t *testing.T) {
+	data := testFile(1000)
+	srv, _ := serveFile(t, data, nil)
+
+	st, radio, err := openURL(context.Background(), srv.Client(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if radio != nil {
+		t.Fatal("a plain file was taken for a live stream")
+	}
+	defer st.Close()
+	got, err := st.Bytes(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("downloaded file differs")
+	}
+}
+
+func TestParseStreamTitle(t *testing.T) {
+	tests := []struct {
+		meta, want string
+		ok         bool
+	}{
+		{"StreamTitle='Artist - Song';StreamUrl='';", "Artist - Song", true},
+		{"StreamTitle='Guns N' Roses - Don't Cry';", "Guns N' Roses - Don't Cry", true},
+		{"StreamTitle='No terminator'", "No terminator", true},
+		{"StreamUrl='http://example.com';", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := parseStreamTitle(tt.meta)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseStreamTitle(%q) = %q, %v; want %q, %v", tt.meta, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestOnlyRadioSourcesAreLive(t *testing.T) {
+	file, err := NewPCMSource(testWAV(8000, 800))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		src  PCMSource
+		want bool
+	}{
+		{&radioSource{}, true},
+		{file, false},
+	} {
+		live, ok := tt.src.(liveSource)
+		if got := ok && live.Live(); got != tt.want {
+			t.Errorf("%T live = %v, want %v", tt.src, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,653 @@
+package audio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// streamStartBuffer is how much of a URL is downloaded before it can start playing.
+	streamStartBuffer = 256 << 10
+	// streamChunk is the size of each read from the network.
+	streamChunk = 32 << 10
+	// streamMaxSkip is how far beyond the download a reader can wait before the download
+	// jumps there with a Range request instead of reading up to it.
+	streamMaxSkip = 512 << 10
+	// streamRetries is how many times in a row a dropped download is resumed before it fails.
+	streamRetries = 5
+	// streamRetryDelay is the wait before resuming; it grows with each retry in a row.
+	streamRetryDelay = 500 * time.Millisecond
+)
+
+// errNoRanges is returned when a download has to continue from an offset but the server
+// doesn't accept Range requests.
+var errNoRanges = errors.New("server does not support range requests")
+
+// errInterrupted is returned by a stream read that gave up waiting for data because
+// playback paused, seeked or stopped.
+var errInterrupted = errors.New("stream read interrupted")
+
+// permanentError marks a download error that retrying won't fix.
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// span is a downloaded byte range [start, end).
+type span struct{ start, end int64 }
+
+// StreamStatus describes how far a Stream's download has got.
+type StreamStatus struct {
+	// Size is the length of the file in bytes, or -1 if the server didn't say.
+	Size int64
+	// Downloaded is the number of bytes downloaded so far.
+	Downloaded int64
+	// Ahead is the number of bytes downloaded right after the last read: what playback has left.
+	Ahead int64
+	// Waiting is set while a reader waits for data that hasn't arrived yet.
+	Waiting bool
+	// Retries counts the times the download was resumed after the connection dropped.
+	Retries int
+	// Done is set once the whole file is downloaded.
+	Done bool
+	// Live is set for a live broadcast, which has no size and never finishes.
+	Live bool
+	// Err is why the download stopped early, if it did.
+	Err error
+}
+
+// Stream downloads an HTTP resource in the background while it is being read, so a
+// track can start playing after a small buffer. A dropped connection is resumed with
+// a Range request, and a reader seeking past the download makes it jump there, when
+// the server supports ranges. Readers block until the bytes they want arrive.
+type Stream struct {
+	url    string
+	client *http.Client
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu   sync.Mutex
+	cond *sync.Cond
+	// first is the response the stream was opened with, until the download takes it over.
+	first *http.Response
+	// data holds the file; while its size is unknown it grows as bytes arrive.
+	data []byte
+	// have lists the downloaded ranges of data, sorted and merged.
+	have []span
+	size int64
+	// ranges is set when the server accepts Range requests.
+	ranges bool
+	// want is the offset a reader is waiting for, or -1; readPos is where the last read ended.
+	want    int64
+	readPos int64
+	retries int
+	done    bool
+	err     error
+}
+
+// OpenStream starts downloading url with client. The download runs until the whole
+// file has arrived, it fails for good or the stream is closed.
+func OpenStream(client *http.Client, url string) *Stream {
+	ctx, cancel := context.WithCancel(context.Background())
+	return startStream(client, url, ctx, cancel, nil)
+}
+
+// startStream starts a Stream whose download runs until ctx is done. first, if not nil, is
+// the response to a request for the whole file, made with ctx.
+func startStream(client *http.Client, url string, ctx context.Context, cancel context.CancelFunc, first *http.Response) *Stream {
+	s := &Stream{
+		url:    url,
+		client: client,
+		ctx:    ctx,
+		cancel: cancel,
+		first:  first,
+		size:   -1,
+		want:   -1,
+	}
+	s.cond = sync.NewCond(&s.mu)
+	go s.run()
+	return s
+}
+
+// Close stops the download. What has been downloaded stays readable.
+func (s *Stream) Close() {
+	s.cancel()
+}
+
+// NewReader returns a reader over the stream with its own position.
+func (s *Stream) NewReader() io.ReadSeeker {
+	return &streamReader{s: s}
+}
+
+// Status reports the progress of the download.
+func (s *Stream) Status() StreamStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := StreamStatus{
+		Size:    s.size,
+		Waiting: s.want >= 0,
+		Retries: s.retries,
+		Done:    s.done,
+		Err:     s.err,
+	}
+	for _, sp := range s.have {
+		st.Downloaded += sp.end - sp.start
+	}
+	st.Ahead = s.spanEnd(s.readPos) - s.readPos
+	return st
+}
+
+// Bytes waits for the whole file to download and returns it.
+func (s *Stream) Bytes(ctx context.Context) ([]byte, error) {
+	data, _, err := s.waitComplete(ctx, 0)
+	return data, err
+}
+
+// waitBuffered waits up to timeout (forever if 0) for the first n bytes of the file,
+// or all of it if shorter, to download. It reports whether they have.
+func (s *Stream) waitBuffered(ctx context.Context, n int64, timeout time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ready := func() bool {
+		return s.done || s.spanEnd(0) >= n || (s.size >= 0 && s.spanEnd(0) >= s.size)
+	}
+	if err := s.wait(ctx, timeout, func() bool { return ready() || s.err != nil }); err != nil {
+		return false, err
+	}
+	if ready() {
+		return true, nil
+	}
+	return false, s.err
+}
+
+// waitComplete waits up to timeout (forever if 0) for the download to finish, and
+// returns the file if it has.
+func (s *Stream) waitComplete(ctx context.Context, timeout time.Duration) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.wait(ctx, timeout, func() bool { return s.done || s.err != nil }); err != nil {
+		return nil, false, err
+	}
+	if s.err != nil {
+		return nil, false, s.err
+	}
+	return s.data, s.done, nil
+}
+
+// complete returns the file if it has been downloaded in full.
+func (s *Stream) complete() ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data, s.done
+}
+
+// prefix returns the bytes downloaded without a gap from the start of the file.
+func (s *Stream) prefix() []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	end := s.spanEnd(0)
+	return s.data[:end:end]
+}
+
+// wait blocks until ready reports true, ctx is done or timeout (if non-zero) passes.
+// It must be called with s.mu held.
+func (s *Stream) wait(ctx context.Context, timeout time.Duration, ready func() bool) error {
+	wake := func() {
+		s.mu.Lock()
+		s.cond.Broadcast()
+		s.mu.Unlock()
+	}
+	stop := context.AfterFunc(ctx, wake)
+	defer stop()
+
+	expired := false
+	if timeout > 0 {
+		t := time.AfterFunc(timeout, func() {
+			s.mu.Lock()
+			expired = true
+			s.cond.Broadcast()
+			s.mu.Unlock()
+		})
+		defer t.Stop()
+	}
+
+	for !ready() {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("download cancelled: %w", err)
+		}
+		if expired {
+			return nil
+		}
+		s.cond.Wait()
+	}
+	return nil
+}
+
+// run downloads the file, continuing from the next missing byte after each request ends.
+func (s *Stream) run() {
+	failures := 0
+	for {
+		off, ok := s.nextGap()
+		if !ok {
+			s.finish(nil)
+			return
+		}
+
+		progressed, err := s.fetch(off)
+		if err == nil {
+			failures = 0
+			continue
+		}
+		if s.ctx.Err() != nil {
+			s.finish(fmt.Errorf("download cancelled: %w", s.ctx.Err()))
+			return
+		}
+		if progressed {
+			failures = 0
+		}
+		var perm permanentError
+		if errors.As(err, &perm) || failures >= streamRetries {
+			s.finish(err)
+			return
+		}
+		failures++
+
+		s.mu.Lock()
+		s.retries++
+		s.mu.Unlock()
+		logDebug("Stream %s: %v; resuming (retry %d)", s.url, err, failures)
+
+		select {
+		case <-s.ctx.Done():
+		case <-time.After(time.Duration(failures) * streamRetryDelay):
+		}
+	}
+}
+
+// nextGap returns the offset to download from next: where a reader is waiting, else the
+// first missing byte after the last read, else the first missing byte at all. It reports
+// false once the whole file is there.
+func (s *Stream) nextGap() (int64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.size < 0 {
+		// Without a length only a download from front to back is possible.
+		return s.spanEnd(0), true
+	}
+	for _, from := range []int64{s.want, s.readPos, 0} {
+		if from < 0 || from >= s.size {
+			continue
+		}
+		if end := s.spanEnd(from); end < s.size {
+			return end, true
+		}
+	}
+	return 0, false
+}
+
+// fetch requests the file from off on and stores what arrives, until the response ends,
+// the download runs into bytes it already has, or a reader waits elsewhere. progressed
+// reports whether any bytes arrived.
+func (s *Stream) fetch(off int64) (progressed bool, err error) {
+	resp, err := s.request(off)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK && off == 0:
+		s.start(resp.ContentLength, resp.Header.Get("Accept-Ranges") == "bytes")
+	case resp.StatusCode == http.StatusPartialContent:
+		first, total, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || first != off {
+			return false, permanentError{fmt.Errorf("unexpected Content-Range %q", resp.Header.Get("Content-Range"))}
+		}
+		s.start(total, true)
+	case resp.StatusCode == http.StatusOK:
+		// Asked for a range but sent the whole file.
+		return false, permanentError{errNoRanges}
+	default:
+		err := fmt.Errorf("server returned %d", resp.StatusCode)
+		if resp.StatusCode < 500 {
+			return false, permanentError{err}
+		}
+		return false, err
+	}
+
+	buf := make([]byte, streamChunk)
+	pos := off
+	for {
+		n, err := resp.Body.Read(buf)
+		if n > 0 {
+			progressed = true
+			stop := s.store(pos, buf[:n])
+			pos += int64(n)
+			if stop {
+				return true, nil
+			}
+		}
+		if err == io.EOF {
+			return progressed, s.endAt(pos)
+		}
+		if err != nil {
+			return progressed, fmt.Errorf("download error: %w", err)
+		}
+	}
+}
+
+// request asks for the file from off on. The first request, always from the start, may
+// already have been made when the stream was opened.
+func (s *Stream) request(off int64) (*http.Response, error) {
+	s.mu.Lock()
+	resp, ranges := s.first, s.ranges
+	s.first = nil
+	s.mu.Unlock()
+	if resp != nil {
+		return resp, nil
+	}
+	if off > 0 && !ranges {
+		return nil, permanentError{errNoRanges}
+	}
+
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, permanentError{fmt.Errorf("failed to create request: %w", err)}
+	}
+	if off > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", off))
+	}
+	resp, err = s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download: %w", err)
+	}
+	return resp, nil
+}
+
+// start records what a response says about the file: its size (-1 if unknown) and
+// whether the server takes Range requests.
+func (s *Stream) start(size int64, ranges bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ranges = ranges
+	if s.size < 0 && size >= 0 {
+		data := make([]byte, size)
+		copy(data, s.data)
+		s.data = data
+		s.size = size
+	}
+}
+
+// store copies bytes downloaded at pos into the file and wakes waiting readers. It
+// reports whether the request should stop: because it reached bytes that are already
+// there, or because a reader is waiting too far from it.
+func (s *Stream) store(pos int64, b []byte) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	end := pos + int64(len(b))
+	if s.size >= 0 {
+		if end > s.size {
+			end = s.size
+		}
+		if pos >= end {
+			return true
+		}
+		copy(s.data[pos:end], b)
+	} else {
+		s.data = append(s.data[:pos], b...)
+	}
+	s.have = addSpan(s.have, pos, end)
+	s.cond.Broadcast()
+
+	if s.size < 0 {
+		return false
+	}
+	if end >= s.size || s.spanEnd(end) > end {
+		return true
+	}
+	return s.want >= 0 && s.spanEnd(s.want) == s.want && (s.want < end || s.want > end+streamMaxSkip)
+}
+
+// endAt handles the end of a response body at pos. If the size was unknown, the file
+// ends there; otherwise ending short of the size means the connection dropped.
+func (s *Stream) endAt(pos int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.size < 0 {
+		s.size = pos
+		s.data = s.data[:pos]
+		s.cond.Broadcast()
+		return nil
+	}
+	if pos < s.size && s.spanEnd(pos) == pos {
+		return fmt.Errorf("download ended early at %d of %d bytes: %w", pos, s.size, io.ErrUnexpectedEOF)
+	}
+	return nil
+}
+
+// finish ends the download, successfully if err is nil.
+func (s *Stream) finish(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil {
+		logDebug("Stream %s failed: %v", s.url, err)
+		s.err = err
+	} else {
+		s.done = true
+	}
+	s.cond.Broadcast()
+}
+
+// spanEnd returns the end of the downloaded range containing off, or off if that byte
+// hasn't been downloaded. It must be called with s.mu held.
+func (s *Stream) spanEnd(off int64) int64 {
+	for _, sp := range s.have {
+		if sp.start <= off && off < sp.end {
+			return sp.end
+		}
+	}
+	return off
+}
+
+// addSpan merges [start, end) into sorted, disjoint spans.
+func addSpan(spans []span, start, end int64) []span {
+	out := make([]span, 0, len(spans)+1)
+	i := 0
+	for ; i < len(spans) && spans[i].end < start; i++ {
+		out = append(out, spans[i])
+	}
+	merged := span{start, end}
+	for ; i < len(spans) && spans[i].start <= end; i++ {
+		if spans[i].start < merged.start {
+			merged.start = spans[i].start
+		}
+		if spans[i].end > merged.end {
+			merged.end = spans[i].end
+		}
+	}
+	out = append(out, merged)
+	return append(out, spans[i:]...)
+}
+
+// parseContentRange reads "bytes first-last/total" from a 206 response. total is -1 if
+// the server gives it as "*".
+func parseContentRange(h string) (first, total int64, ok bool) {
+	rest, found := strings.CutPrefix(h, "bytes ")
+	if !found {
+		return 0, 0, false
+	}
+	rng, size, found := strings.Cut(rest, "/")
+	if !found {
+		return 0, 0, false
+	}
+	from, _, found := strings.Cut(rng, "-")
+	if !found {
+		return 0, 0, false
+	}
+	first, err := strconv.ParseInt(from, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	total = -1
+	if size != "*" {
+		if total, err = strconv.ParseInt(size, 10, 64); err != nil {
+			return 0, 0, false
+		}
+	}
+	return first, total, true
+}
+
+// streamReader reads a Stream from its own position, waiting for bytes that haven't
+// been downloaded yet.
+type streamReader struct {
+	s   *Stream
+	pos int64
+	// interrupted makes a read that would wait fail with errInterrupted instead. Guarded by s.mu.
+	interrupted bool
+}
+
+func (r *streamReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for {
+		if s.size >= 0 && r.pos >= s.size {
+			return 0, io.EOF
+		}
+		if end := s.spanEnd(r.pos); end > r.pos {
+			if s.want == r.pos {
+				s.want = -1
+			}
+			n := copy(p, s.data[r.pos:end])
+			r.pos += int64(n)
+			s.readPos = r.pos
+			return n, nil
+		}
+		if s.err != nil {
+			return 0, s.err
+		}
+		if r.interrupted {
+			return 0, errInterrupted
+		}
+		s.want = r.pos
+		s.cond.Wait()
+	}
+}
+
+// Seek sets the position of the next read. Seeking from the end waits until the size is known.
+func (r *streamReader) Seek(offset int64, whence int) (int64, error) {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.pos
+	case io.SeekEnd:
+		for s.size < 0 && s.err == nil {
+			s.cond.Wait()
+		}
+		if s.size < 0 {
+			return 0, s.err
+		}
+		offset += s.size
+	default:
+		return 0, fmt.Errorf("invalid whence %d", whence)
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("negative position %d", offset)
+	}
+	r.pos = offset
+	return offset, nil
+}
+
+// setInterrupted turns interruption of waiting reads on or off, waking any read waiting now.
+func (r *streamReader) setInterrupted(on bool) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.interrupted = on
+	r.s.cond.Broadcast()
+}
+
+// decode opens a PCMSource over the stream, in the format its first bytes show.
+func (s *Stream) decode() (PCMSource, string, error) {
+	r := &streamReader{s: s}
+	if err := skipID3v2(r); err != nil {
+		return nil, "", err
+	}
+	header := make([]byte, 512)
+	n, err := io.ReadFull(r, header)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, "", err
+	}
+	d, err := DetectDecoder(header[:n])
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, "", err
+	}
+	src, err := d.Decode(r)
+	if err != nil {
+		return nil, d.Name(), fmt.Errorf("%s: %w", d.Name(), err)
+	}
+	return &streamSource{PCMSource: src, r: r}, d.Name(), nil
+}
+
+// streamSource is a PCMSource decoded from a Stream. A read interrupted while waiting for
+// the network may leave the decoder mid-frame, so the next read first seeks back to the
+// frame playback had reached.
+type streamSource struct {
+	PCMSource
+	r *streamReader
+	// read counts the PCM bytes returned since the last seek, from frame.
+	read  int64
+	frame int64
+	dirty bool
+}
+
+func (s *streamSource) Read(p []byte) (int, error) {
+	if s.dirty {
+		if err := s.SeekFrame(s.frame + s.read/int64(frameSize(s))); err != nil {
+			return 0, err
+		}
+	}
+	n, err := s.PCMSource.Read(p)
+	s.read += int64(n)
+	if errors.Is(err, errInterrupted) {
+		s.dirty = true
+	}
+	return n, err
+}
+
+func (s *streamSource) SeekFrame(frame int64) error {
+	if err := s.PCMSource.SeekFrame(frame); err != nil {
+		return err
+	}
+	s.frame, s.read, s.dirty = frame, 0, false
+	return nil
+}
+
+func (s *streamSource) setInterrupted(on bool) {
+	s.r.setInterrupted(on)
+}
@@ -0,0 +1,314 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"gowav/internal/cache"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testFile returns n bytes of recognisable content.
+func testFile(n int) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	return data
+}
+
+// serveFile serves data with Range support, like a typical static file server. Before
+// a whole-file response, handle may take over the request; it reports whether it did.
+func serveFile(t *testing.T, data []byte, handle func(w http.ResponseWriter, r *http.Request) bool) (*httptest.Server, func() []string) {
+	t.Helper()
+	var (
+		mu     sync.Mutex
+		ranges []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ranges = append(ranges, r.Header.Get("Range"))
+		mu.Unlock()
+		if r.Header.Get("Range") == "" && handle != nil && handle(w, r) {
+			return
+		}
+		http.ServeContent(w, r, "file", time.Time{}, bytes.NewReader(data))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), ranges...)
+	}
+}
+
+func TestStreamReadsBeforeDownloadFinishes(t *testing.T) {
+	data := testFile(200 << 10)
+	release := make(chan struct{})
+	srv, _ := serveFile(t, data, func(w http.ResponseWriter, r *http.Request) bool {
+		w.Header().Set("Content-Length", "204800")
+		w.Write(data[:64<<10])
+		w.(http.Flusher).Flush()
+		<-release
+		w.Write(data[64<<10:])
+		return true
+	})
+
+	st := OpenStream(srv.Client(), srv.URL)
+	defer st.Close()
+
+	head := make([]byte, 64<<10)
+	if _, err := io.ReadFull(st.NewReader(), head); err != nil {
+		t.Fatalf("reading the first part: %v", err)
+	}
+	if !bytes.Equal(head, data[:len(head)]) {
+		t.Fatal("first part differs")
+	}
+	if status := st.Status(); status.Done || status.Size != int64(len(data)) {
+		t.Fatalf("status before release = %+v", status)
+	}
+
+	close(release)
+	got, err := st.Bytes(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("downloaded file differs")
+	}
+}
+
+func TestStreamResumesAfterDroppedConnection(t *testing.T) {
+	data := testFile(300 << 10)
+	srv, ranges := serveFile(t, data, func(w http.ResponseWriter, r *http.Request) bool {
+		w.Header().Set("Accept-Ranges", "bytes")
+		w.Header().Set("Content-Length", "307200")
+		w.Write(data[:100<<10])
+		w.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	})
+
+	st := OpenStream(srv.Client(), srv.URL)
+	defer st.Close()
+
+	got, err := io.ReadAll(st.NewReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("resumed file differs")
+	}
+	if status := st.Status(); status.Retries != 1 {
+		t.Errorf("Retries = %d, want 1", status.Retries)
+	}
+	if r := ranges(); len(r) != 2 || !strings.HasPrefix(r[1], "bytes=") || r[1] == "bytes=0-" {
+		t.Errorf("requests = %q, want a resume from where the first one dropped", r)
+	}
+}
+
+func TestStreamSeekUsesRange(t *testing.T) {
+	data := testFile(4 << 20)
+	srv, ranges := serveFile(t, data, func(w http.ResponseWriter, r *http.Request) bool {
+		// Trickle the whole file, so a seek far ahead has to jump.
+		w.Header().Set("Accept-Ranges", "bytes")
+		w.Header().Set("Content-Length", "4194304")
+		for off := 0; off < len(data); off += 16 << 10 {
+			if _, err := w.Write(data[off : off+16<<10]); err != nil {
+				return true
+			}
+			w.(http.Flusher).Flush()
+			time.Sleep(10 * time.Millisecond)
+		}
+		return true
+	})
+
+	st := OpenStream(srv.Client(), srv.URL)
+	defer st.Close()
+
+	r := st.NewReader()
+	off := int64(3 << 20)
+	if _, err := r.Seek(off, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 1000)
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.ReadFull(r, buf)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("read after seek waited for the trickling download")
+	}
+	if !bytes.Equal(buf, data[off:off+1000]) {
+		t.Fatal("bytes after seek differ")
+	}
+
+	jumped := false
+	for _, h := range ranges() {
+		if strings.HasPrefix(h, "bytes=3145728-") {
+			jumped = true
+		}
+	}
+	if !jumped {
+		t.Errorf("requests = %q, want a Range request from the seek position", ranges())
+	}
+}
+
+func TestStreamWithoutRangesFailsAfterDrop(t *testing.T) {
+	data := testFile(100 << 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "102400")
+		w.Write(data[:10<<10])
+		w.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}))
+	defer srv.Close()
+
+	st := OpenStream(srv.Client(), srv.URL)
+	defer st.Close()
+
+	if _, err := st.Bytes(context.Background()); err == nil {
+		t.Fatal("Bytes succeeded on a truncated download")
+	}
+}
+
+// testWAV returns a mono 16-bit WAV file of a 440 Hz tone.
+func testWAV(sampleRate, frames int) []byte {
+	var b bytes.Buffer
+	dataSize := frames * 2
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, uint32(36+dataSize))
+	b.WriteString("WAVEfmt ")
+	for _, v := range []any{uint32(16), uint16(1), uint16(1), uint32(sampleRate), uint32(sampleRate * 2), uint16(2), uint16(16)} {
+		binary.Write(&b, binary.LittleEndian, v)
+	}
+	b.WriteString("data")
+	binary.Write(&b, binary.LittleEndian, uint32(dataSize))
+	for i := 0; i < frames; i++ {
+		v := int16(8000 * math.Sin(2*math.Pi*440*float64(i)/float64(sampleRate)))
+		binary.Write(&b, binary.LittleEndian, v)
+	}
+	return b.Bytes()
+}
+
+func TestProcessorStreamsURL(t *testing.T) {
+	wav := testWAV(8000, 8000*60)
+	srv, _ := serveFile(t, wav, nil)
+
+	p := NewProcessor()
+	p.SetHTTPClient(srv.Client())
+	events, unsubscribe := p.Subscribe()
+	defer unsubscribe()
+	defer p.Close()
+
+	if err := p.LoadFile(context.Background(), srv.URL+"/tone.wav"); err != nil {
+		t.Fatal(err)
+	}
+	for ev := range events {
+		if ev.Kind == EventError {
+			t.Fatalf("load failed: %v", ev.Status.Err)
+		}
+		if ev.Kind == EventMetadataReady {
+			break
+		}
+	}
+
+	md := p.GetMetadata()
+	if md.Format != "wav" || md.Duration != time.Minute || md.FileSize != int64(len(wav)) {
+		t.Errorf("metadata = %s, %v, %d bytes", md.Format, md.Duration, md.FileSize)
+	}
+	if _, ok := p.StreamStatus(); !ok {
+		t.Error("StreamStatus reports no stream")
+	}
+
+	src, err := p.OpenSource()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := src.SeekFrame(8000 * 30); err != nil {
+		t.Fatal(err)
+	}
+	pcm, err := io.ReadAll(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := len(wav) - 44 - 8000*30*2; len(pcm) != want {
+		t.Errorf("read %d bytes of PCM after seeking halfway, want %d", len(pcm), want)
+	}
+}
+
+func TestProcessorLoadsCachedURL(t *testing.T) {
+	wav := testWAV(8000, 8000*5)
+	srv, ranges := serveFile(t, wav, nil)
+	c, err := cache.Open(t.TempDir(), 1<<30)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewProcessor()
+	p.SetHTTPClient(srv.Client())
+	p.SetCache(c)
+	defer p.Close()
+	url := srv.URL + "/tone.wav"
+
+	load := func() ProcessingStatus {
+		t.Helper()
+		events, unsubscribe := p.Subscribe()
+		defer unsubscribe()
+		if err := p.LoadFile(context.Background(), url); err != nil {
+			t.Fatal(err)
+		}
+		for ev := range events {
+			if ev.Kind == EventError {
+				t.Fatalf("load failed: %v", ev.Status.Err)
+			}
+			if ev.Kind == EventMetadataReady {
+				return ev.Status
+			}
+		}
+		t.Fatal("no EventMetadataReady")
+		return ProcessingStatus{}
+	}
+
+	if status := load(); status.Cached {
+		t.Fatal("first load reported a cached copy")
+	}
+	// The finished download goes into the cache in the background.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, ok := c.Lookup(url); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("streamed file never reached the cache")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	requests := len(ranges())
+	status := load()
+	if !status.Cached || !strings.Contains(status.Message, "cached") {
+		t.Errorf("second load status = %+v, want it served from the cache", status)
+	}
+	if n := len(ranges()); n != requests {
+		t.Errorf("second load made %d requests, want none", n-requests)
+	}
+	if md := p.GetMetadata(); md.Format != "wav" || md.Duration != 5*time.Second {
+		t.Errorf("metadata = %s, %v", md.Format, md.Duration)
+	}
+	if _, ok := p.StreamStatus(); ok {
+		t.Error("a cached track reports a download")
+	}
+}
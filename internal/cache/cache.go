@@ -0,0 +1,346 @@
+// Package cache keeps copies of remote tracks on disk, so playing them again needs no
+// download. Files are stored under the SHA-256 of their content, and an index maps the
+// URLs they came from to them. Once the cache outgrows its limit the least recently used
+// files are evicted; kept files, such as explicit downloads, never are.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Entry is one cached file.
+type Entry struct {
+	Hash string `json:"hash"`
+	// URLs are the addresses the file was fetched from; identical files share an entry.
+	URLs     []string  `json:"urls"`
+	Title    string    `json:"title,omitempty"`
+	Size     int64     `json:"size"`
+	Added    time.Time `json:"added"`
+	LastUsed time.Time `json:"last_used"`
+	// Kept files don't count towards the limit and are never evicted.
+	Kept bool `json:"kept,omitempty"`
+}
+
+// Cache is a directory of cached files with its index. It is safe for concurrent use.
+type Cache struct {
+	mu      sync.Mutex
+	dir     string
+	limit   int64
+	entries map[string]*Entry
+	// dirty is set when the index has changes that haven't been saved. Lookups only mark
+	// it, so playing a cached track doesn't rewrite the index; see Close.
+	dirty bool
+}
+
+// indexFile is the on-disk layout of the index.
+type indexFile struct {
+	Version int      `json:"version"`
+	Entries []*Entry `json:"entries"`
+}
+
+const indexVersion = 1
+
+// Open loads the cache in dir, which is created when the first file is stored. limit is
+// the most the files that aren't kept may take up, in bytes. Index entries whose file has
+// gone are dropped.
+func Open(dir string, limit int64) (*Cache, error) {
+	c := &Cache{dir: dir, limit: limit, entries: make(map[string]*Entry)}
+
+	data, err := os.ReadFile(c.indexPath())
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var idx indexFile
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return nil, fmt.Errorf("corrupt cache index %s: %w", c.indexPath(), err)
+	}
+	for _, e := range idx.Entries {
+		if _, err := os.Stat(c.path(e.Hash)); err == nil {
+			c.entries[e.Hash] = e
+		}
+	}
+	return c, nil
+}
+
+// Dir returns the directory the cache is kept in.
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Limit returns the size limit in bytes.
+func (c *Cache) Limit() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.limit
+}
+
+// SetLimit changes the size limit, evicting files if the cache is now over it.
+func (c *Cache) SetLimit(limit int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.limit = limit
+	if c.evict() == 0 {
+		return nil
+	}
+	return c.save()
+}
+
+// Close saves the index if lookups have changed it since it was last written. The
+// cache can still be used afterwards.
+func (c *Cache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return nil
+	}
+	return c.save()
+}
+
+// Lookup returns the path of the cached copy of url, and marks it as just used. The
+// change is saved with the next change to the cache, or by Close.
+func (c *Cache) Lookup(url string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := c.find(url)
+	if e == nil {
+		return "", false
+	}
+	path := c.path(e.Hash)
+	if _, err := os.Stat(path); err != nil {
+		// Deleted behind our back.
+		delete(c.entries, e.Hash)
+		c.dirty = true
+		return "", false
+	}
+	e.LastUsed = time.Now()
+	c.dirty = true
+	return path, true
+}
+
+// Put stores the file read from r as the content of url. If keep is set the file is kept
+// until it is removed with Clear(true). A file that doesn't fit the limit on its own isn't
+// kept in the cache, but Put still succeeds.
+func (c *Cache) Put(url, title string, r io.Reader, keep bool) (Entry, error) {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return Entry{}, err
+	}
+	tmp, err := os.CreateTemp(c.dir, "download-*")
+	if err != nil {
+		return Entry{}, err
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), r)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return Entry{}, err
+	}
+	hash := hex.EncodeToString(h.Sum(nil))
+	path := c.path(hash)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return Entry{}, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return Entry{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// The URL may have served different content before.
+	if old := c.find(url); old != nil && old.Hash != hash {
+		c.forget(old, url)
+	}
+	now := time.Now()
+	e, ok := c.entries[hash]
+	if !ok {
+		e = &Entry{Hash: hash, Size: size, Added: now}
+		c.entries[hash] = e
+	}
+	if c.find(url) == nil {
+		e.URLs = append(e.URLs, url)
+	}
+	if title != "" {
+		e.Title = title
+	}
+	e.Kept = e.Kept || keep
+	e.LastUsed = now
+	out := *e
+	c.evict()
+	return out, c.save()
+}
+
+// Keep marks the cached copy of url as kept. It reports false if url isn't cached.
+func (c *Cache) Keep(url string) (Entry, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := c.find(url)
+	if e == nil {
+		return Entry{}, false, nil
+	}
+	e.Kept = true
+	return *e, true, c.save()
+}
+
+// Entries returns the cached files, most recently used first.
+func (c *Cache) Entries() []Entry {
+	c.mu.Lock()
+	out := make([]Entry, 0, len(c.entries))
+	for _, e := range c.entries {
+		out = append(out, *e)
+	}
+	c.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].LastUsed.After(out[j].LastUsed) })
+	return out
+}
+
+// Usage returns how much the files take up, split into those counted against the limit and
+// those kept.
+func (c *Cache) Usage() (cached, kept int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, e := range c.entries {
+		if e.Kept {
+			kept += e.Size
+		} else {
+			cached += e.Size
+		}
+	}
+	return cached, kept
+}
+
+// Clear removes the cached files that aren't kept, or every file if all is set, and
+// returns how many it removed and the space freed.
+func (c *Cache) Clear(all bool) (int, int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var (
+		n     int
+		freed int64
+	)
+	for hash, e := range c.entries {
+		if e.Kept && !all {
+			continue
+		}
+		if err := os.Remove(c.path(hash)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return n, freed, err
+		}
+		delete(c.entries, hash)
+		n++
+		freed += e.Size
+	}
+	return n, freed, c.save()
+}
+
+// evict removes the least recently used files that aren't kept until the rest fit within
+// the limit, and returns how many it removed. The caller holds c.mu.
+func (c *Cache) evict() int {
+	var (
+		total      int64
+		candidates []*Entry
+	)
+	for _, e := range c.entries {
+		if !e.Kept {
+			total += e.Size
+			candidates = append(candidates, e)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].LastUsed.Before(candidates[j].LastUsed) })
+
+	n := 0
+	for _, e := range candidates {
+		if total <= c.limit {
+			break
+		}
+		os.Remove(c.path(e.Hash))
+		delete(c.entries, e.Hash)
+		total -= e.Size
+		n++
+	}
+	return n
+}
+
+// find returns the entry url is cached under, or nil. The caller holds c.mu.
+func (c *Cache) find(url string) *Entry {
+	for _, e := range c.entries {
+		for _, u := range e.URLs {
+			if u == url {
+				return e
+			}
+		}
+	}
+	return nil
+}
+
+// forget removes url from e, and e itself, with its file, once no URL refers to it. The
+// caller holds c.mu.
+func (c *Cache) forget(e *Entry, url string) {
+	urls := e.URLs[:0]
+	for _, u := range e.URLs {
+		if u != url {
+			urls = append(urls, u)
+		}
+	}
+	e.URLs = urls
+	if len(urls) == 0 {
+		os.Remove(c.path(e.Hash))
+		delete(c.entries, e.Hash)
+	}
+}
+
+// save writes the index atomically via a temporary file. The caller holds c.mu.
+func (c *Cache) save() error {
+	idx := indexFile{Version: indexVersion}
+	for _, e := range c.entries {
+		idx.Entries = append(idx.Entries, e)
+	}
+	sort.Slice(idx.Entries, func(i, j int) bool { return idx.Entries[i].Hash < idx.Entries[j].Hash })
+
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+	tmp := c.indexPath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, c.indexPath()); err != nil {
+		return err
+	}
+	c.dirty = false
+	return nil
+}
+
+func (c *Cache) indexPath() string {
+	return filepath.Join(c.dir, "index.json")
+}
+
+// path returns where the file with the given hash is stored, fanned out over
+// subdirectories by its first two digits.
+func (c *Cache) path(hash string) string {
+	return filepath.Join(c.dir, hash[:2], hash)
+}
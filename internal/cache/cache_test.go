@@ -0,0 +1,206 @@
+package cache
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func put(t *testing.T, c *Cache, url, content string, keep bool) Entry {
+	t.Helper()
+	e, err := c.Put(url, "", strings.NewReader(content), keep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Keep the LastUsed times of successive puts apart.
+	time.Sleep(2 * time.Millisecond)
+	return e
+}
+
+func TestPutAndLookup(t *testing.T) {
+	dir := t.TempDir()
+	c, err := Open(dir, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := put(t, c, "https://example.com/a.mp3", "audio a", false)
+	if e.Size != 7 || len(e.Hash) != 64 {
+		t.Errorf("entry = %+v", e)
+	}
+
+	path, ok := c.Lookup("https://example.com/a.mp3")
+	if !ok {
+		t.Fatal("Lookup missed a cached URL")
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "audio a" {
+		t.Errorf("cached file = %q, %v", data, err)
+	}
+	if _, ok := c.Lookup("https://example.com/other.mp3"); ok {
+		t.Error("Lookup found a URL that was never cached")
+	}
+
+	// The index survives reopening.
+	c, err = Open(dir, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Lookup("https://example.com/a.mp3"); !ok {
+		t.Error("cached URL lost after reopening")
+	}
+}
+
+func TestIdenticalFilesShareAnEntry(t *testing.T) {
+	c, err := Open(t.TempDir(), 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	put(t, c, "https://a.example/song.mp3", "same bytes", false)
+	put(t, c, "https://b.example/mirror.mp3", "same bytes", false)
+
+	entries := c.Entries()
+	if len(entries) != 1 || len(entries[0].URLs) != 2 {
+		t.Fatalf("entries = %+v, want one entry with both URLs", entries)
+	}
+
+	// A URL whose content changes moves to the new file.
+	put(t, c, "https://a.example/song.mp3", "new bytes", false)
+	if entries := c.Entries(); len(entries) != 2 {
+		t.Fatalf("got %d entries after the content changed, want 2", len(entries))
+	}
+	path, _ := c.Lookup("https://a.example/song.mp3")
+	if data, _ := os.ReadFile(path); !bytes.Equal(data, []byte("new bytes")) {
+		t.Errorf("Lookup returned the old content %q", data)
+	}
+}
+
+func TestEvictsLeastRecentlyUsed(t *testing.T) {
+	c, err := Open(t.TempDir(), 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	put(t, c, "u1", "0123456789", false)
+	put(t, c, "u2", "abcdefghij", false)
+	put(t, c, "kept", "KKKKKKKKKKKKKKKKKKKKKKKKKKKKKK", true)
+	// Using u1 makes u2 the least recently used.
+	if _, ok := c.Lookup("u1"); !ok {
+		t.Fatal("u1 missing before the cache was full")
+	}
+	time.Sleep(2 * time.Millisecond)
+	put(t, c, "u3", "ABCDEFGHIJ", false)
+
+	if _, ok := c.Lookup("u2"); ok {
+		t.Error("u2 was not evicted")
+	}
+	for _, url := range []string{"u1", "u3", "kept"} {
+		if _, ok := c.Lookup(url); !ok {
+			t.Errorf("%s was evicted", url)
+		}
+	}
+	if cached, kept := c.Usage(); cached != 20 || kept != 30 {
+		t.Errorf("Usage() = %d, %d; want 20, 30", cached, kept)
+	}
+
+	// Lowering the limit evicts at once.
+	if err := c.SetLimit(10); err != nil {
+		t.Fatal(err)
+	}
+	if cached, _ := c.Usage(); cached != 10 {
+		t.Errorf("cached = %d after lowering the limit, want 10", cached)
+	}
+}
+
+func TestKeepAndClear(t *testing.T) {
+	c, err := Open(t.TempDir(), 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	put(t, c, "a", "aaa", false)
+	put(t, c, "b", "bbbb", false)
+	if _, ok, err := c.Keep("b"); !ok || err != nil {
+		t.Fatalf("Keep(b) = %v, %v", ok, err)
+	}
+	if _, ok, _ := c.Keep("missing"); ok {
+		t.Error("Keep succeeded for a URL that isn't cached")
+	}
+
+	n, freed, err := c.Clear(false)
+	if err != nil || n != 1 || freed != 3 {
+		t.Errorf("Clear(false) = %d, %d, %v; want 1, 3", n, freed, err)
+	}
+	if _, ok := c.Lookup("b"); !ok {
+		t.Error("Clear(false) removed a kept file")
+	}
+	if n, _, _ := c.Clear(true); n != 1 || len(c.Entries()) != 0 {
+		t.Errorf("Clear(true) removed %d, left %d", n, len(c.Entries()))
+	}
+}
+
+func TestLookupSavesOnClose(t *testing.T) {
+	dir := t.TempDir()
+	c, err := Open(dir, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	put(t, c, "a", "aaa", false)
+	put(t, c, "b", "bbb", false)
+	saved, err := os.ReadFile(c.indexPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lastUsed := func(c *Cache) map[string]time.Time {
+		out := make(map[string]time.Time)
+		for _, e := range c.Entries() {
+			out[e.URLs[0]] = e.LastUsed
+		}
+		return out
+	}
+	before := lastUsed(c)
+
+	// Hits and a file deleted behind the cache's back only change the index in memory.
+	if _, ok := c.Lookup("a"); !ok {
+		t.Fatal("Lookup(a) missed")
+	}
+	b, _ := c.Lookup("b")
+	if err := os.Remove(b); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Lookup("b"); ok {
+		t.Fatal("Lookup(b) found a deleted file")
+	}
+	if data, err := os.ReadFile(c.indexPath()); err != nil || !bytes.Equal(data, saved) {
+		t.Fatalf("Lookup rewrote the index: %v\n%s", err, data)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := Open(dir, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after := lastUsed(reopened)
+	if len(after) != 1 || !after["a"].After(before["a"]) {
+		t.Errorf("after Close the index has %v, want a alone, used after %v", after, before["a"])
+	}
+
+	// With nothing changed Close writes nothing, and with a change it reports a failed save.
+	if err := os.Remove(reopened.indexPath()); err != nil {
+		t.Fatal(err)
+	}
+	if err := reopened.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(reopened.indexPath()); !os.IsNotExist(err) {
+		t.Errorf("Close without changes wrote the index: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(reopened.indexPath(), "blocked"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	reopened.Lookup("a")
+	if err := reopened.Close(); err == nil {
+		t.Error("Close succeeded without saving the index")
+	}
+}
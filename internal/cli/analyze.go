@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"gowav/internal/audio"
+)
+
+// analysis is the JSON form of analyze's results. Fields for measurements that were
+// not asked for are left out.
+type analysis struct {
+	Path     string        `json:"path"`
+	Duration float64       `json:"duration"`
+	BPM      *float64      `json:"bpm,omitempty"`
+	Beats    *int          `json:"beats,omitempty"`
+	Loudness *loudnessInfo `json:"loudness,omitempty"`
+}
+
+type loudnessInfo struct {
+	Peak float64 `json:"peak_dbfs"`
+	RMS  float64 `json:"rms_dbfs"`
+}
+
+// analyze decodes a file and runs the same tempo and level analysis as the player.
+func (c *cli) analyze(args []string) error {
+	fs := c.flags()
+	bpm := fs.Bool("bpm", false, "estimate the tempo")
+	loudness := fs.Bool("loudness", false, "measure the peak and RMS level")
+	asJSON := fs.Bool("json", false, "print the results as JSON")
+	path, err := c.parseFile(fs, args)
+	if err != nil {
+		return err
+	}
+	if !*bpm && !*loudness {
+		*bpm, *loudness = true, true
+	}
+
+	cfg := c.loadConfig()
+	data, meta, err := readFile(path)
+	if err != nil {
+		return err
+	}
+
+	// Ctrl+C stops the analysis of a long file.
+	ctx, stop := notifyInterrupt()
+	defer stop()
+
+	model := audio.NewModel(meta.SampleRate)
+	model.SetParameters(cfg.Analysis.WindowSize, cfg.Analysis.HopSize, cfg.Analysis.FFTSize)
+	if err := model.AnalyzeWaveform(ctx, data, nil); err != nil {
+		if errors.Is(err, context.Canceled) {
+			return errInterrupted
+		}
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	result := analysis{Path: path, Duration: meta.Duration.Seconds()}
+	if *bpm {
+		if err := model.AnalyzeBeats(ctx, nil); err != nil {
+			if errors.Is(err, context.Canceled) {
+				return errInterrupted
+			}
+			return fmt.Errorf("beat detection failed: %w", err)
+		}
+		tempo, beats := model.EstimatedTempo, len(model.GetBeatTimes())
+		result.BPM, result.Beats = &tempo, &beats
+	}
+	if *loudness {
+		l, err := model.AnalyzeLoudness()
+		if err != nil {
+			return fmt.Errorf("loudness analysis failed: %w", err)
+		}
+		result.Loudness = &loudnessInfo{Peak: roundLevel(l.Peak), RMS: roundLevel(l.RMS)}
+	}
+
+	if *asJSON {
+		return c.writeJSON(result)
+	}
+	var sb strings.Builder
+	if result.BPM != nil {
+		sb.WriteString(fmt.Sprintf("%-9s %.1f (%d beats)\n", "BPM:", *result.BPM, *result.Beats))
+	}
+	if l := result.Loudness; l != nil {
+		sb.WriteString(fmt.Sprintf("%-9s %.1f dBFS\n", "Peak:", l.Peak))
+		sb.WriteString(fmt.Sprintf("%-9s %.1f dBFS\n", "RMS:", l.RMS))
+	}
+	_, err = fmt.Fprint(c.stdout, sb.String())
+	return err
+}
+
+// roundLevel rounds a level to a hundredth of a decibel, finer than anyone can hear.
+func roundLevel(db float64) float64 {
+	return math.Round(db*100) / 100
+}
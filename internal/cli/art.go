@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// art saves a file's embedded cover art. The image is written as stored unless the
+// output name asks for the other format, in which case it is converted.
+func (c *cli) art(args []string) error {
+	fs := c.flags()
+	out := fs.String("o", "", "output file, or - for stdout (default cover.jpg or cover.png)")
+	path, err := c.parseFile(fs, args)
+	if err != nil {
+		return err
+	}
+
+	_, meta, err := readFile(path)
+	if err != nil {
+		return err
+	}
+	if !meta.HasArtwork {
+		return fmt.Errorf("%s has no embedded artwork", path)
+	}
+
+	// Go by the data rather than the tagged MIME type, which is not always right.
+	isPNG := bytes.HasPrefix(meta.ArtworkData, []byte("\x89PNG"))
+	isJPEG := bytes.HasPrefix(meta.ArtworkData, []byte{0xff, 0xd8, 0xff})
+	if *out == "" {
+		*out = "cover.jpg"
+		if isPNG {
+			*out = "cover.png"
+		}
+	}
+
+	data := meta.ArtworkData
+	var buf bytes.Buffer
+	switch strings.ToLower(filepath.Ext(*out)) {
+	case ".png":
+		if !isPNG {
+			if err := png.Encode(&buf, meta.Artwork); err != nil {
+				return fmt.Errorf("failed to convert artwork: %w", err)
+			}
+			data = buf.Bytes()
+		}
+	case ".jpg", ".jpeg":
+		if !isJPEG {
+			if err := jpeg.Encode(&buf, meta.Artwork, &jpeg.Options{Quality: 90}); err != nil {
+				return fmt.Errorf("failed to convert artwork: %w", err)
+			}
+			data = buf.Bytes()
+		}
+	}
+
+	if *out == "-" {
+		_, err := c.stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*out, data, 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "Saved %dx%d artwork to %s\n", meta.ArtworkSize.X, meta.ArtworkSize.Y, *out)
+	return nil
+}
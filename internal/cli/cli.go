@@ -0,0 +1,204 @@
+// Package cli implements gowav's command line: headless subcommands that work on a file,
+// and script mode, which runs player commands without the interface.
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"gowav/internal/audio"
+	"gowav/internal/config"
+)
+
+// Exit codes returned by Run.
+const (
+	ExitOK    = 0
+	ExitError = 1
+	// ExitUsage reports a bad command line.
+	ExitUsage = 2
+	// ExitInterrupted follows the shell convention for a process stopped by SIGINT.
+	ExitInterrupted = 130
+)
+
+// errInterrupted is returned by a subcommand stopped by Ctrl+C.
+var errInterrupted = errors.New("interrupted")
+
+// notifyInterrupt returns a context cancelled by Ctrl+C or SIGTERM, and a function that
+// stops watching for them.
+var notifyInterrupt = func() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// usageError is a problem with the command line rather than with the work itself.
+// An empty msg means the problem has already been reported.
+type usageError struct{ msg string }
+
+func (e usageError) Error() string { return e.msg }
+
+type command struct {
+	name string
+	// usage is the command line after "gowav".
+	usage   string
+	summary string
+	run     func(c *cli, args []string) error
+}
+
+var subcommands = []command{
+	{"info", "info <file> [--json]", "Show the tags and stream properties of a file", (*cli).info},
+	{"analyze", "analyze <file> [--bpm] [--loudness] [--json]", "Estimate the tempo and measure the level of a file", (*cli).analyze},
+	{"art", "art <file> [-o cover.jpg]", "Save the embedded cover art", (*cli).art},
+	{"play", "play <file> --no-tui [--volume n]", "Play a file in the terminal without the interface", (*cli).play},
+}
+
+func findCommand(name string) (command, bool) {
+	for _, cmd := range subcommands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+type cli struct {
+	stdout io.Writer
+	stderr io.Writer
+	// cmd is the subcommand being run.
+	cmd command
+}
+
+// Run executes the subcommand named by args[0] and returns the process exit code.
+func Run(args []string, stdout, stderr io.Writer) int {
+	c := &cli{stdout: stdout, stderr: stderr}
+	if len(args) == 0 {
+		c.usage()
+		return ExitUsage
+	}
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		c.usage()
+		return ExitOK
+	}
+	cmd, ok := findCommand(args[0])
+	rest := args[1:]
+	if strings.HasPrefix(args[0], "-") {
+		// -c and --script are flags of the player itself rather than a subcommand.
+		cmd, ok, rest = scriptCommand, true, args
+	}
+	if !ok {
+		fmt.Fprintf(stderr, "gowav: unknown command %q\n\n", args[0])
+		c.usage()
+		return ExitUsage
+	}
+
+	c.cmd = cmd
+	err := cmd.run(c, rest)
+	var usageErr usageError
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, flag.ErrHelp):
+		return ExitOK
+	case errors.Is(err, errInterrupted):
+		return ExitInterrupted
+	case errors.As(err, &usageErr):
+		if usageErr.msg == "" {
+			return ExitUsage
+		}
+		fmt.Fprintf(stderr, "gowav %s: %v\nusage: gowav %s\n", cmd.name, err, cmd.usage)
+		return ExitUsage
+	default:
+		fmt.Fprintf(stderr, "gowav %s: %v\n", cmd.name, err)
+		return ExitError
+	}
+}
+
+func (c *cli) usage() {
+	var sb strings.Builder
+	sb.WriteString("usage: gowav [command]\n\n")
+	sb.WriteString("Without a command, gowav starts the interactive player.\n\nCommands:\n")
+	for _, cmd := range subcommands {
+		sb.WriteString(fmt.Sprintf("  %-9s %s\n", cmd.name, cmd.summary))
+		sb.WriteString(fmt.Sprintf("  %-9s   gowav %s\n", "", cmd.usage))
+	}
+	sb.WriteString(fmt.Sprintf("\n%s:\n  gowav %s\n", scriptCommand.summary, scriptCommand.usage))
+	sb.WriteString("\nRun 'gowav <command> -h' for the options of a command.\n")
+	fmt.Fprint(c.stderr, sb.String())
+}
+
+// flags returns a flag set for the current subcommand whose errors go to stderr.
+func (c *cli) flags() *flag.FlagSet {
+	fs := flag.NewFlagSet(c.cmd.name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(c.stderr, "usage: gowav %s\n", c.cmd.usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFile parses args, which may mix flags with the file name, and returns the file name.
+func (c *cli) parseFile(fs *flag.FlagSet, args []string) (string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return "", err
+			}
+			// The flag package has already printed the problem and the usage.
+			return "", usageError{}
+		}
+		if args = fs.Args(); len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+
+	switch len(positional) {
+	case 0:
+		return "", usageError{msg: "no file given"}
+	case 1:
+		return positional[0], nil
+	default:
+		return "", usageError{msg: fmt.Sprintf("expected one file, got %d", len(positional))}
+	}
+}
+
+// readFile reads an audio file and its tags.
+func readFile(path string) ([]byte, *audio.Metadata, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	meta, err := audio.ExtractMetadata(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return data, meta, nil
+}
+
+// loadConfig reads the config file for the analysis and logging settings. A broken file is
+// reported and the defaults are used, as in the interactive player.
+func (c *cli) loadConfig() *config.Config {
+	cfg, err := config.LoadDefault()
+	if err != nil {
+		fmt.Fprintf(c.stderr, "warning: %v; using default settings\n", err)
+		cfg = config.Default()
+	}
+	audio.SetLogDir(cfg.Log.Dir)
+	return cfg
+}
+
+func (c *cli) writeJSON(v interface{}) error {
+	enc := json.NewEncoder(c.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
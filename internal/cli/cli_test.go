@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// wavFile returns a mono 16-bit 8 kHz WAV file of a second of silence.
+func wavFile() []byte {
+	data := make([]byte, 16000)
+	b := []byte("RIFF")
+	b = binary.LittleEndian.AppendUint32(b, uint32(36+len(data)))
+	b = append(b, "WAVEfmt "...)
+	b = binary.LittleEndian.AppendUint32(b, 16)
+	b = binary.LittleEndian.AppendUint16(b, 1)
+	b = binary.LittleEndian.AppendUint16(b, 1)
+	b = binary.LittleEndian.AppendUint32(b, 8000)
+	b = binary.LittleEndian.AppendUint32(b, 16000)
+	b = binary.LittleEndian.AppendUint16(b, 2)
+	b = binary.LittleEndian.AppendUint16(b, 16)
+	b = append(b, "data"...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(data)))
+	return append(b, data...)
+}
+
+// testDir returns a directory holding track.wav and notes.txt, and points the home
+// directory at it so the user's settings stay out of the test.
+func testDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, ".config"))
+	files := map[string][]byte{"track.wav": wavFile(), "notes.txt": []byte("not audio")}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// run calls Run and returns the exit code and what was written to stdout and stderr.
+func run(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := Run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+// interruptNow makes every interrupt watch start out interrupted until the test ends.
+func interruptNow(t *testing.T) {
+	orig := notifyInterrupt
+	notifyInterrupt = func() (context.Context, context.CancelFunc) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		return ctx, cancel
+	}
+	t.Cleanup(func() { notifyInterrupt = orig })
+}
+
+func TestRunExitCodes(t *testing.T) {
+	dir := testDir(t)
+	track, notes := filepath.Join(dir, "track.wav"), filepath.Join(dir, "notes.txt")
+
+	tests := []struct {
+		name       string
+		args       []string
+		interrupt  bool
+		want       int
+		wantStderr string
+	}{
+		{"no arguments", nil, false, ExitUsage, "usage: gowav [command]"},
+		{"help", []string{"help"}, false, ExitOK, "usage: gowav [command]"},
+		{"unknown command", []string{"frobnicate"}, false, ExitUsage, `unknown command "frobnicate"`},
+		{"command help", []string{"info", "-h"}, false, ExitOK, "usage: gowav info <file> [--json]"},
+		{"bad flag", []string{"info", "--yaml", track}, false, ExitUsage, "flag provided but not defined: -yaml"},
+		{"bad flag value", []string{"play", track, "--volume", "loud"}, false, ExitUsage, `invalid value "loud"`},
+		{"no file", []string{"analyze", "--bpm"}, false, ExitUsage, "gowav analyze: no file given\nusage: gowav analyze"},
+		{"two files", []string{"info", track, track}, false, ExitUsage, "expected one file, got 2"},
+		{"volume out of range", []string{"play", track, "--volume", "500"}, false, ExitUsage, "volume must be between 0 and"},
+		{"missing file", []string{"info", filepath.Join(dir, "missing.wav")}, false, ExitError, "no such file"},
+		{"not audio", []string{"analyze", notes}, false, ExitError, "gowav analyze: " + notes},
+		{"no artwork", []string{"art", track}, false, ExitError, "has no embedded artwork"},
+		{"interrupted", []string{"analyze", track, "--bpm"}, true, ExitInterrupted, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.interrupt {
+				interruptNow(t)
+			}
+			code, _, stderr := run(tt.args...)
+			if code != tt.want {
+				t.Errorf("Run(%q) = %d, want %d; stderr:\n%s", tt.args, code, tt.want, stderr)
+			}
+			if !strings.Contains(stderr, tt.wantStderr) {
+				t.Errorf("stderr is\n%s\nwant it to contain %q", stderr, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestInfoJSON(t *testing.T) {
+	track := filepath.Join(testDir(t), "track.wav")
+	// Flags may come after the file.
+	code, stdout, stderr := run("info", track, "--json")
+	if code != ExitOK {
+		t.Fatalf("exit code %d; stderr:\n%s", code, stderr)
+	}
+	var got trackInfo
+	if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+		t.Fatalf("output isn't JSON: %v\n%s", err, stdout)
+	}
+	if got.Path != track || got.SampleRate != 8000 || got.Channels != 1 || got.Duration != 1 {
+		t.Errorf("info = %+v, want 1s of 8 kHz mono from %s", got, track)
+	}
+}
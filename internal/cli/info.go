@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gowav/internal/audio"
+)
+
+// trackInfo is the JSON form of a file's metadata.
+type trackInfo struct {
+	Path        string       `json:"path"`
+	Title       string       `json:"title,omitempty"`
+	Artist      string       `json:"artist,omitempty"`
+	Album       string       `json:"album,omitempty"`
+	AlbumArtist string       `json:"album_artist,omitempty"`
+	Year        int          `json:"year,omitempty"`
+	Genre       string       `json:"genre,omitempty"`
+	Track       string       `json:"track,omitempty"`
+	Disc        string       `json:"disc,omitempty"`
+	Comment     string       `json:"comment,omitempty"`
+	BPM         string       `json:"bpm,omitempty"`
+	Format      string       `json:"format"`
+	Duration    float64      `json:"duration"`
+	BitRate     int          `json:"bit_rate,omitempty"`
+	SampleRate  int          `json:"sample_rate"`
+	Channels    int          `json:"channels"`
+	FileSize    int64        `json:"file_size"`
+	Artwork     *artworkInfo `json:"artwork,omitempty"`
+}
+
+type artworkInfo struct {
+	MIME   string `json:"mime"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Size   int    `json:"size"`
+}
+
+func newTrackInfo(path string, m *audio.Metadata) trackInfo {
+	info := trackInfo{
+		Path:        path,
+		Title:       m.Title,
+		Artist:      m.Artist,
+		Album:       m.Album,
+		AlbumArtist: m.AlbumArtist,
+		Year:        m.Year,
+		Genre:       m.Genre,
+		Track:       m.Track,
+		Disc:        m.Disc,
+		Comment:     m.Comment,
+		BPM:         m.BPM,
+		Format:      m.Format,
+		Duration:    m.Duration.Seconds(),
+		BitRate:     m.BitRate,
+		SampleRate:  m.SampleRate,
+		Channels:    m.Channels,
+		FileSize:    m.FileSize,
+	}
+	if m.HasArtwork {
+		info.Artwork = &artworkInfo{
+			MIME:   m.ArtworkMIME,
+			Width:  m.ArtworkSize.X,
+			Height: m.ArtworkSize.Y,
+			Size:   len(m.ArtworkData),
+		}
+	}
+	return info
+}
+
+// info prints the tags and stream properties of a file.
+func (c *cli) info(args []string) error {
+	fs := c.flags()
+	asJSON := fs.Bool("json", false, "print the metadata as JSON")
+	path, err := c.parseFile(fs, args)
+	if err != nil {
+		return err
+	}
+
+	_, meta, err := readFile(path)
+	if err != nil {
+		return err
+	}
+	info := newTrackInfo(path, meta)
+	if *asJSON {
+		return c.writeJSON(info)
+	}
+
+	var sb strings.Builder
+	field := func(label, value string) {
+		if value != "" && value != "0" {
+			sb.WriteString(fmt.Sprintf("%-12s %s\n", label+":", value))
+		}
+	}
+	field("File", info.Path)
+	field("Title", info.Title)
+	field("Artist", info.Artist)
+	field("Album", info.Album)
+	field("Album artist", info.AlbumArtist)
+	field("Year", fmt.Sprint(info.Year))
+	field("Genre", info.Genre)
+	field("Track", info.Track)
+	field("Disc", info.Disc)
+	field("BPM", info.BPM)
+	field("Format", info.Format)
+	field("Duration", formatDuration(meta.Duration))
+	if info.BitRate > 0 {
+		field("Bit rate", fmt.Sprintf("%d kb/s", info.BitRate))
+	}
+	field("Sample rate", fmt.Sprintf("%d Hz", info.SampleRate))
+	field("Channels", fmt.Sprint(info.Channels))
+	field("File size", fmt.Sprintf("%d bytes", info.FileSize))
+	if a := info.Artwork; a != nil {
+		field("Artwork", fmt.Sprintf("%s, %dx%d, %d bytes", a.MIME, a.Width, a.Height, a.Size))
+	}
+	_, err = fmt.Fprint(c.stdout, sb.String())
+	return err
+}
+
+// formatDuration formats d as M:SS, or H:MM:SS for an hour or more.
+func formatDuration(d time.Duration) string {
+	s := int(d.Round(time.Second).Seconds())
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+	}
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"fmt"
+
+	"gowav/internal/audio"
+)
+
+// play plays a file to the end without the interface. Ctrl+C stops it.
+func (c *cli) play(args []string) error {
+	fs := c.flags()
+	// The subcommand never opens the interface; the flag makes that explicit in scripts.
+	fs.Bool("no-tui", false, "play without the interactive interface (always the case for this command)")
+	volume := fs.Int("volume", 100, fmt.Sprintf("volume in percent, 0-%d", audio.MaxVolume))
+	path, err := c.parseFile(fs, args)
+	if err != nil {
+		return err
+	}
+	if *volume < 0 || *volume > audio.MaxVolume {
+		return usageError{msg: fmt.Sprintf("volume must be between 0 and %d", audio.MaxVolume)}
+	}
+
+	c.loadConfig()
+	data, meta, err := readFile(path)
+	if err != nil {
+		return err
+	}
+	src, err := audio.NewPCMSource(data)
+	if err != nil {
+		return fmt.Errorf("failed to decode: %w", err)
+	}
+
+	player := audio.NewPlayer()
+	player.SetVolume(*volume)
+	player.SetDuration(meta.Duration)
+
+	ctx, stop := notifyInterrupt()
+	defer stop()
+
+	if err := player.Play(src); err != nil {
+		return fmt.Errorf("failed to play: %w", err)
+	}
+	fmt.Fprintf(c.stdout, "Playing %s (%s)\n", trackLabel(path, meta), formatDuration(meta.Duration))
+
+	select {
+	case <-player.Ended():
+		return nil
+	case <-ctx.Done():
+		player.Stop()
+		return errInterrupted
+	}
+}
+
+// trackLabel names a track by its tags, falling back to the file name.
+func trackLabel(path string, m *audio.Metadata) string {
+	switch {
+	case m.Title != "" && m.Artist != "":
+		return m.Artist + " - " + m.Title
+	case m.Title != "":
+		return m.Title
+	default:
+		return path
+	}
+}
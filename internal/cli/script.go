@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"gowav/internal/commands"
+)
+
+// scriptCommand runs player commands, as typed at the prompt, without the interface.
+var scriptCommand = command{
+	"script", `-c "cmd; cmd ..." | --script file.gowav`, "Run player commands without the interface", (*cli).script,
+}
+
+// step is one command of a script, with where it came from for error messages.
+type step struct {
+	where string
+	text  string
+}
+
+// splitScript splits text into commands separated by newlines or semicolons. Blank lines
+// and lines starting with # are skipped. name labels file lines; without it commands are numbered.
+func splitScript(text, name string) []step {
+	var steps []step
+	for i, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line == "" || line[0] == '#' {
+			continue
+		}
+		for _, cmd := range strings.Split(line, ";") {
+			if cmd = strings.TrimSpace(cmd); cmd == "" {
+				continue
+			}
+			where := fmt.Sprintf("%s:%d", name, i+1)
+			if name == "" {
+				where = fmt.Sprintf("command %d", len(steps)+1)
+			}
+			steps = append(steps, step{where: where, text: cmd})
+		}
+	}
+	return steps
+}
+
+// script runs the commands given with -c or in a --script file in order, each after the
+// loading and analysis started by the one before has finished. It stops at the first error,
+// and at the end waits for any playback it started.
+func (c *cli) script(args []string) error {
+	fs := c.flags()
+	inline := fs.String("c", "", "commands to run, separated by semicolons")
+	file := fs.String("script", "", "file of commands to run, one per line")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return usageError{}
+	}
+	if fs.NArg() > 0 {
+		return usageError{msg: fmt.Sprintf("unexpected argument %q", fs.Arg(0))}
+	}
+
+	var steps []step
+	switch {
+	case *inline != "" && *file != "":
+		return usageError{msg: "use either -c or --script, not both"}
+	case *inline != "":
+		steps = splitScript(*inline, "")
+	case *file != "":
+		data, err := os.ReadFile(*file)
+		if err != nil {
+			return err
+		}
+		steps = splitScript(string(data), *file)
+	default:
+		return usageError{msg: "no commands given"}
+	}
+
+	ctx, stop := notifyInterrupt()
+	defer stop()
+
+	s := commands.NewScript(c.stdout, ctx.Done())
+	defer func() {
+		if err := s.Close(); err != nil {
+			fmt.Fprintf(c.stderr, "warning: %v\n", err)
+		}
+	}()
+	for _, st := range steps {
+		// A step that doesn't wait for anything wouldn't notice Ctrl+C on its own.
+		if ctx.Err() != nil {
+			return errInterrupted
+		}
+		fmt.Fprintf(c.stdout, "> %s\n", st.text)
+		quit, err := s.Run(st.text)
+		if errors.Is(err, commands.ErrScriptStopped) {
+			return errInterrupted
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %s: %w", st.where, st.text, err)
+		}
+		if quit {
+			return nil
+		}
+	}
+	if err := s.Finish(); err != nil {
+		if errors.Is(err, commands.ErrScriptStopped) {
+			return errInterrupted
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitScript(t *testing.T) {
+	tests := []struct {
+		name, text, file string
+		want             []step
+	}{
+		{
+			name: "semicolons",
+			text: "load a.mp3; viz spectrum ;export out.txt;",
+			want: []step{{"command 1", "load a.mp3"}, {"command 2", "viz spectrum"}, {"command 3", "export out.txt"}},
+		},
+		{
+			name: "empty commands",
+			text: " ; ;;play",
+			want: []step{{"command 1", "play"}},
+		},
+		{
+			name: "lines and semicolons",
+			text: "load a.mp3\nplay; seek 1:00\n",
+			want: []step{{"command 1", "load a.mp3"}, {"command 2", "play"}, {"command 3", "seek 1:00"}},
+		},
+		{
+			name: "file lines",
+			file: "demo.gowav",
+			text: "# demo\n\nload a.mp3\n  # indented comment\nplay; seek 1:00\n",
+			want: []step{{"demo.gowav:3", "load a.mp3"}, {"demo.gowav:5", "play"}, {"demo.gowav:5", "seek 1:00"}},
+		},
+		{
+			// Only whole lines are comments; a # later on belongs to the command.
+			name: "hash inside a line",
+			file: "demo.gowav",
+			text: "load track#1.mp3\r\n#play",
+			want: []step{{"demo.gowav:1", "load track#1.mp3"}},
+		},
+		{
+			name: "nothing to run",
+			text: "\n# only a comment\n ; \n",
+		},
+	}
+	for _, tt := range tests {
+		if got := splitScript(tt.text, tt.file); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: splitScript(%q, %q) = %+v, want %+v", tt.name, tt.text, tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestRunScript(t *testing.T) {
+	dir := testDir(t)
+	track := filepath.Join(dir, "track.wav")
+	script, spectrum, never := filepath.Join(dir, "demo.gowav"), filepath.Join(dir, "spectrum.txt"), filepath.Join(dir, "never.txt")
+	if err := os.WriteFile(script, []byte("# demo\nload "+track+"\n\nseek 9:00\nhelp\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		interrupt  bool
+		want       int
+		wantStdout []string
+		skipped    string
+		wantStderr string
+		exported   string
+	}{
+		{
+			name: "waits for analysis before the next step",
+			args: []string{"-c", "load " + track + "; viz spectrum; export " + spectrum},
+			want: ExitOK, wantStdout: []string{"> load", "> viz spectrum", "> export " + spectrum},
+			exported: spectrum,
+		},
+		{
+			name: "stops at the first failed step",
+			args: []string{"-c", "load " + filepath.Join(dir, "missing.wav") + "; export " + never},
+			want: ExitError, skipped: "> export", wantStderr: "gowav script: command 1: load ",
+		},
+		{
+			name: "script file",
+			args: []string{"--script", script},
+			want: ExitError, wantStdout: []string{"> load", "> seek 9:00"}, skipped: "> help",
+			wantStderr: "gowav script: " + script + ":4: seek 9:00: ",
+		},
+		{
+			name: "quit ends the script",
+			args: []string{"-c", "help; quit; load " + filepath.Join(dir, "missing.wav")},
+			want: ExitOK, wantStdout: []string{"> help", "> quit"}, skipped: "> load",
+		},
+		{
+			name: "interrupted",
+			args: []string{"-c", "help"}, interrupt: true,
+			want: ExitInterrupted, skipped: "> help",
+		},
+		{name: "missing script", args: []string{"--script", filepath.Join(dir, "missing.gowav")}, want: ExitError, wantStderr: "no such file"},
+		{name: "both modes", args: []string{"-c", "help", "--script", script}, want: ExitUsage, wantStderr: "use either -c or --script"},
+		{name: "no commands", args: []string{"-c", ""}, want: ExitUsage, wantStderr: "no commands given"},
+		{name: "extra argument", args: []string{"-c", "help", "extra"}, want: ExitUsage, wantStderr: `unexpected argument "extra"`},
+		{name: "bad flag", args: []string{"--scirpt", script}, want: ExitUsage, wantStderr: "flag provided but not defined"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.interrupt {
+				interruptNow(t)
+			}
+			code, stdout, stderr := run(tt.args...)
+			if code != tt.want {
+				t.Errorf("exit code %d, want %d; stderr:\n%s", code, tt.want, stderr)
+			}
+			for _, want := range tt.wantStdout {
+				if !strings.Contains(stdout, want) {
+					t.Errorf("stdout is\n%s\nwant it to contain %q", stdout, want)
+				}
+			}
+			if tt.skipped != "" && strings.Contains(stdout, tt.skipped) {
+				t.Errorf("stdout is\n%s\nwant no %q", stdout, tt.skipped)
+			}
+			if !strings.Contains(stderr, tt.wantStderr) {
+				t.Errorf("stderr is\n%s\nwant it to contain %q", stderr, tt.wantStderr)
+			}
+			if tt.exported != "" {
+				if data, err := os.ReadFile(tt.exported); err != nil || len(data) == 0 {
+					t.Errorf("exported %q, %v", data, err)
+				}
+			}
+			if _, err := os.Stat(never); err == nil {
+				t.Error("a step after the failure ran")
+			}
+		})
+	}
+}
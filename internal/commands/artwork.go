@@ -5,6 +5,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/x/term"
+	"image"
 	"strings"
 )
 
@@ -34,12 +35,34 @@ func (c *Commander) handleArtwork() (string, error, tea.Cmd) {
 		height = 24
 	}
 
-	bounds := metadata.Artwork.Bounds()
+	art := renderArtwork(metadata.Artwork, width-4, height-8)
+
+	style := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("240")).
+		Padding(0)
+
+	output := lipgloss.JoinVertical(
+		lipgloss.Left,
+		header,
+		style.Render(art),
+	)
+
+	return output, nil, nil
+}
+
+// renderArtwork draws img as coloured block characters, scaled to fit within maxWidth x maxHeight
+// cells while keeping its aspect ratio (a cell is about twice as tall as it is wide).
+func renderArtwork(img image.Image, maxWidth, maxHeight int) string {
+	bounds := img.Bounds()
 	origWidth := bounds.Dx()
 	origHeight := bounds.Dy()
+	if origWidth == 0 || origHeight == 0 {
+		return ""
+	}
 
-	targetWidth := width - 4
-	targetHeight := height - 8
+	targetWidth := maxWidth
+	targetHeight := maxHeight
 	aspect := float64(origWidth) / float64(origHeight) * 2
 
 	if float64(targetWidth)/float64(targetHeight) > aspect {
@@ -58,9 +81,9 @@ func (c *Commander) handleArtwork() (string, error, tea.Cmd) {
 	var sb strings.Builder
 	for y := 0; y < targetHeight; y++ {
 		for x := 0; x < targetWidth; x++ {
-			imgX := int(float64(x) * float64(origWidth) / float64(targetWidth))
-			imgY := int(float64(y) * float64(origHeight) / float64(targetHeight))
-			r, g, b, _ := metadata.Artwork.At(imgX, imgY).RGBA()
+			imgX := bounds.Min.X + int(float64(x)*float64(origWidth)/float64(targetWidth))
+			imgY := bounds.Min.Y + int(float64(y)*float64(origHeight)/float64(targetHeight))
+			r, g, b, _ := img.At(imgX, imgY).RGBA()
 			r >>= 8
 			g >>= 8
 			b >>= 8
@@ -71,17 +94,5 @@ func (c *Commander) handleArtwork() (string, error, tea.Cmd) {
 		}
 		sb.WriteString("\n")
 	}
-
-	style := lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("240")).
-		Padding(0)
-
-	output := lipgloss.JoinVertical(
-		lipgloss.Left,
-		header,
-		style.Render(sb.String()),
-	)
-
-	return output, nil, nil
+	return sb.String()
 }
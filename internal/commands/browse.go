@@ -0,0 +1,198 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"gowav/pkg/api"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// browseTimeout bounds fetching an album or author page, artwork included.
+const browseTimeout = 20 * time.Second
+
+// Artwork shown beside album and author pages is kept small so the track list stays on screen.
+const (
+	browseArtWidth  = 32
+	browseArtHeight = 14
+)
+
+var browseHeaderStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("87"))
+
+// handleAlbum shows a remote album and its tracks: "album <slug|n> [enqueue]", where n picks
+// the album of search result n. The tracks become the current results for play/enqueue.
+func (c *Commander) handleAlbum(args []string) (string, error, tea.Cmd) {
+	slugArg, enqueue, err := parseBrowseArgs(args, "album")
+	if err != nil {
+		return "", err, nil
+	}
+	slug, err := c.resolveSlug(slugArg, "album", func(r SearchResult) string { return r.AlbumSlug })
+	if err != nil {
+		return "", err, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), browseTimeout)
+	defer cancel()
+	album, err := c.apiClient.GetAlbum(ctx, slug)
+	if err != nil {
+		if api.IsNotFound(err) {
+			return "", fmt.Errorf("no album %q", slug), nil
+		}
+		return "", fmt.Errorf("failed to fetch album: %w", err), nil
+	}
+
+	var sb strings.Builder
+	header := album.Name
+	if names := authorNames(album.Authors); names != "" {
+		header += " — " + names
+	}
+	sb.WriteString(browseHeaderStyle.Render(header) + "\n")
+	sb.WriteString(c.remoteArtwork(ctx, album.ImageCropped))
+
+	c.setBrowseResults(album.Songs)
+	sb.WriteString(fmt.Sprintf("\nTracks (%d):\n", len(album.Songs)))
+	sb.WriteString(c.formatBrowseTracks(false))
+	sb.WriteString(c.enqueueBrowseResults(enqueue))
+	return sb.String(), nil, nil
+}
+
+// handleArtist shows a remote author with their albums and songs: "artist <slug|n> [enqueue]".
+func (c *Commander) handleArtist(args []string) (string, error, tea.Cmd) {
+	slugArg, enqueue, err := parseBrowseArgs(args, "artist")
+	if err != nil {
+		return "", err, nil
+	}
+	slug, err := c.resolveSlug(slugArg, "artist", func(r SearchResult) string { return r.ArtistSlug })
+	if err != nil {
+		return "", err, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), browseTimeout)
+	defer cancel()
+	author, err := c.apiClient.GetAuthor(ctx, slug)
+	if err != nil {
+		if api.IsNotFound(err) {
+			return "", fmt.Errorf("no artist %q", slug), nil
+		}
+		return "", fmt.Errorf("failed to fetch artist: %w", err), nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(browseHeaderStyle.Render(author.Name) + "\n")
+	sb.WriteString(c.remoteArtwork(ctx, author.ImageCropped))
+
+	if len(author.Albums) > 0 {
+		sb.WriteString(fmt.Sprintf("\nAlbums (%d):\n", len(author.Albums)))
+		for _, a := range author.Albums {
+			sb.WriteString(fmt.Sprintf("  %s  (album %s)\n", a.Name, a.Slug))
+		}
+	}
+
+	c.setBrowseResults(author.Songs)
+	sb.WriteString(fmt.Sprintf("\nSongs (%d):\n", len(author.Songs)))
+	sb.WriteString(c.formatBrowseTracks(true))
+	sb.WriteString(c.enqueueBrowseResults(enqueue))
+	return sb.String(), nil, nil
+}
+
+// parseBrowseArgs splits "<slug> [enqueue]".
+func parseBrowseArgs(args []string, cmd string) (string, bool, error) {
+	enqueue := false
+	if n := len(args); n > 1 && strings.EqualFold(args[n-1], "enqueue") {
+		enqueue = true
+		args = args[:n-1]
+	}
+	if len(args) != 1 {
+		return "", false, fmt.Errorf("usage: %s <slug|n> [enqueue]", cmd)
+	}
+	return args[0], enqueue, nil
+}
+
+// resolveSlug returns arg itself, or for a number the slug that pick takes from that search result.
+func (c *Commander) resolveSlug(arg, kind string, pick func(SearchResult) string) (string, error) {
+	if _, err := strconv.Atoi(arg); err != nil || len(c.searchResults) == 0 {
+		return arg, nil
+	}
+	r, err := c.result(arg)
+	if err != nil {
+		return "", err
+	}
+	slug := pick(r)
+	if slug == "" {
+		return "", fmt.Errorf("result %s has no %s page", arg, kind)
+	}
+	return slug, nil
+}
+
+// setBrowseResults makes songs the current result list, so play <n> and enqueue act on them.
+func (c *Commander) setBrowseResults(songs []api.Song) {
+	c.searchResults = make([]SearchResult, len(songs))
+	for i, s := range songs {
+		c.searchResults[i] = songResult(s)
+	}
+	c.searchNotice = ""
+	c.searchPage = nil
+}
+
+func (c *Commander) formatBrowseTracks(withAlbum bool) string {
+	if len(c.searchResults) == 0 {
+		return "  (none)\n"
+	}
+	var sb strings.Builder
+	for i, r := range c.searchResults {
+		line := fmt.Sprintf("%3d. %s", i+1, r.Title)
+		if withAlbum && r.Album != "" {
+			line += " · " + r.Album
+		}
+		sb.WriteString(fmt.Sprintf("%s  %s\n", line, FormatDuration(time.Duration(r.Duration)*time.Second)))
+	}
+	return sb.String()
+}
+
+// enqueueBrowseResults queues every listed track if asked to, and says how to otherwise.
+func (c *Commander) enqueueBrowseResults(enqueue bool) string {
+	if len(c.searchResults) == 0 {
+		return ""
+	}
+	if !enqueue {
+		return "\nplay <n> to play a track, enqueue all to queue them all."
+	}
+	items := make([]QueueItem, len(c.searchResults))
+	for i, r := range c.searchResults {
+		items[i] = r.queueItem()
+	}
+	c.queue.Add(items...)
+	return fmt.Sprintf("\nQueued %d tracks (%d in queue)", len(items), c.queue.Len())
+}
+
+// remoteArtwork downloads and renders an ImageCropped link. Artwork is decoration, so
+// failures are noted in a single line rather than failing the command.
+func (c *Commander) remoteArtwork(ctx context.Context, link string) string {
+	if link == "" {
+		return ""
+	}
+	data, err := c.apiClient.FetchImage(ctx, link)
+	if err != nil {
+		return fmt.Sprintf("(artwork unavailable: %v)\n", err)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Sprintf("(artwork unavailable: %v)\n", err)
+	}
+	return renderArtwork(img, browseArtWidth, browseArtHeight)
+}
+
+func authorNames(authors []api.Author) string {
+	names := make([]string, len(authors))
+	for i, a := range authors {
+		names[i] = a.Name
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,164 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"gowav/internal/cache"
+	"net/http"
+	"strings"
+)
+
+// DownloadDoneMsg carries the outcome of a background `download`.
+type DownloadDoneMsg struct {
+	Summary string
+	Err     error
+}
+
+// handleDownload keeps a search result or URL in the cache for good, downloading it in the
+// background unless it is already cached.
+func (c *Commander) handleDownload(args []string) (string, error, tea.Cmd) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("usage: download <n|url>"), nil
+	}
+	store, err := c.openCache()
+	if err != nil {
+		return "", err, nil
+	}
+
+	// label names the track in messages; title, if known, is kept with it in the cache.
+	var url, label, title string
+	if c.isResultRef(args) {
+		r, err := c.result(args[0])
+		if err != nil {
+			return "", err, nil
+		}
+		if r.Source == SourceLocal {
+			return "", fmt.Errorf("%s is already a local file", r.Label()), nil
+		}
+		url, label, title = r.URL, r.Label(), r.Label()
+	} else {
+		url = strings.Trim(strings.Join(args, " "), `"'`)
+		if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+			return "", fmt.Errorf("download needs a search result number or an http(s) URL"), nil
+		}
+		label = url
+	}
+
+	if e, ok, err := store.Keep(url); err != nil {
+		return "", err, nil
+	} else if ok {
+		return fmt.Sprintf("Kept %s (already cached, %s)", label, formatSize(e.Size)), nil, nil
+	}
+
+	c.downloads.Add(1)
+	client := c.apiClient.DownloadClient()
+	return fmt.Sprintf("Downloading %s...", label), nil, func() tea.Msg {
+		defer c.downloads.Add(-1)
+		e, err := download(client, store, url, title)
+		if err != nil {
+			return DownloadDoneMsg{Err: fmt.Errorf("download of %s failed: %w", label, err)}
+		}
+		return DownloadDoneMsg{Summary: fmt.Sprintf("Downloaded %s (%s)", label, formatSize(e.Size))}
+	}
+}
+
+// download fetches url into the cache as a kept file.
+func download(client *http.Client, store *cache.Cache, url, title string) (cache.Entry, error) {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+	if err != nil {
+		return cache.Entry{}, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return cache.Entry{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return cache.Entry{}, fmt.Errorf("server returned %s", resp.Status)
+	}
+	return store.Put(url, title, resp.Body, true)
+}
+
+// handleCache lists the cached tracks or clears them.
+func (c *Commander) handleCache(args []string) (string, error, tea.Cmd) {
+	store, err := c.openCache()
+	if err != nil {
+		return "", err, nil
+	}
+	if len(args) == 0 || strings.EqualFold(args[0], "ls") || strings.EqualFold(args[0], "list") {
+		return listCache(store), nil, nil
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "clear":
+		all := len(args) > 1 && strings.EqualFold(args[1], "all")
+		n, freed, err := store.Clear(all)
+		if err != nil {
+			return "", fmt.Errorf("failed to clear the cache: %w", err), nil
+		}
+		if all {
+			return fmt.Sprintf("Removed %d tracks (%s)", n, formatSize(freed)), nil, nil
+		}
+		return fmt.Sprintf("Removed %d cached tracks (%s); downloads are kept (use 'cache clear all' to remove them too)",
+			n, formatSize(freed)), nil, nil
+	default:
+		return "", fmt.Errorf("usage: cache [ls | clear [all]]"), nil
+	}
+}
+
+func listCache(store *cache.Cache) string {
+	entries := store.Entries()
+	cached, kept := store.Usage()
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Cache in %s: %s of %s used, %s downloaded\n",
+		store.Dir(), formatSize(cached), formatSize(store.Limit()), formatSize(kept)))
+	if len(entries) == 0 {
+		sb.WriteString("\nNothing cached yet. Streamed tracks are cached once they finish downloading;\n")
+		sb.WriteString("'download <n|url>' keeps one for good.")
+		return sb.String()
+	}
+	sb.WriteString("\n")
+	for i, e := range entries {
+		name := e.Title
+		if name == "" {
+			name = e.URLs[0]
+		}
+		state := "last played " + e.LastUsed.Format("2006-01-02 15:04")
+		if e.Kept {
+			state = "downloaded"
+		}
+		sb.WriteString(fmt.Sprintf("%3d. %s  %s, %s\n", i+1, name, formatSize(e.Size), state))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// openCache loads the cache index the first time the cache is used.
+func (c *Commander) openCache() (*cache.Cache, error) {
+	if c.cache != nil {
+		return c.cache, nil
+	}
+	if c.config.Cache.Dir == "" {
+		return nil, fmt.Errorf("no cache directory set (use 'config set cache.dir <dir>')")
+	}
+	store, err := cache.Open(c.config.Cache.Dir, c.config.Cache.Size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache: %w", err)
+	}
+	c.cache = store
+	return store, nil
+}
+
+// formatSize shows a byte count in the largest fitting binary unit, e.g. "4.2 MB".
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
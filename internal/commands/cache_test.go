@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCacheDirSetting(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	c := NewCommander()
+	defer c.processor.Close()
+
+	run := func(line string) string {
+		t.Helper()
+		out, err, _ := c.Execute(line)
+		if err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+		return out
+	}
+	if out := run("cache ls"); !strings.Contains(out, filepath.Join(home, ".gowav", "cache")) {
+		t.Errorf("cache ls with the default settings:\n%s", out)
+	}
+	store := c.cache
+	if _, err := store.Put("https://a.example/a.mp3", "", strings.NewReader("audio"), false); err != nil {
+		t.Fatal(err)
+	}
+	store.Lookup("https://a.example/a.mp3")
+	index := filepath.Join(store.Dir(), "index.json")
+	if err := os.Remove(index); err != nil {
+		t.Fatal(err)
+	}
+
+	// Moving the cache saves the old index and opens the new place.
+	moved := filepath.Join(home, "elsewhere")
+	if out := run("config set cache.dir " + moved); strings.Contains(out, "Warning") {
+		t.Errorf("config set: %s", out)
+	}
+	if _, err := os.Stat(index); err != nil {
+		t.Errorf("the old index wasn't saved: %v", err)
+	}
+	if out := run("cache ls"); !strings.Contains(out, moved) || !strings.Contains(out, "Nothing cached") {
+		t.Errorf("cache ls after moving the cache:\n%s", out)
+	}
+}
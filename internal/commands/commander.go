@@ -4,30 +4,207 @@ import (
 	"fmt"
 	tea "github.com/charmbracelet/bubbletea"
 	"gowav/internal/audio"
+	"gowav/internal/cache"
+	"gowav/internal/config"
+	"gowav/internal/library"
+	"gowav/internal/podcast"
 	"gowav/pkg/api"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
+	"time"
 )
 
 type Commander struct {
 	player       *audio.Player
 	processor    *audio.Processor
 	apiClient    *api.Client
+	config       *config.Config
+	configErr    error
 	mode         Mode
 	loadProgress float64
 	currentTrack *Track
 
 	searchResults []SearchResult
+	searchNotice  string
+	// searchQuery and searchPage are the last remote search, for "more".
+	searchQuery string
+	searchPage  *api.SearchPage
+
+	queue *Queue
+	// fromQueue is set while the loaded track was started from the queue, so its end advances the queue.
+	fromQueue bool
+	// queueFailures counts the queue entries in a row that failed to load, so a queue where
+	// none loads stops instead of going round for ever under repeat-all.
+	queueFailures int
+	// playOnLoad is set while a load started by play/next/... should start playback once it completes;
+	// loadSeq tells that load apart from any started after it.
+	playOnLoad bool
+	loadSeq    int
+
+	// watchingEnd is set while a command is waiting on the player's Ended channel.
+	watchingEnd atomic.Bool
+
+	// library is opened on first use; scanning is set while a background scan runs.
+	library  *library.Library
+	scanning atomic.Bool
+
+	// podcasts is opened on first use; fetchingFeeds is set while feeds are being fetched.
+	// episodes is the last `podcast episodes` listing. episode is the episode playing, whose
+	// position is saved now and then (last at episodeSaved), and resumeAt where to start it.
+	podcasts      *podcast.Store
+	fetchingFeeds atomic.Bool
+	episodes      *podcast.Podcast
+	episode       *episodeRef
+	episodeSaved  time.Time
+	resumeAt      time.Duration
+
+	// cache keeps copies of remote tracks and is opened with the first processor;
+	// downloads counts the `download` commands still running.
+	cache     *cache.Cache
+	downloads atomic.Int32
+
+	// events receives the current processor's events once the UI starts listening;
+	// unsubscribe ends the subscription to the processor they come from.
+	events      chan audio.Event
+	unsubscribe func()
 }
 
 func NewCommander() *Commander {
-	return &Commander{
-		player:    audio.NewPlayer(),
-		processor: audio.NewProcessor(),
-		apiClient: api.NewClient(),
+	player := audio.NewPlayer()
+	if v, ok := loadVolume(); ok {
+		player.SetVolume(v)
+	}
+
+	// A broken config file shouldn't keep the player from starting; fall back to defaults and say so.
+	cfg, err := config.LoadDefault()
+	if err != nil {
+		cfg = config.Default()
+	}
+
+	c := &Commander{
+		player:    player,
+		configErr: err,
 		mode:      ModeNormal,
+		queue:     NewQueue(),
+	}
+	c.processor = audio.NewProcessor()
+	// No cache is open yet, so there is no index to fail to save.
+	c.applyConfig(cfg)
+	return c
+}
+
+// setProcessor replaces the processor, cancelling whatever the old one was doing and any
+// download, and moves the event subscription over to the new one.
+func (c *Commander) setProcessor(p *audio.Processor) {
+	if c.processor != nil {
+		c.processor.Close()
+	}
+	c.processor = p
+	c.subscribe()
+}
+
+// subscribe forwards the current processor's events to c.events, if anyone is listening.
+func (c *Commander) subscribe() {
+	if c.events == nil {
+		return
+	}
+	if c.unsubscribe != nil {
+		c.unsubscribe()
+	}
+	sub, unsubscribe := c.processor.Subscribe()
+	c.unsubscribe = unsubscribe
+	events := c.events
+	go func() {
+		for ev := range sub {
+			events <- ev
+		}
+	}()
+}
+
+// WaitProcessorEvent returns a command that waits for the next event from the processor
+// and delivers it as a ProcessorEventMsg. The UI runs it again after each event.
+func (c *Commander) WaitProcessorEvent() tea.Cmd {
+	if c.events == nil {
+		c.events = make(chan audio.Event, 64)
+		c.subscribe()
+	}
+	events := c.events
+	return func() tea.Msg {
+		return ProcessorEventMsg{<-events}
+	}
+}
+
+// applyConfig makes cfg the active configuration for the API client, logging, the cache and
+// the current processor. The settings take effect even if it fails to save the cache index.
+func (c *Commander) applyConfig(cfg *config.Config) error {
+	c.config = cfg
+	c.apiClient = api.NewClientWithConfig(apiConfig(cfg))
+	audio.SetLogDir(cfg.Log.Dir)
+	var err error
+	if c.cache != nil && c.cache.Dir() != cfg.Cache.Dir {
+		// The next use opens the cache in its new place.
+		err = c.cache.Close()
+		c.cache = nil
+	}
+	if c.cache != nil {
+		err = c.cache.SetLimit(cfg.Cache.Size)
+	}
+	c.configureProcessor(c.processor)
+	if err != nil {
+		return fmt.Errorf("failed to update the cache index: %w", err)
+	}
+	return nil
+}
+
+// Close saves what is kept on disk but written lazily, such as when cached tracks were last played.
+func (c *Commander) Close() error {
+	if c.cache == nil {
+		return nil
 	}
+	return c.cache.Close()
+}
+
+// newProcessor creates a Processor set up from the current configuration.
+func (c *Commander) newProcessor() *audio.Processor {
+	p := audio.NewProcessor()
+	c.configureProcessor(p)
+	return p
+}
+
+// configureProcessor makes p download through the API client, so requests to the API host
+// carry the configured credentials, and through the cache, if it opens. It also applies the
+// analysis and color settings.
+func (c *Commander) configureProcessor(p *audio.Processor) {
+	p.SetHTTPClient(c.apiClient.DownloadClient())
+	if store, err := c.openCache(); err == nil {
+		p.SetCache(store)
+	}
+	a := c.config.Analysis
+	p.SetAnalysisParameters(a.WindowSize, a.HopSize, a.FFTSize)
+	p.SetColorScheme(c.config.Viz.ColorScheme())
+}
+
+// apiConfig maps the api.* settings onto the client configuration.
+func apiConfig(cfg *config.Config) api.Config {
+	return api.Config{
+		BaseURL:    cfg.API.URL,
+		Token:      cfg.API.Token,
+		AuthHeader: cfg.API.AuthHeader,
+		UserAgent:  cfg.API.UserAgent,
+		Timeout:    cfg.API.Timeout,
+	}
+}
+
+// ConfigError reports a problem loading the config file at startup, if there was one.
+func (c *Commander) ConfigError() error {
+	return c.configErr
+}
+
+// Config returns the active configuration.
+func (c *Commander) Config() *config.Config {
+	return c.config
 }
 
 func (c *Commander) IsInTrackMode() bool {
@@ -39,10 +216,14 @@ func (c *Commander) GetProcessor() *audio.Processor {
 }
 
 func (c *Commander) GetCurrentTrack() *Track {
-	if c.processor == nil || c.processor.GetMetadata() == nil {
+	if c.processor == nil {
 		return nil
 	}
 	meta := c.processor.GetMetadata()
+	if meta == nil {
+		// Still loading: show what the search result or queue entry told us, if anything.
+		return c.currentTrack
+	}
 	return &Track{
 		Title:    meta.Title,
 		Artist:   meta.Artist,
@@ -63,8 +244,15 @@ func (c *Commander) GetPlaybackStatus() string {
 		formatPlaybackState(state),
 		FormatDuration(position),
 		FormatDuration(duration))
+	if c.player.IsLive() {
+		status = fmt.Sprintf("[%s] %s (live)", formatPlaybackState(state), FormatDuration(position))
+	}
 
-	return status + "\n" + c.player.RenderTrackBar(60)
+	status += "\n" + c.player.RenderTrackBar(60)
+	if health := c.BufferHealth(); health != "" {
+		status += "\n" + health
+	}
+	return status
 }
 
 func (c *Commander) GetLoadingProgress() float64 {
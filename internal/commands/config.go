@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"gowav/internal/config"
+	"os"
+	"strings"
+)
+
+// ConfigChangedMsg is sent after `config set` or `config reload` so the UI can pick up new key bindings.
+type ConfigChangedMsg struct{}
+
+// handleConfig shows and edits the settings in the config file.
+func (c *Commander) handleConfig(args []string) (string, error, tea.Cmd) {
+	path, err := config.Path()
+	if err != nil {
+		return "", fmt.Errorf("cannot locate config file: %w", err), nil
+	}
+	if len(args) == 0 {
+		return c.listConfig(path), nil, nil
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "get":
+		if len(args) != 2 {
+			return "", fmt.Errorf("usage: config get <key>"), nil
+		}
+		v, err := c.config.Display(args[1])
+		if err != nil {
+			return "", err, nil
+		}
+		return fmt.Sprintf("%s = %q", args[1], v), nil, nil
+
+	case "set":
+		if len(args) < 3 {
+			return "", fmt.Errorf("usage: config set <key> <value>"), nil
+		}
+		key := args[1]
+		value := strings.Trim(strings.Join(args[2:], " "), `"'`)
+		if err := config.Update(path, key, value); err != nil {
+			return "", err, nil
+		}
+		warn, err := c.reloadConfig(path)
+		if err != nil {
+			return "", fmt.Errorf("saved %s, but the config no longer loads: %w", key, err), nil
+		}
+		out := fmt.Sprintf("Set %s in %s", key, path)
+		if _, ok := os.LookupEnv(config.EnvName(key)); ok {
+			out += fmt.Sprintf("\nNote: %s is set and overrides the file.", config.EnvName(key))
+		}
+		if warn != nil {
+			out += fmt.Sprintf("\nWarning: %v", warn)
+		}
+		return out, nil, configChanged
+
+	case "reload":
+		warn, err := c.reloadConfig(path)
+		if err != nil {
+			return "", fmt.Errorf("keeping current settings: %w", err), nil
+		}
+		out := fmt.Sprintf("Reloaded settings from %s", path)
+		if warn != nil {
+			out += fmt.Sprintf("\nWarning: %v", warn)
+		}
+		return out, nil, configChanged
+
+	default:
+		return "", fmt.Errorf("usage: config [get <key> | set <key> <value> | reload]"), nil
+	}
+}
+
+// reloadConfig loads the config file and applies it. On error the current settings stay in
+// effect. warn is a problem applying settings that did load, which take effect regardless.
+func (c *Commander) reloadConfig(path string) (warn, err error) {
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	c.configErr = nil
+	return c.applyConfig(cfg), nil
+}
+
+func (c *Commander) listConfig(path string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Settings (%s):\n\n", path))
+	for _, key := range c.config.Keys() {
+		v, _ := c.config.Display(key)
+		line := fmt.Sprintf("  %-22s %q", key, v)
+		if _, ok := os.LookupEnv(config.EnvName(key)); ok && !config.IsBinding(key) && !config.IsStation(key) {
+			line += fmt.Sprintf("  (from %s)", config.EnvName(key))
+		}
+		sb.WriteString(line + "\n")
+	}
+	sb.WriteString("\nChange a setting with 'config set <key> <value>'.")
+	return sb.String()
+}
+
+func configChanged() tea.Msg {
+	return ConfigChangedMsg{}
+}
@@ -0,0 +1,29 @@
+package commands
+
+import (
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// ansiEscape matches the terminal color and style sequences in rendered output.
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+
+// handleExport saves the current visualization as plain text.
+func (c *Commander) handleExport(args []string) (string, error, tea.Cmd) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("usage: export <file>"), nil
+	}
+	path := expandHome(strings.Trim(strings.Join(args, " "), `"'`))
+
+	rendered, err := c.processor.ExportVisualization()
+	if err != nil {
+		return "", err, nil
+	}
+	if err := os.WriteFile(path, []byte(ansiEscape.ReplaceAllString(rendered, "")+"\n"), 0644); err != nil {
+		return "", fmt.Errorf("failed to export: %w", err), nil
+	}
+	return fmt.Sprintf("Exported visualization to %s", path), nil, nil
+}
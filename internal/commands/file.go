@@ -1,7 +1,9 @@
 package commands
 
 import (
+	"context"
 	"fmt"
+	"gowav/internal/audio"
 	"sync"
 	"time"
 )
@@ -59,10 +61,31 @@ func (s *LoadingState) GetETA() string {
 
 // handleLoad starts the asynchronous load of a local file or URL.
 func (c *Commander) handleLoad(path string) (string, error) {
-	err := c.processor.LoadFile(path)
-	if err != nil {
+	if err := c.loadTrack(path, nil); err != nil {
 		return "", err
 	}
+	c.fromQueue = false
+	c.playOnLoad = false
 	// We only confirm that loading started. The UI will show the spinner/progress/ETA while loading.
 	return fmt.Sprintf("Started loading file: %s\nPress Ctrl+C to cancel...", path), nil
 }
+
+// loadTrack starts loading path. track holds whatever is already known about it (e.g. from a
+// search result); it stands in for the tags until they are read and fills any they leave empty.
+func (c *Commander) loadTrack(path string, track *Track) error {
+	c.leaveEpisode()
+	var hint *audio.Metadata
+	if track != nil {
+		hint = &audio.Metadata{
+			Title:    track.Title,
+			Artist:   track.Artist,
+			Album:    track.Album,
+			Duration: time.Duration(track.Duration) * time.Second,
+		}
+	}
+	if err := c.processor.LoadFileWithHint(context.Background(), path, hint); err != nil {
+		return err
+	}
+	c.currentTrack = track
+	return nil
+}
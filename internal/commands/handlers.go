@@ -1,9 +1,9 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	tea "github.com/charmbracelet/bubbletea"
-	"gowav/internal/audio"
 	"gowav/internal/types"
 	"gowav/pkg/viz"
 	"path/filepath"
@@ -22,19 +22,70 @@ func (c *Commander) handleTrackCommand(cmd string, args []string) (string, error
 	case "help", "h":
 		return c.handleTrackHelp()
 	case "unload":
+		c.leaveEpisode()
 		c.mode = ModeNormal
-		c.processor = audio.NewProcessor()
+		c.setProcessor(c.newProcessor())
+		c.currentTrack = nil
 		return "Track unloaded. Returning to normal mode.", nil, nil
 	case "info", "i":
 		return c.handleInfo()
+	case "load", "l":
+		return c.handleLoadCommand(args)
 	case "play", "p":
+		if len(args) > 0 {
+			return c.handlePlayResult(args[0])
+		}
 		return c.handlePlay()
+	case "enqueue":
+		return c.handleEnqueue(args)
+	case "album":
+		return c.handleAlbum(args)
+	case "artist":
+		return c.handleArtist(args)
 	case "pause":
 		return c.handlePause()
 	case "stop":
 		return c.handleStop()
+	case "seek":
+		return c.handleSeek(args)
+	case "volume", "vol":
+		return c.handleVolume(args)
+	case "volume-up":
+		return c.handleVolumeStep(volumeStep)
+	case "volume-down":
+		return c.handleVolumeStep(-volumeStep)
+	case "mute":
+		return c.handleMute(true)
+	case "unmute":
+		return c.handleMute(false)
+	case "queue":
+		return c.handleQueue(args)
+	case "next":
+		return c.handleNext()
+	case "prev":
+		return c.handlePrev()
+	case "shuffle":
+		return c.handleShuffle(args)
+	case "repeat":
+		return c.handleRepeat(args)
+	case "playlist", "pl":
+		return c.handlePlaylist(args)
+	case "library", "lib":
+		return c.handleLibrary(args)
+	case "config":
+		return c.handleConfig(args)
+	case "radio":
+		return c.handleRadio(args)
+	case "podcast", "pod":
+		return c.handlePodcast(args)
+	case "download":
+		return c.handleDownload(args)
+	case "cache":
+		return c.handleCache(args)
 	case "artwork", "art":
 		return c.handleArtwork()
+	case "export":
+		return c.handleExport(args)
 	case "viz", "v":
 		if len(args) == 0 {
 			return c.handleVisualization([]string{"wave"})
@@ -50,23 +101,57 @@ func (c *Commander) handleNormalCommand(cmd string, args []string) (string, erro
 	case "help", "h":
 		return c.handleHelp()
 	case "load", "l":
+		return c.handleLoadCommand(args)
+	case "play", "p":
 		if len(args) == 0 {
-			return "", fmt.Errorf("usage: load <path/url>"), nil
-		}
-		path := strings.Join(args, " ")
-		path = strings.Trim(path, `"'`)
-		path = filepath.Clean(path)
-		out, err := c.handleLoad(path)
-		if err == nil {
-			c.mode = ModeTrack
+			return "", fmt.Errorf("usage: play <n> to play a search result (or load a track first)"), nil
 		}
-		return out, err, nil
+		return c.handlePlayResult(args[0])
+	case "enqueue":
+		return c.handleEnqueue(args)
+	case "album":
+		return c.handleAlbum(args)
+	case "artist":
+		return c.handleArtist(args)
 	case "search", "s":
-		if len(args) == 0 {
-			return "", fmt.Errorf("usage: search <query>"), nil
-		}
-		output, err := c.handleSearch(strings.Join(args, " "))
+		output, err := c.handleSearchCommand(args)
 		return output, err, nil
+	case "more":
+		return c.handleMore()
+	case "volume", "vol":
+		return c.handleVolume(args)
+	case "volume-up":
+		return c.handleVolumeStep(volumeStep)
+	case "volume-down":
+		return c.handleVolumeStep(-volumeStep)
+	case "mute":
+		return c.handleMute(true)
+	case "unmute":
+		return c.handleMute(false)
+	case "queue":
+		return c.handleQueue(args)
+	case "next":
+		return c.handleNext()
+	case "prev":
+		return c.handlePrev()
+	case "shuffle":
+		return c.handleShuffle(args)
+	case "repeat":
+		return c.handleRepeat(args)
+	case "playlist", "pl":
+		return c.handlePlaylist(args)
+	case "library", "lib":
+		return c.handleLibrary(args)
+	case "config":
+		return c.handleConfig(args)
+	case "radio":
+		return c.handleRadio(args)
+	case "podcast", "pod":
+		return c.handlePodcast(args)
+	case "download":
+		return c.handleDownload(args)
+	case "cache":
+		return c.handleCache(args)
 	case "quit", "q", "exit":
 		return "Goodbye!", nil, tea.Quit
 	default:
@@ -74,6 +159,26 @@ func (c *Commander) handleNormalCommand(cmd string, args []string) (string, erro
 	}
 }
 
+// handleLoadCommand loads a path or URL, or search result n for "load <n>".
+func (c *Commander) handleLoadCommand(args []string) (string, error, tea.Cmd) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("usage: load <path/url> | load <n>"), nil
+	}
+	if c.isResultRef(args) {
+		return c.handleLoadResult(args[0])
+	}
+	path := strings.Join(args, " ")
+	path = strings.Trim(path, `"'`)
+	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		path = filepath.Clean(path)
+	}
+	out, err := c.handleLoad(path)
+	if err == nil {
+		c.mode = ModeTrack
+	}
+	return out, err, nil
+}
+
 func (c *Commander) handleVisualization(args []string) (string, error, tea.Cmd) {
 	if len(args) == 0 {
 		return "", fmt.Errorf("visualization type required"), nil
@@ -98,8 +203,24 @@ func (c *Commander) handleVisualization(args []string) (string, error, tea.Cmd)
 		return "", fmt.Errorf("unknown visualization: %s", vizType), nil
 	}
 
+	// "viz wave stereo" / "viz density midside" pick the channel layout; plain "viz wave" is mono.
+	var layoutArg string
+	if len(args) > 1 {
+		if vMode != viz.WaveformMode && vMode != viz.DensityMode {
+			return "", fmt.Errorf("channel layouts are only available for wave and density"), nil
+		}
+		layoutArg = args[1]
+	}
+	if vMode == viz.WaveformMode || vMode == viz.DensityMode {
+		layout, err := viz.ParseChannelLayout(layoutArg)
+		if err != nil {
+			return "", err, nil
+		}
+		c.processor.SetChannelLayout(layout)
+	}
+
 	// Always try to switch visualization, which will initiate analysis if needed
-	output, err := c.processor.SwitchVisualization(vMode)
+	output, err := c.processor.SwitchVisualization(context.Background(), vMode)
 	if err != nil {
 		// Check if it's just "preparing visualization" message
 		if strings.Contains(err.Error(), "preparing visualization") {
@@ -9,7 +9,29 @@ func (c *Commander) handleHelp() (string, error, tea.Cmd) {
     
 help, h          Show this help message
 load, l <path>   Load audio file from path or URL
-search, s <query> Search for tracks
+search, s <query> Search the library and online catalogue (search --page N <query> for later pages)
+more             Show the next page of online results
+play, p <n>      Play search result n (also: load <n>)
+enqueue <n>      Queue search results, e.g. enqueue 3, enqueue 1-5, enqueue 1,4, enqueue all
+album <slug|n> [enqueue]   Show an online album's tracks (n: album of search result n)
+artist <slug|n> [enqueue]  Show an online artist's albums and songs
+volume <0-150>   Set volume (or volume +5 / volume -5)
+mute, unmute     Silence or restore output
+queue add <path|dir|url>  Add tracks to the play queue
+queue list       Show the queue (also: queue remove <n>, queue move <from> <to>, queue play [n], queue clear)
+next, prev       Skip to the next or previous queued track
+shuffle on|off   Shuffle the queue order
+repeat off|one|all  Repeat the current track or the whole queue
+playlist load <file>  Queue tracks from an M3U/M3U8, PLS or XSPF playlist
+playlist save <file> [queue|results]  Save the queue or last search results as a playlist
+library scan <dir>  Index music under a directory (rescans only changed files)
+library artists  List artists in the library (also: library albums <artist>, library tracks <album>)
+config           Show settings (also: config get <key>, config set <key> <value>, config reload)
+radio [n|name]   List internet radio stations or tune in (also: radio add <name> <url>, radio remove <name>)
+podcast [list]   List podcast subscriptions (also: podcast add <feed-url>, podcast refresh)
+podcast episodes <n|name>  List a podcast's episodes; podcast play <n> plays one, resuming where it stopped
+download <n|url>  Keep a search result or URL in the cache for offline play
+cache ls         List cached and downloaded tracks (also: cache clear, cache clear all)
 quit, q, exit    Exit application
 
 (type 'help' for more info)`
@@ -22,16 +44,40 @@ func (c *Commander) handleTrackHelp() (string, error, tea.Cmd) {
 
 info, i          Show detailed track information
 play, p          Play current track
+play, p <n>      Play search result n (also: load <n>)
+enqueue <n>      Queue search results, e.g. enqueue 3, enqueue 1-5, enqueue 1,4, enqueue all
+album <slug|n> [enqueue]   Show an online album's tracks (n: album of search result n)
+artist <slug|n> [enqueue]  Show an online artist's albums and songs
 pause            Pause playback
 stop             Stop playback
+seek <mm:ss>     Jump to a position (or seek +10s / seek -10s)
+volume <0-150>   Set volume (or volume +5 / volume -5)
+mute, unmute     Silence or restore output
+queue add <path|dir|url>  Add tracks to the play queue
+queue list       Show the queue (also: queue remove <n>, queue move <from> <to>, queue play [n], queue clear)
+next, prev       Skip to the next or previous queued track
+shuffle on|off   Shuffle the queue order
+repeat off|one|all  Repeat the current track or the whole queue
+playlist load <file>  Queue tracks from an M3U/M3U8, PLS or XSPF playlist
+playlist save <file> [queue|results]  Save the queue or last search results as a playlist
+library scan <dir>  Index music under a directory (rescans only changed files)
+library artists  List artists in the library (also: library albums <artist>, library tracks <album>)
+config           Show settings (also: config get <key>, config set <key> <value>, config reload)
+radio [n|name]   List internet radio stations or tune in (also: radio add <name> <url>, radio remove <name>)
+podcast [list]   List podcast subscriptions (also: podcast add <feed-url>, podcast refresh)
+podcast episodes <n|name>  List a podcast's episodes; podcast play <n> plays one, resuming where it stopped
+download <n|url>  Keep a search result or URL in the cache for offline play
+cache ls         List cached and downloaded tracks (also: cache clear, cache clear all)
 artwork          Show album artwork in ASCII
 unload           Unload current track, return to normal mode
 
 viz wave         Waveform visualization
+viz wave stereo  Waveform with left/right channels (or midside)
 viz spectrum     Frequency (Spectrogram) visualization
 viz tempo        Tempo/energy analysis
 viz density      Density map
 viz beat         Beat/rhythm patterns
+export <file>    Save the current visualization as text
 
 help, h          Show this help message
 `
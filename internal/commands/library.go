@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"gowav/internal/library"
+	"path/filepath"
+	"strings"
+)
+
+// LibraryScanDoneMsg carries the outcome of a background `library scan`.
+type LibraryScanDoneMsg struct {
+	Result library.ScanResult
+	Err    error
+}
+
+// handleLibrary scans directories into the local library index and browses it by artist and album.
+func (c *Commander) handleLibrary(args []string) (string, error, tea.Cmd) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("usage: library scan <dir> | artists | albums <artist> | tracks <album>"), nil
+	}
+	lib, err := c.openLibrary()
+	if err != nil {
+		return "", err, nil
+	}
+	rest := strings.Trim(strings.Join(args[1:], " "), `"'`)
+
+	switch strings.ToLower(args[0]) {
+	case "scan":
+		if rest == "" {
+			return "", fmt.Errorf("usage: library scan <dir>"), nil
+		}
+		if !c.scanning.CompareAndSwap(false, true) {
+			return "", fmt.Errorf("a library scan is already running"), nil
+		}
+		dir := expandHome(rest)
+		return fmt.Sprintf("Scanning %s...", dir), nil, func() tea.Msg {
+			defer c.scanning.Store(false)
+			res, err := lib.Scan(dir)
+			return LibraryScanDoneMsg{Result: res, Err: err}
+		}
+
+	case "artists":
+		artists, counts := lib.Artists()
+		if len(artists) == 0 {
+			return "Library is empty. Use 'library scan <dir>' to add music.", nil, nil
+		}
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("Artists (%d):\n", len(artists)))
+		for i, a := range artists {
+			sb.WriteString(fmt.Sprintf("%3d. %s (%d)\n", i+1, a, counts[a]))
+		}
+		return sb.String(), nil, nil
+
+	case "albums":
+		if rest == "" {
+			return "", fmt.Errorf("usage: library albums <artist>"), nil
+		}
+		albums, counts := lib.Albums(rest)
+		if len(albums) == 0 {
+			return "", fmt.Errorf("no albums by %s in the library", rest), nil
+		}
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("Albums by %s (%d):\n", rest, len(albums)))
+		for i, a := range albums {
+			sb.WriteString(fmt.Sprintf("%3d. %s (%d tracks)\n", i+1, a, counts[a]))
+		}
+		return sb.String(), nil, nil
+
+	case "tracks":
+		if rest == "" {
+			return "", fmt.Errorf("usage: library tracks <album>"), nil
+		}
+		tracks := lib.Tracks(rest)
+		if len(tracks) == 0 {
+			return "", fmt.Errorf("no album named %s in the library", rest), nil
+		}
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("%s (%d tracks):\n", tracks[0].AlbumName(), len(tracks)))
+		for i, t := range tracks {
+			name := t.DisplayTitle()
+			if t.Artist != "" {
+				name = t.Artist + " - " + name
+			}
+			sb.WriteString(fmt.Sprintf("%3d. %s [%s]\n", i+1, name, FormatDuration(t.Duration)))
+			sb.WriteString(fmt.Sprintf("     %s\n", filepath.Base(t.Path)))
+		}
+		return sb.String(), nil, nil
+
+	default:
+		return "", fmt.Errorf("unknown library command: %s (scan, artists, albums, tracks)", args[0]), nil
+	}
+}
+
+// openLibrary loads the on-disk index the first time the library is used.
+func (c *Commander) openLibrary() (*library.Library, error) {
+	if c.library != nil {
+		return c.library, nil
+	}
+	path, err := library.DefaultPath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate library index: %w", err)
+	}
+	lib, err := library.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open library: %w", err)
+	}
+	c.library = lib
+	return lib, nil
+}
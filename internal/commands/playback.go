@@ -4,17 +4,33 @@ import (
 	"fmt"
 	tea "github.com/charmbracelet/bubbletea"
 	"gowav/internal/audio"
+	"strconv"
+	"strings"
 	"time"
 )
 
 func (c *Commander) handlePlay() (string, error, tea.Cmd) {
-	if c.processor == nil || c.processor.GetCurrentFile() == nil {
+	if c.processor == nil || c.processor.GetMetadata() == nil {
 		return "", fmt.Errorf("no track loaded"), nil
 	}
-	if err := c.player.Play(c.processor.GetCurrentFile()); err != nil {
+	if c.player.GetState() == audio.StatePaused {
+		if err := c.player.Resume(); err != nil {
+			return "", fmt.Errorf("failed to resume: %w", err), nil
+		}
+		return "Resumed", nil, tea.Batch(c.startPlaybackUpdates(), c.watchTrackEnd())
+	}
+
+	src, err := c.processor.OpenSource()
+	if err != nil {
+		return "", fmt.Errorf("failed to decode: %w", err), nil
+	}
+	if meta := c.processor.GetMetadata(); meta != nil {
+		c.player.SetDuration(meta.Duration)
+	}
+	if err := c.player.Play(src); err != nil {
 		return "", fmt.Errorf("failed to play: %w", err), nil
 	}
-	return "Playing...", nil, c.startPlaybackUpdates()
+	return "Playing...", nil, tea.Batch(c.startPlaybackUpdates(), c.watchTrackEnd())
 }
 
 func (c *Commander) handlePause() (string, error, tea.Cmd) {
@@ -24,22 +40,146 @@ func (c *Commander) handlePause() (string, error, tea.Cmd) {
 	if err := c.player.Pause(); err != nil {
 		return "", fmt.Errorf("failed to pause: %w", err), nil
 	}
+	c.saveEpisodePosition()
 	return "Paused", nil, nil
 }
 
 func (c *Commander) handleStop() (string, error, tea.Cmd) {
+	c.saveEpisodePosition()
 	if err := c.player.Stop(); err != nil {
 		return "", fmt.Errorf("failed to stop: %w", err), nil
 	}
 	return "Stopped", nil, nil
 }
 
+// handleSeek moves playback to an absolute position ("1:30", "90") or by a relative offset ("+10s", "-1m").
+func (c *Commander) handleSeek(args []string) (string, error, tea.Cmd) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("usage: seek <mm:ss> | +<duration> | -<duration>"), nil
+	}
+	if c.player.GetState() == audio.StateStopped {
+		return "", fmt.Errorf("no track is currently playing"), nil
+	}
+
+	target, err := parseSeekTarget(args[0], c.player.GetPosition())
+	if err != nil {
+		return "", err, nil
+	}
+	if d := c.player.GetDuration(); d > 0 && target > d {
+		target = d
+	}
+	if err := c.player.Seek(target); err != nil {
+		return "", err, nil
+	}
+	return fmt.Sprintf("Seeked to %s", FormatDuration(c.player.GetPosition())), nil, nil
+}
+
+// parseSeekTarget resolves a seek argument against the current position.
+// Relative offsets use Go duration syntax with a sign; absolute positions are
+// [hh:]mm:ss or plain seconds.
+func parseSeekTarget(arg string, current time.Duration) (time.Duration, error) {
+	if strings.HasPrefix(arg, "+") || strings.HasPrefix(arg, "-") {
+		offset, err := time.ParseDuration(arg)
+		if err != nil {
+			return 0, fmt.Errorf("invalid seek offset %q (try +10s or -1m30s)", arg)
+		}
+		target := current + offset
+		if target < 0 {
+			target = 0
+		}
+		return target, nil
+	}
+
+	parts := strings.Split(arg, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid seek position %q (try 1:30)", arg)
+	}
+	var total float64
+	for _, part := range parts {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil || v < 0 {
+			return 0, fmt.Errorf("invalid seek position %q (try 1:30)", arg)
+		}
+		total = total*60 + v
+	}
+	return time.Duration(total * float64(time.Second)), nil
+}
+
 func (c *Commander) startPlaybackUpdates() tea.Cmd {
 	return tea.Tick(time.Second/10, func(time.Time) tea.Msg {
-		return playbackUpdateMsg{}
+		return PlaybackUpdateMsg{}
 	})
 }
 
+// NextPlaybackUpdate schedules the next PlaybackUpdateMsg while a track is playing, and
+// now and then saves how far a podcast episode has got.
+func (c *Commander) NextPlaybackUpdate() tea.Cmd {
+	if c.player.GetState() != audio.StatePlaying {
+		return nil
+	}
+	if c.episode != nil && time.Since(c.episodeSaved) >= episodeSaveInterval {
+		c.saveEpisodePosition()
+	}
+	return c.startPlaybackUpdates()
+}
+
+// watchTrackEnd waits for the player to finish a track and reports it as a TrackEndedMsg.
+// Only one watcher runs at a time; it stays armed across pauses, stops and seeks.
+func (c *Commander) watchTrackEnd() tea.Cmd {
+	if !c.watchingEnd.CompareAndSwap(false, true) {
+		return nil
+	}
+	ended := c.player.Ended()
+	return func() tea.Msg {
+		<-ended
+		c.watchingEnd.Store(false)
+		return TrackEndedMsg{}
+	}
+}
+
+// HandleTrackEnded reacts to a TrackEndedMsg, marking a podcast episode played and
+// advancing the queue, and returns the message to show.
+func (c *Commander) HandleTrackEnded() (string, tea.Cmd) {
+	if c.episode != nil {
+		c.podcasts.MarkPlayed(c.episode.feed, c.episode.guid)
+		c.episode = nil
+	}
+	return c.advanceQueue()
+}
+
+// BufferHealth describes the download of a streamed track or live stream: how much is
+// buffered ahead of playback and how much has arrived. It is "" for local files and
+// finished downloads.
+func (c *Commander) BufferHealth() string {
+	st, ok := c.processor.StreamStatus()
+	if !ok || st.Done {
+		return ""
+	}
+	if st.Err != nil {
+		return fmt.Sprintf("Stream: %v", st.Err)
+	}
+
+	var parts []string
+	if st.Waiting {
+		parts = append(parts, "buffering...")
+	} else if meta := c.processor.GetMetadata(); meta != nil && meta.BitRate > 0 {
+		seconds := float64(st.Ahead*8) / float64(meta.BitRate*1000)
+		parts = append(parts, fmt.Sprintf("%.0fs ahead", seconds))
+	} else {
+		parts = append(parts, fmt.Sprintf("%d KB ahead", st.Ahead>>10))
+	}
+	if st.Size > 0 {
+		parts = append(parts, fmt.Sprintf("%d%% downloaded", st.Downloaded*100/st.Size))
+	}
+	if st.Retries > 0 {
+		parts = append(parts, fmt.Sprintf("%d reconnects", st.Retries))
+	}
+	if st.Live {
+		return "Live buffer: " + strings.Join(parts, " · ")
+	}
+	return "Buffer: " + strings.Join(parts, " · ")
+}
+
 func formatPlaybackState(state audio.PlaybackState) string {
 	switch state {
 	case audio.StatePlaying:
@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"gowav/pkg/playlist"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// handlePlaylist loads a playlist file into the queue or saves the queue (or the last search results) to one.
+func (c *Commander) handlePlaylist(args []string) (string, error, tea.Cmd) {
+	if len(args) < 2 {
+		return "", fmt.Errorf("usage: playlist load <file> | playlist save <file> [queue|results]"), nil
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "load":
+		path := expandHome(strings.Trim(strings.Join(args[1:], " "), `"'`))
+		pl, err := playlist.Load(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to load playlist: %w", err), nil
+		}
+		if len(pl.Entries) == 0 {
+			return "", fmt.Errorf("playlist %s has no entries", path), nil
+		}
+		items := make([]QueueItem, len(pl.Entries))
+		for i, e := range pl.Entries {
+			items[i] = QueueItem{Path: e.Location, Title: entryTitle(e), Duration: e.Duration}
+		}
+		c.queue.Add(items...)
+		return fmt.Sprintf("Queued %d tracks from %s (%d in queue)", len(items), filepath.Base(path), c.queue.Len()), nil, nil
+
+	case "save":
+		source := "queue"
+		fileArgs := args[1:]
+		if n := len(fileArgs); n > 1 {
+			if last := strings.ToLower(fileArgs[n-1]); last == "queue" || last == "results" {
+				source, fileArgs = last, fileArgs[:n-1]
+			}
+		}
+		path := expandHome(strings.Trim(strings.Join(fileArgs, " "), `"'`))
+
+		pl := &playlist.Playlist{Title: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))}
+		if source == "results" {
+			for _, r := range c.searchResults {
+				pl.Entries = append(pl.Entries, playlist.Entry{
+					Location: r.URL,
+					Title:    r.Label(),
+					Duration: time.Duration(r.Duration) * time.Second,
+				})
+			}
+		} else {
+			for _, item := range c.queue.Items() {
+				pl.Entries = append(pl.Entries, playlist.Entry{
+					Location: item.Path,
+					Title:    item.Title,
+					Duration: item.Duration,
+				})
+			}
+		}
+		if len(pl.Entries) == 0 {
+			return "", fmt.Errorf("nothing to save: the %s is empty", source), nil
+		}
+
+		if err := playlist.Save(path, pl); err != nil {
+			return "", fmt.Errorf("failed to save playlist: %w", err), nil
+		}
+		return fmt.Sprintf("Saved %d tracks to %s", len(pl.Entries), path), nil, nil
+
+	default:
+		return "", fmt.Errorf("unknown playlist command: %s (load, save)", args[0]), nil
+	}
+}
+
+// entryTitle falls back to the file name when a playlist entry has no title.
+func entryTitle(e playlist.Entry) string {
+	if e.Title != "" {
+		return e.Title
+	}
+	return filepath.Base(e.Location)
+}
+
+// expandHome replaces a leading "~/" with the user's home directory.
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
@@ -0,0 +1,224 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"gowav/internal/audio"
+	"gowav/internal/podcast"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PodcastFetchDoneMsg carries the outcome of a background `podcast add` or `podcast refresh`.
+type PodcastFetchDoneMsg struct {
+	Summary string
+	Err     error
+}
+
+// episodeRef identifies the podcast episode that is playing, so its position can be saved.
+type episodeRef struct {
+	feed, guid string
+}
+
+// episodeSaveInterval is how often the position of a playing episode is saved.
+const episodeSaveInterval = 5 * time.Second
+
+// handlePodcast subscribes to podcast feeds, lists their episodes and plays them.
+func (c *Commander) handlePodcast(args []string) (string, error, tea.Cmd) {
+	store, err := c.openPodcasts()
+	if err != nil {
+		return "", err, nil
+	}
+	if len(args) == 0 || strings.EqualFold(args[0], "list") {
+		return listPodcasts(store.Podcasts()), nil, nil
+	}
+	rest := strings.Trim(strings.Join(args[1:], " "), `"'`)
+
+	switch strings.ToLower(args[0]) {
+	case "add":
+		if rest == "" {
+			return "", fmt.Errorf("usage: podcast add <feed-url>"), nil
+		}
+		if !strings.HasPrefix(rest, "http://") && !strings.HasPrefix(rest, "https://") {
+			return "", fmt.Errorf("feed address must start with http:// or https://"), nil
+		}
+		return c.fetchFeeds(fmt.Sprintf("Fetching %s...", rest), func(client *http.Client) PodcastFetchDoneMsg {
+			p, err := store.Add(context.Background(), client, rest)
+			if err != nil {
+				return PodcastFetchDoneMsg{Err: err}
+			}
+			return PodcastFetchDoneMsg{Summary: fmt.Sprintf("Subscribed to %s (%d episodes)", p.Title, len(p.Episodes))}
+		})
+
+	case "refresh":
+		if len(store.Podcasts()) == 0 {
+			return "", fmt.Errorf("no podcasts to refresh (add one with 'podcast add <feed-url>')"), nil
+		}
+		return c.fetchFeeds("Refreshing podcasts...", func(client *http.Client) PodcastFetchDoneMsg {
+			res, err := store.Refresh(context.Background(), client)
+			if err != nil {
+				return PodcastFetchDoneMsg{Err: fmt.Errorf("podcast refresh failed: %w", err)}
+			}
+			return PodcastFetchDoneMsg{Summary: res.String()}
+		})
+
+	case "episodes", "eps":
+		if rest == "" {
+			return "", fmt.Errorf("usage: podcast episodes <n|name>"), nil
+		}
+		p, err := store.Find(rest)
+		if err != nil {
+			return "", err, nil
+		}
+		c.episodes = &p
+		return listEpisodes(p), nil, nil
+
+	case "play", "p":
+		if rest == "" {
+			return "", fmt.Errorf("usage: podcast play <n>"), nil
+		}
+		return c.playEpisode(rest)
+
+	default:
+		return "", fmt.Errorf("unknown podcast command: %s (add, list, episodes, play, refresh)", args[0]), nil
+	}
+}
+
+// fetchFeeds runs fetch in the background, one fetch at a time.
+func (c *Commander) fetchFeeds(started string, fetch func(client *http.Client) PodcastFetchDoneMsg) (string, error, tea.Cmd) {
+	if !c.fetchingFeeds.CompareAndSwap(false, true) {
+		return "", fmt.Errorf("podcast feeds are already being fetched"), nil
+	}
+	client := c.apiClient.DownloadClient()
+	return started, nil, func() tea.Msg {
+		defer c.fetchingFeeds.Store(false)
+		return fetch(client)
+	}
+}
+
+// playEpisode plays episode n of the last `podcast episodes` listing, picking up where it
+// was left unless it was played to the end.
+func (c *Commander) playEpisode(ref string) (string, error, tea.Cmd) {
+	if c.episodes == nil {
+		return "", fmt.Errorf("no episodes listed (use 'podcast episodes <n|name>' first)"), nil
+	}
+	n, err := strconv.Atoi(ref)
+	if err != nil || n < 1 || n > len(c.episodes.Episodes) {
+		return "", fmt.Errorf("no episode %s (there are %d)", ref, len(c.episodes.Episodes)), nil
+	}
+	feed := c.episodes.URL
+	ep := c.episodes.Episodes[n-1]
+	// The listing may be out of date; the store has the latest position.
+	if p, err := c.podcasts.Find(feed); err == nil {
+		for _, e := range p.Episodes {
+			if e.GUID == ep.GUID {
+				ep = e
+			}
+		}
+	}
+
+	c.leaveEpisode()
+	c.player.Stop()
+	track := &Track{
+		Title:    ep.Title,
+		Artist:   c.episodes.Author,
+		Album:    c.episodes.Title,
+		Duration: int(ep.Duration.Seconds()),
+	}
+	if err := c.loadTrack(ep.URL, track); err != nil {
+		return "", err, nil
+	}
+	c.mode = ModeTrack
+	c.fromQueue = false
+	c.episode = &episodeRef{feed: feed, guid: ep.GUID}
+	c.episodeSaved = time.Now()
+	if !ep.Played {
+		c.resumeAt = ep.Position
+	}
+	return fmt.Sprintf("Loading %s...", ep.Title), nil, c.playWhenLoaded()
+}
+
+// saveEpisodePosition records how far the playing episode has got. It is best effort:
+// failing to save is no reason to interrupt playback. A stopped player has lost its
+// position, so there is nothing to save then.
+func (c *Commander) saveEpisodePosition() {
+	if c.episode == nil || c.player.GetState() == audio.StateStopped {
+		return
+	}
+	c.podcasts.SetPosition(c.episode.feed, c.episode.guid, c.player.GetPosition())
+	c.episodeSaved = time.Now()
+}
+
+// leaveEpisode saves the position of the playing episode before something else is loaded.
+// Call it before stopping the player.
+func (c *Commander) leaveEpisode() {
+	c.saveEpisodePosition()
+	c.episode = nil
+	c.resumeAt = 0
+}
+
+// openPodcasts loads the subscriptions the first time podcasts are used.
+func (c *Commander) openPodcasts() (*podcast.Store, error) {
+	if c.podcasts != nil {
+		return c.podcasts, nil
+	}
+	path, err := podcast.DefaultPath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate podcast list: %w", err)
+	}
+	store, err := podcast.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open podcast list: %w", err)
+	}
+	c.podcasts = store
+	return store, nil
+}
+
+func listPodcasts(podcasts []podcast.Podcast) string {
+	if len(podcasts) == 0 {
+		return "No podcasts yet. Subscribe with 'podcast add <feed-url>'."
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Podcasts (%d):\n", len(podcasts)))
+	for i, p := range podcasts {
+		sb.WriteString(fmt.Sprintf("%3d. %s (%d episodes, %d unplayed)\n", i+1, p.Title, len(p.Episodes), p.Unplayed()))
+	}
+	sb.WriteString("\nList episodes with 'podcast episodes <n|name>'.")
+	return sb.String()
+}
+
+// listEpisodes shows a podcast's episodes, marking those played (✓) and those started (▶).
+func listEpisodes(p podcast.Podcast) string {
+	if len(p.Episodes) == 0 {
+		return fmt.Sprintf("%s has no episodes.", p.Title)
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s (%d episodes):\n", p.Title, len(p.Episodes)))
+	for i, ep := range p.Episodes {
+		mark := " "
+		switch {
+		case ep.Played:
+			mark = "✓"
+		case ep.Position > 0:
+			mark = "▶"
+		}
+		line := fmt.Sprintf("%3d. %s %s", i+1, mark, ep.Title)
+		if !ep.Published.IsZero() {
+			line += "  " + ep.Published.Format("2006-01-02")
+		}
+		switch {
+		case ep.Position > 0 && ep.Duration > 0:
+			line += fmt.Sprintf(" [%s / %s]", FormatDuration(ep.Position), FormatDuration(ep.Duration))
+		case ep.Position > 0:
+			line += fmt.Sprintf(" [at %s]", FormatDuration(ep.Position))
+		case ep.Duration > 0:
+			line += fmt.Sprintf(" [%s]", FormatDuration(ep.Duration))
+		}
+		sb.WriteString(line + "\n")
+	}
+	sb.WriteString("\nPlay one with 'podcast play <n>'.")
+	return sb.String()
+}
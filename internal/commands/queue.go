@@ -0,0 +1,287 @@
+package commands
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// RepeatMode controls what the queue does when it reaches the end of a track or of the list.
+type RepeatMode int
+
+const (
+	RepeatOff RepeatMode = iota
+	RepeatOne
+	RepeatAll
+)
+
+func (r RepeatMode) String() string {
+	switch r {
+	case RepeatOne:
+		return "one"
+	case RepeatAll:
+		return "all"
+	default:
+		return "off"
+	}
+}
+
+// QueueItem is one entry of the play queue: a local path or an http(s) URL.
+// Duration is zero when unknown; Track is set when the entry came from a search result.
+type QueueItem struct {
+	Path     string
+	Title    string
+	Duration time.Duration
+	Track    *Track
+}
+
+// Queue is an ordered list of tracks with a play cursor. Items keep their listed order;
+// order holds the sequence they are played in, which differs only while shuffling.
+type Queue struct {
+	items   []QueueItem
+	order   []int
+	pos     int
+	shuffle bool
+	repeat  RepeatMode
+	rng     *rand.Rand
+}
+
+// NewQueue creates an empty queue with shuffle and repeat off.
+func NewQueue() *Queue {
+	return &Queue{
+		pos: -1,
+		rng: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Len returns the number of queued items.
+func (q *Queue) Len() int {
+	return len(q.items)
+}
+
+// Items returns the queued items in listed order.
+func (q *Queue) Items() []QueueItem {
+	return q.items
+}
+
+// CurrentIndex returns the listed index of the current item, or -1 if playback hasn't reached the queue.
+func (q *Queue) CurrentIndex() int {
+	if q.pos < 0 || q.pos >= len(q.order) {
+		return -1
+	}
+	return q.order[q.pos]
+}
+
+// Current returns the current item, if any.
+func (q *Queue) Current() (QueueItem, bool) {
+	i := q.CurrentIndex()
+	if i < 0 {
+		return QueueItem{}, false
+	}
+	return q.items[i], true
+}
+
+// Add appends items to the list. While shuffling they are slotted in at random among the upcoming tracks.
+func (q *Queue) Add(items ...QueueItem) {
+	for _, item := range items {
+		idx := len(q.items)
+		q.items = append(q.items, item)
+		if !q.shuffle {
+			q.order = append(q.order, idx)
+			continue
+		}
+		at := q.pos + 1 + q.rng.Intn(len(q.order)-q.pos)
+		q.order = append(q.order, 0)
+		copy(q.order[at+1:], q.order[at:])
+		q.order[at] = idx
+	}
+}
+
+// Remove deletes the item at listed index i. Removing the current item makes the
+// following one next in line.
+func (q *Queue) Remove(i int) error {
+	if i < 0 || i >= len(q.items) {
+		return fmt.Errorf("no queue entry %d", i+1)
+	}
+	q.items = append(q.items[:i], q.items[i+1:]...)
+
+	k := q.orderPos(i)
+	q.order = append(q.order[:k], q.order[k+1:]...)
+	for j, idx := range q.order {
+		if idx > i {
+			q.order[j] = idx - 1
+		}
+	}
+	if k <= q.pos {
+		q.pos--
+	}
+	return nil
+}
+
+// Move moves the item at listed index from to index to, shifting the items in between.
+func (q *Queue) Move(from, to int) error {
+	if from < 0 || from >= len(q.items) {
+		return fmt.Errorf("no queue entry %d", from+1)
+	}
+	if to < 0 || to >= len(q.items) {
+		return fmt.Errorf("no queue entry %d", to+1)
+	}
+	if from == to {
+		return nil
+	}
+
+	// newIndex maps each listed index to where it ends up after the move.
+	newIndex := make([]int, len(q.items))
+	for i := range newIndex {
+		switch {
+		case i == from:
+			newIndex[i] = to
+		case from < to && i > from && i <= to:
+			newIndex[i] = i - 1
+		case to < from && i >= to && i < from:
+			newIndex[i] = i + 1
+		default:
+			newIndex[i] = i
+		}
+	}
+
+	moved := q.items[from]
+	q.items = append(q.items[:from], q.items[from+1:]...)
+	q.items = append(q.items[:to], append([]QueueItem{moved}, q.items[to:]...)...)
+
+	for j, idx := range q.order {
+		q.order[j] = newIndex[idx]
+	}
+	if !q.shuffle {
+		// Unshuffled, the play order is the listed order; keep the cursor on the same track.
+		cur := q.CurrentIndex()
+		q.resetOrder()
+		if cur >= 0 {
+			q.pos = cur
+		}
+	}
+	return nil
+}
+
+// Clear empties the queue.
+func (q *Queue) Clear() {
+	q.items = nil
+	q.order = nil
+	q.pos = -1
+}
+
+// Jump makes listed index i the current item.
+func (q *Queue) Jump(i int) (QueueItem, error) {
+	if i < 0 || i >= len(q.items) {
+		return QueueItem{}, fmt.Errorf("no queue entry %d", i+1)
+	}
+	q.pos = q.orderPos(i)
+	return q.items[i], nil
+}
+
+// Next advances to the following item. When auto is set (the previous track ended
+// by itself) repeat-one replays the current item; a manual skip always moves on.
+// It reports false when the end of the queue is reached and repeat is off.
+func (q *Queue) Next(auto bool) (QueueItem, bool) {
+	if len(q.order) == 0 {
+		return QueueItem{}, false
+	}
+	if auto && q.repeat == RepeatOne && q.CurrentIndex() >= 0 {
+		return q.Current()
+	}
+	switch {
+	case q.pos+1 < len(q.order):
+		q.pos++
+	case q.repeat == RepeatAll:
+		if q.shuffle {
+			q.rng.Shuffle(len(q.order), func(a, b int) { q.order[a], q.order[b] = q.order[b], q.order[a] })
+		}
+		q.pos = 0
+	default:
+		return QueueItem{}, false
+	}
+	return q.Current()
+}
+
+// Prev steps back to the previous item, wrapping around under repeat-all.
+func (q *Queue) Prev() (QueueItem, bool) {
+	if len(q.order) == 0 {
+		return QueueItem{}, false
+	}
+	switch {
+	case q.pos > 0:
+		q.pos--
+	case q.repeat == RepeatAll:
+		q.pos = len(q.order) - 1
+	default:
+		q.pos = 0
+	}
+	return q.Current()
+}
+
+// Shuffle reports whether shuffle is on.
+func (q *Queue) Shuffle() bool {
+	return q.shuffle
+}
+
+// SetShuffle turns shuffle on or off. The current item stays current either way;
+// turning it on randomizes everything else after it.
+func (q *Queue) SetShuffle(on bool) {
+	if on == q.shuffle {
+		return
+	}
+	cur := q.CurrentIndex()
+	q.shuffle = on
+	q.resetOrder()
+	if !on {
+		if cur >= 0 {
+			q.pos = cur
+		}
+		return
+	}
+
+	q.rng.Shuffle(len(q.order), func(a, b int) { q.order[a], q.order[b] = q.order[b], q.order[a] })
+	if cur >= 0 {
+		k := q.orderPos(cur)
+		q.order[0], q.order[k] = q.order[k], q.order[0]
+		q.pos = 0
+	}
+}
+
+// Repeat returns the repeat mode.
+func (q *Queue) Repeat() RepeatMode {
+	return q.repeat
+}
+
+// SetRepeat changes the repeat mode.
+func (q *Queue) SetRepeat(r RepeatMode) {
+	q.repeat = r
+}
+
+// Upcoming returns up to n items that will play after the current one, in play order.
+func (q *Queue) Upcoming(n int) []QueueItem {
+	var out []QueueItem
+	for k := q.pos + 1; k < len(q.order) && len(out) < n; k++ {
+		out = append(out, q.items[q.order[k]])
+	}
+	return out
+}
+
+// resetOrder makes the play order the listed order and rewinds the cursor.
+func (q *Queue) resetOrder() {
+	q.order = make([]int, len(q.items))
+	for i := range q.order {
+		q.order[i] = i
+	}
+	q.pos = -1
+}
+
+// orderPos returns where listed index i sits in the play order.
+func (q *Queue) orderPos(i int) int {
+	for k, idx := range q.order {
+		if idx == i {
+			return k
+		}
+	}
+	return -1
+}
@@ -0,0 +1,342 @@
+package commands
+
+import (
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"gowav/internal/audio"
+	"gowav/pkg/utils"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TrackLoadedMsg is sent once a track that should start playing when ready has finished loading.
+type TrackLoadedMsg struct {
+	seq int
+}
+
+func (c *Commander) handleQueue(args []string) (string, error, tea.Cmd) {
+	if len(args) == 0 {
+		return c.formatQueue(), nil, nil
+	}
+
+	sub, rest := strings.ToLower(args[0]), args[1:]
+	switch sub {
+	case "list", "ls":
+		return c.formatQueue(), nil, nil
+
+	case "add", "a":
+		if len(rest) == 0 {
+			return "", fmt.Errorf("usage: queue add <path|dir|url>"), nil
+		}
+		items, err := resolveQueueItems(strings.Trim(strings.Join(rest, " "), `"'`))
+		if err != nil {
+			return "", err, nil
+		}
+		c.queue.Add(items...)
+		if len(items) == 1 {
+			return fmt.Sprintf("Queued: %s (%d in queue)", items[0].Title, c.queue.Len()), nil, nil
+		}
+		return fmt.Sprintf("Queued %d tracks (%d in queue)", len(items), c.queue.Len()), nil, nil
+
+	case "remove", "rm":
+		if len(rest) != 1 {
+			return "", fmt.Errorf("usage: queue remove <n>"), nil
+		}
+		n, err := parseQueueIndex(rest[0])
+		if err != nil {
+			return "", err, nil
+		}
+		title := ""
+		if n < c.queue.Len() {
+			title = c.queue.Items()[n].Title
+		}
+		if err := c.queue.Remove(n); err != nil {
+			return "", err, nil
+		}
+		return fmt.Sprintf("Removed: %s", title), nil, nil
+
+	case "move", "mv":
+		if len(rest) != 2 {
+			return "", fmt.Errorf("usage: queue move <from> <to>"), nil
+		}
+		from, err := parseQueueIndex(rest[0])
+		if err != nil {
+			return "", err, nil
+		}
+		to, err := parseQueueIndex(rest[1])
+		if err != nil {
+			return "", err, nil
+		}
+		if err := c.queue.Move(from, to); err != nil {
+			return "", err, nil
+		}
+		return c.formatQueue(), nil, nil
+
+	case "play":
+		n := 0
+		if len(rest) > 0 {
+			var err error
+			if n, err = parseQueueIndex(rest[0]); err != nil {
+				return "", err, nil
+			}
+		}
+		item, err := c.queue.Jump(n)
+		if err != nil {
+			return "", err, nil
+		}
+		return c.playQueueItem(item)
+
+	case "clear":
+		c.queue.Clear()
+		return "Queue cleared", nil, nil
+
+	default:
+		return "", fmt.Errorf("unknown queue command: %s (add, list, remove, move, play, clear)", sub), nil
+	}
+}
+
+func (c *Commander) handleNext() (string, error, tea.Cmd) {
+	item, ok := c.queue.Next(false)
+	if !ok {
+		return "", fmt.Errorf("end of queue"), nil
+	}
+	return c.playQueueItem(item)
+}
+
+func (c *Commander) handlePrev() (string, error, tea.Cmd) {
+	item, ok := c.queue.Prev()
+	if !ok {
+		return "", fmt.Errorf("queue is empty"), nil
+	}
+	return c.playQueueItem(item)
+}
+
+func (c *Commander) handleShuffle(args []string) (string, error, tea.Cmd) {
+	on := !c.queue.Shuffle()
+	if len(args) > 0 {
+		switch strings.ToLower(args[0]) {
+		case "on":
+			on = true
+		case "off":
+			on = false
+		default:
+			return "", fmt.Errorf("usage: shuffle on|off"), nil
+		}
+	}
+	c.queue.SetShuffle(on)
+	if on {
+		return "Shuffle on", nil, nil
+	}
+	return "Shuffle off", nil, nil
+}
+
+func (c *Commander) handleRepeat(args []string) (string, error, tea.Cmd) {
+	if len(args) == 0 {
+		return fmt.Sprintf("Repeat: %s", c.queue.Repeat()), nil, nil
+	}
+	switch strings.ToLower(args[0]) {
+	case "off":
+		c.queue.SetRepeat(RepeatOff)
+	case "one":
+		c.queue.SetRepeat(RepeatOne)
+	case "all":
+		c.queue.SetRepeat(RepeatAll)
+	default:
+		return "", fmt.Errorf("usage: repeat off|one|all"), nil
+	}
+	return fmt.Sprintf("Repeat: %s", c.queue.Repeat()), nil, nil
+}
+
+// playQueueItem stops the current track, loads item and plays it once loading completes.
+func (c *Commander) playQueueItem(item QueueItem) (string, error, tea.Cmd) {
+	c.leaveEpisode()
+	c.player.Stop()
+	if err := c.loadTrack(item.Path, item.Track); err != nil {
+		return "", err, nil
+	}
+	c.mode = ModeTrack
+	c.fromQueue = true
+	return fmt.Sprintf("Loading %s...", item.Title), nil, c.playWhenLoaded()
+}
+
+// playWhenLoaded waits for the processor's current load to finish and reports it with a TrackLoadedMsg.
+func (c *Commander) playWhenLoaded() tea.Cmd {
+	c.playOnLoad = true
+	c.loadSeq++
+	proc, seq := c.processor, c.loadSeq
+	// Subscribe before checking the status, so the end of the load can't slip in between.
+	events, unsubscribe := proc.Subscribe()
+	return func() tea.Msg {
+		defer unsubscribe()
+		for proc.GetStatus().State == audio.StateLoading {
+			if _, ok := <-events; !ok {
+				break
+			}
+		}
+		return TrackLoadedMsg{seq: seq}
+	}
+}
+
+// HandleTrackLoaded starts playback once a track loaded by play, next or the queue is ready.
+// A failed queue load skips ahead to the next entry, giving up once every entry has failed in
+// a row; loads superseded by a newer one are ignored.
+func (c *Commander) HandleTrackLoaded(msg TrackLoadedMsg) (string, error, tea.Cmd) {
+	if msg.seq != c.loadSeq || !c.playOnLoad {
+		return "", nil, nil
+	}
+	c.playOnLoad = false
+	if c.processor.GetMetadata() == nil {
+		failed := c.processor.GetStatus().Message
+		if !c.fromQueue {
+			return "", fmt.Errorf("%s", failed), nil
+		}
+		c.queueFailures++
+		if c.queueFailures >= c.queue.Len() {
+			c.queueFailures = 0
+			return "", fmt.Errorf("%s\nStopped: none of the %d queue entries would load", failed, c.queue.Len()), nil
+		}
+		item, ok := c.queue.Next(false)
+		if !ok {
+			return "", fmt.Errorf("%s", failed), nil
+		}
+		out, err, cmd := c.playQueueItem(item)
+		if err != nil {
+			return "", err, nil
+		}
+		return failed + "\n" + out, nil, cmd
+	}
+	c.queueFailures = 0
+	out, err, cmd := c.handlePlay()
+	if err == nil && c.resumeAt > 0 {
+		if c.player.Seek(c.resumeAt) == nil {
+			out = fmt.Sprintf("Resuming at %s", FormatDuration(c.resumeAt))
+		}
+	}
+	c.resumeAt = 0
+	return out, err, cmd
+}
+
+// advanceQueue moves to the next queued track after one finished playing.
+func (c *Commander) advanceQueue() (string, tea.Cmd) {
+	if !c.fromQueue {
+		return "Playback finished", nil
+	}
+	item, ok := c.queue.Next(true)
+	if !ok {
+		return "Reached the end of the queue", nil
+	}
+	out, err, cmd := c.playQueueItem(item)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	return out, cmd
+}
+
+// QueueSummary is a one-line queue status for the playback views, or "" when the queue is empty.
+func (c *Commander) QueueSummary() string {
+	if c.queue.Len() == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	if i := c.queue.CurrentIndex(); i >= 0 {
+		sb.WriteString(fmt.Sprintf("Queue %d/%d", i+1, c.queue.Len()))
+	} else {
+		sb.WriteString(fmt.Sprintf("Queue: %d tracks", c.queue.Len()))
+	}
+	if next := c.queue.Upcoming(1); len(next) > 0 {
+		sb.WriteString(" · Next: " + next[0].Title)
+	}
+	if c.queue.Shuffle() {
+		sb.WriteString(" · shuffle")
+	}
+	if r := c.queue.Repeat(); r != RepeatOff {
+		sb.WriteString(" · repeat " + r.String())
+	}
+	return sb.String()
+}
+
+func (c *Commander) formatQueue() string {
+	if c.queue.Len() == 0 {
+		return "Queue is empty. Add tracks with: queue add <path|dir|url>"
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Queue (%d tracks, shuffle %s, repeat %s):\n\n",
+		c.queue.Len(), onOff(c.queue.Shuffle()), c.queue.Repeat()))
+	current := c.queue.CurrentIndex()
+	for i, item := range c.queue.Items() {
+		marker := "  "
+		if i == current {
+			marker = "▶ "
+		}
+		sb.WriteString(fmt.Sprintf("%s%2d. %s\n", marker, i+1, item.Title))
+	}
+	return sb.String()
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+// parseQueueIndex converts a 1-based queue position typed by the user into an index.
+func parseQueueIndex(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid queue position: %s", s)
+	}
+	return n - 1, nil
+}
+
+// resolveQueueItems expands a queue argument: URLs and files become one item,
+// directories contribute every music file beneath them in path order.
+func resolveQueueItems(target string) ([]QueueItem, error) {
+	if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+		title := target
+		if u, err := url.Parse(target); err == nil && path.Base(u.Path) != "/" && path.Base(u.Path) != "." {
+			title = path.Base(u.Path)
+		}
+		return []QueueItem{{Path: target, Title: title}}, nil
+	}
+
+	target = filepath.Clean(expandHome(target))
+
+	info, err := os.Stat(target)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []QueueItem{{Path: target, Title: filepath.Base(target)}}, nil
+	}
+
+	var paths []string
+	err = filepath.WalkDir(target, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // skip unreadable entries
+		}
+		if !d.IsDir() && utils.IsMusicFile(p) {
+			paths = append(paths, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no music files found in %s", target)
+	}
+	sort.Strings(paths)
+
+	items := make([]QueueItem, len(paths))
+	for i, p := range paths {
+		items[i] = QueueItem{Path: p, Title: filepath.Base(p)}
+	}
+	return items, nil
+}
@@ -0,0 +1,285 @@
+package commands
+
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// testQueue returns a queue of n items titled "0", "1", ... with a fixed shuffle seed.
+func testQueue(n int) *Queue {
+	q := NewQueue()
+	q.rng = rand.New(rand.NewSource(1))
+	for i := 0; i < n; i++ {
+		q.Add(QueueItem{Path: "/music/" + strconv.Itoa(i) + ".mp3", Title: strconv.Itoa(i)})
+	}
+	return q
+}
+
+// titles lists the titles of the queued items in listed order.
+func titles(q *Queue) []string {
+	var out []string
+	for _, item := range q.Items() {
+		out = append(out, item.Title)
+	}
+	return out
+}
+
+// current returns the title of the current item, or "" if there is none.
+func current(q *Queue) string {
+	item, _ := q.Current()
+	return item.Title
+}
+
+// playOrder returns the titles from the current item to the end of the play order.
+func playOrder(q *Queue) []string {
+	out := []string{current(q)}
+	for _, item := range q.Upcoming(q.Len()) {
+		out = append(out, item.Title)
+	}
+	return out
+}
+
+func TestQueueRemove(t *testing.T) {
+	tests := []struct {
+		name       string
+		remove     int
+		wantTitles []string
+		wantCur    string
+		wantNext   string
+	}{
+		{name: "before current", remove: 0, wantTitles: []string{"1", "2", "3"}, wantCur: "2", wantNext: "3"},
+		// The removed item's predecessor becomes current, so the next step lands on its successor.
+		{name: "current", remove: 2, wantTitles: []string{"0", "1", "3"}, wantCur: "1", wantNext: "3"},
+		{name: "after current", remove: 3, wantTitles: []string{"0", "1", "2"}, wantCur: "2", wantNext: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := testQueue(4)
+			if _, err := q.Jump(2); err != nil {
+				t.Fatal(err)
+			}
+			if err := q.Remove(tt.remove); err != nil {
+				t.Fatal(err)
+			}
+			if got := titles(q); !reflect.DeepEqual(got, tt.wantTitles) {
+				t.Errorf("items = %v, want %v", got, tt.wantTitles)
+			}
+			if got := current(q); got != tt.wantCur {
+				t.Errorf("current = %q, want %q", got, tt.wantCur)
+			}
+			next, _ := q.Next(false)
+			if next.Title != tt.wantNext {
+				t.Errorf("next = %q, want %q", next.Title, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestQueueRemoveShuffled(t *testing.T) {
+	q := testQueue(6)
+	if _, err := q.Jump(3); err != nil {
+		t.Fatal(err)
+	}
+	q.SetShuffle(true)
+	if _, ok := q.Next(false); !ok {
+		t.Fatal("no next item")
+	}
+	order := playOrder(q)
+	cur, last := order[0], order[len(order)-1]
+
+	// Remove the item already played (3, first in the shuffled order) and the last upcoming one;
+	// neither may move the cursor or reorder what's left.
+	for _, title := range []string{"3", last} {
+		i := indexOf(q, title)
+		if err := q.Remove(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := current(q); got != cur {
+		t.Errorf("current = %q, want %q", got, cur)
+	}
+	if got, want := playOrder(q), order[:len(order)-1]; !reflect.DeepEqual(got, want) {
+		t.Errorf("play order = %v, want %v", got, want)
+	}
+	if _, ok := q.Prev(); !ok || current(q) != cur {
+		t.Errorf("Prev moved off the first remaining item to %q", current(q))
+	}
+}
+
+// indexOf returns the listed index of the item titled title.
+func indexOf(q *Queue, title string) int {
+	for i, item := range q.Items() {
+		if item.Title == title {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestQueueRemoveOutOfRange(t *testing.T) {
+	q := testQueue(2)
+	for _, i := range []int{-1, 2} {
+		if err := q.Remove(i); err == nil {
+			t.Errorf("Remove(%d) succeeded", i)
+		}
+	}
+	if q.Len() != 2 {
+		t.Errorf("Len = %d after failed removes, want 2", q.Len())
+	}
+}
+
+func TestQueueMoveKeepsCurrent(t *testing.T) {
+	tests := []struct {
+		name       string
+		shuffle    bool
+		from, to   int
+		wantTitles []string
+	}{
+		{name: "current forward", from: 1, to: 3, wantTitles: []string{"0", "2", "3", "1", "4"}},
+		{name: "current backward", from: 1, to: 0, wantTitles: []string{"1", "0", "2", "3", "4"}},
+		{name: "other across current", from: 4, to: 0, wantTitles: []string{"4", "0", "1", "2", "3"}},
+		{name: "shuffled current", shuffle: true, from: 1, to: 4, wantTitles: []string{"0", "2", "3", "4", "1"}},
+		{name: "shuffled other", shuffle: true, from: 0, to: 3, wantTitles: []string{"1", "2", "3", "0", "4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := testQueue(5)
+			if _, err := q.Jump(1); err != nil {
+				t.Fatal(err)
+			}
+			q.SetShuffle(tt.shuffle)
+			before := playOrder(q)
+
+			if err := q.Move(tt.from, tt.to); err != nil {
+				t.Fatal(err)
+			}
+			if got := titles(q); !reflect.DeepEqual(got, tt.wantTitles) {
+				t.Errorf("items = %v, want %v", got, tt.wantTitles)
+			}
+			if got := current(q); got != "1" {
+				t.Errorf("current = %q, want %q", got, "1")
+			}
+			if !tt.shuffle {
+				// Unshuffled, play continues in the new listed order after the current item.
+				cur := q.CurrentIndex()
+				if got, want := playOrder(q), tt.wantTitles[cur:]; !reflect.DeepEqual(got, want) {
+					t.Errorf("play order = %v, want %v", got, want)
+				}
+				return
+			}
+			// Shuffled, moving an item in the list leaves the play order alone.
+			if got := playOrder(q); !reflect.DeepEqual(got, before) {
+				t.Errorf("play order = %v, want %v", got, before)
+			}
+		})
+	}
+}
+
+func TestQueueSetShuffleKeepsCurrentFirst(t *testing.T) {
+	for seed := int64(0); seed < 20; seed++ {
+		q := testQueue(8)
+		q.rng = rand.New(rand.NewSource(seed))
+		if _, err := q.Jump(5); err != nil {
+			t.Fatal(err)
+		}
+
+		q.SetShuffle(true)
+		order := playOrder(q)
+		if order[0] != "5" || len(order) != 8 {
+			t.Fatalf("seed %d: shuffled play order = %v, want all 8 items starting with 5", seed, order)
+		}
+		if _, ok := q.Prev(); !ok || current(q) != "5" {
+			t.Errorf("seed %d: the current item isn't first in the shuffled order", seed)
+		}
+
+		q.SetShuffle(false)
+		if got := current(q); got != "5" {
+			t.Errorf("seed %d: current after unshuffling = %q, want 5", seed, got)
+		}
+		if got, want := playOrder(q), []string{"5", "6", "7"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("seed %d: unshuffled play order = %v, want %v", seed, got, want)
+		}
+	}
+}
+
+func TestQueueNext(t *testing.T) {
+	tests := []struct {
+		name   string
+		repeat RepeatMode
+		auto   bool
+		want   []string // titles returned by successive calls; "" where Next reports false
+	}{
+		{name: "off", repeat: RepeatOff, auto: true, want: []string{"0", "1", "2", ""}},
+		{name: "one auto", repeat: RepeatOne, auto: true, want: []string{"0", "0", "0"}},
+		{name: "one manual", repeat: RepeatOne, auto: false, want: []string{"0", "1", "2", ""}},
+		{name: "all auto", repeat: RepeatAll, auto: true, want: []string{"0", "1", "2", "0", "1"}},
+		{name: "all manual", repeat: RepeatAll, auto: false, want: []string{"0", "1", "2", "0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := testQueue(3)
+			q.SetRepeat(tt.repeat)
+			var got []string
+			for range tt.want {
+				item, ok := q.Next(tt.auto)
+				if !ok {
+					item.Title = ""
+				}
+				got = append(got, item.Title)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Next(%v) gave %v, want %v", tt.auto, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueueNextRepeatAllShuffledPlaysEveryItem(t *testing.T) {
+	q := testQueue(4)
+	q.SetShuffle(true)
+	q.SetRepeat(RepeatAll)
+	for round := 0; round < 3; round++ {
+		seen := map[string]bool{}
+		for i := 0; i < 4; i++ {
+			item, ok := q.Next(true)
+			if !ok {
+				t.Fatalf("round %d: Next reported the end under repeat-all", round)
+			}
+			seen[item.Title] = true
+		}
+		if len(seen) != 4 {
+			t.Errorf("round %d played %v, want every item once", round, seen)
+		}
+	}
+}
+
+func TestQueuePrevAtStart(t *testing.T) {
+	tests := []struct {
+		name   string
+		repeat RepeatMode
+		want   string
+	}{
+		{name: "off stays", repeat: RepeatOff, want: "0"},
+		{name: "one stays", repeat: RepeatOne, want: "0"},
+		{name: "all wraps", repeat: RepeatAll, want: "2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := testQueue(3)
+			q.SetRepeat(tt.repeat)
+			if _, err := q.Jump(0); err != nil {
+				t.Fatal(err)
+			}
+			item, ok := q.Prev()
+			if !ok || item.Title != tt.want {
+				t.Errorf("Prev() = %q, %v; want %q, true", item.Title, ok, tt.want)
+			}
+		})
+	}
+
+	if _, ok := NewQueue().Prev(); ok {
+		t.Error("Prev on an empty queue reported an item")
+	}
+}
@@ -0,0 +1,109 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"gowav/internal/audio"
+	"gowav/internal/config"
+	"strconv"
+	"strings"
+)
+
+// handleRadio lists the stations kept in the config file, tunes in to one, and adds or
+// removes stations.
+func (c *Commander) handleRadio(args []string) (string, error, tea.Cmd) {
+	if len(args) == 0 || strings.EqualFold(args[0], "list") {
+		return c.listStations(), nil, nil
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "add":
+		if len(args) < 3 {
+			return "", fmt.Errorf("usage: radio add <name> <url>"), nil
+		}
+		// The name may be several words; the URL is the last one.
+		name := strings.Trim(strings.Join(args[1:len(args)-1], " "), `"'`)
+		url := strings.Trim(args[len(args)-1], `"'`)
+		return c.updateStations(func(path string) error {
+			return config.Update(path, config.StationKey(name), url)
+		}, fmt.Sprintf("Added station %s", name))
+
+	case "remove", "rm":
+		if len(args) < 2 {
+			return "", fmt.Errorf("usage: radio remove <name>"), nil
+		}
+		name, _, err := c.findStation(strings.Join(args[1:], " "))
+		if err != nil {
+			return "", err, nil
+		}
+		return c.updateStations(func(path string) error {
+			return config.Remove(path, config.StationKey(name))
+		}, fmt.Sprintf("Removed station %s", name))
+	}
+
+	name, url, err := c.findStation(strings.Join(args, " "))
+	if err != nil {
+		return "", err, nil
+	}
+	c.leaveEpisode()
+	c.player.Stop()
+	if err := c.processor.LoadFileWithHint(context.Background(), url, &audio.Metadata{Station: name}); err != nil {
+		return "", err, nil
+	}
+	c.currentTrack = nil
+	c.mode = ModeTrack
+	c.fromQueue = false
+	return fmt.Sprintf("Tuning in to %s...", name), nil, c.playWhenLoaded()
+}
+
+// findStation looks a station up by name or by its number in the station list.
+func (c *Commander) findStation(ref string) (name, url string, err error) {
+	ref = strings.Trim(ref, `"'`)
+	names := c.config.StationNames()
+	if n, err := strconv.Atoi(ref); err == nil {
+		if n < 1 || n > len(names) {
+			return "", "", fmt.Errorf("no station %d (there are %d)", n, len(names))
+		}
+		return names[n-1], c.config.Stations[names[n-1]], nil
+	}
+	for _, name := range names {
+		if strings.EqualFold(name, ref) {
+			return name, c.config.Stations[name], nil
+		}
+	}
+	return "", "", fmt.Errorf("no station named %s (see 'radio' for the list)", ref)
+}
+
+func (c *Commander) listStations() string {
+	names := c.config.StationNames()
+	if len(names) == 0 {
+		return "No stations yet. Add one with 'radio add <name> <url>', or load a stream URL directly."
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Stations (%d):\n", len(names)))
+	for i, name := range names {
+		sb.WriteString(fmt.Sprintf("%3d. %s  %s\n", i+1, name, c.config.Stations[name]))
+	}
+	sb.WriteString("\nTune in with 'radio <n|name>'.")
+	return sb.String()
+}
+
+// updateStations changes the config file with edit and reloads it.
+func (c *Commander) updateStations(edit func(path string) error, done string) (string, error, tea.Cmd) {
+	path, err := config.Path()
+	if err != nil {
+		return "", fmt.Errorf("cannot locate config file: %w", err), nil
+	}
+	if err := edit(path); err != nil {
+		return "", err, nil
+	}
+	warn, err := c.reloadConfig(path)
+	if err != nil {
+		return "", fmt.Errorf("saved the station, but the config no longer loads: %w", err), nil
+	}
+	if warn != nil {
+		done += fmt.Sprintf("\nWarning: %v", warn)
+	}
+	return done, nil, nil
+}
@@ -1,5 +1,14 @@
 package commands
 
+import (
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
 func (c *Commander) GetSearchResults() []SearchResult {
 	return c.searchResults
 }
@@ -7,3 +16,148 @@ func (c *Commander) GetSearchResults() []SearchResult {
 func (c *Commander) ClearSearchResults() {
 	c.searchResults = nil
 }
+
+// SearchNotice explains anything missing from the last search, such as the remote being unreachable.
+func (c *Commander) SearchNotice() string {
+	return c.searchNotice
+}
+
+// Label is the "Artist - Title" form used for queue entries and result lists.
+func (r SearchResult) Label() string {
+	if r.Artist == "" || r.Artist == "Unknown" {
+		return r.Title
+	}
+	return r.Artist + " - " + r.Title
+}
+
+// track converts the result into the Track shown while it loads.
+func (r SearchResult) track() *Track {
+	return &Track{Title: r.Title, Artist: r.Artist, Album: r.Album, Duration: r.Duration}
+}
+
+// queueItem converts the result into a queue entry that remembers its tags.
+func (r SearchResult) queueItem() QueueItem {
+	return QueueItem{
+		Path:     r.URL,
+		Title:    r.Label(),
+		Duration: time.Duration(r.Duration) * time.Second,
+		Track:    r.track(),
+	}
+}
+
+// isResultRef reports whether args is a single result number such as "3", as opposed to
+// a file that happens to be named with digits.
+func (c *Commander) isResultRef(args []string) bool {
+	if len(args) != 1 || len(c.searchResults) == 0 {
+		return false
+	}
+	if _, err := strconv.Atoi(args[0]); err != nil {
+		return false
+	}
+	_, err := os.Stat(args[0])
+	return err != nil
+}
+
+// result returns search result n, counted from 1 as printed.
+func (c *Commander) result(arg string) (SearchResult, error) {
+	if len(c.searchResults) == 0 {
+		return SearchResult{}, fmt.Errorf("no search results (use 'search <query>' first)")
+	}
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 1 || n > len(c.searchResults) {
+		return SearchResult{}, fmt.Errorf("invalid result number: %s (1-%d)", arg, len(c.searchResults))
+	}
+	return c.searchResults[n-1], nil
+}
+
+// handleLoadResult loads search result n without playing it.
+func (c *Commander) handleLoadResult(arg string) (string, error, tea.Cmd) {
+	r, err := c.result(arg)
+	if err != nil {
+		return "", err, nil
+	}
+	if err := c.loadTrack(r.URL, r.track()); err != nil {
+		return "", err, nil
+	}
+	c.mode = ModeTrack
+	c.fromQueue = false
+	c.playOnLoad = false
+	return fmt.Sprintf("Started loading: %s\nPress Ctrl+C to cancel...", r.Label()), nil, nil
+}
+
+// handlePlayResult loads search result n and starts playback once it is ready.
+func (c *Commander) handlePlayResult(arg string) (string, error, tea.Cmd) {
+	r, err := c.result(arg)
+	if err != nil {
+		return "", err, nil
+	}
+	c.leaveEpisode()
+	c.player.Stop()
+	if err := c.loadTrack(r.URL, r.track()); err != nil {
+		return "", err, nil
+	}
+	c.mode = ModeTrack
+	c.fromQueue = false
+	return fmt.Sprintf("Loading %s...", r.Label()), nil, c.playWhenLoaded()
+}
+
+// handleEnqueue adds search results to the queue: "enqueue 3", "enqueue 1-5", "enqueue 1,4,7" or "enqueue all".
+func (c *Commander) handleEnqueue(args []string) (string, error, tea.Cmd) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("usage: enqueue <n> | <from-to> | <n,n,...> | all"), nil
+	}
+	if len(c.searchResults) == 0 {
+		return "", fmt.Errorf("no search results (use 'search <query>' first)"), nil
+	}
+	picks, err := parseResultRange(strings.Join(args, ""), len(c.searchResults))
+	if err != nil {
+		return "", err, nil
+	}
+
+	items := make([]QueueItem, len(picks))
+	for i, n := range picks {
+		items[i] = c.searchResults[n].queueItem()
+	}
+	c.queue.Add(items...)
+	if len(items) == 1 {
+		return fmt.Sprintf("Queued: %s (%d in queue)", items[0].Title, c.queue.Len()), nil, nil
+	}
+	return fmt.Sprintf("Queued %d tracks (%d in queue)", len(items), c.queue.Len()), nil, nil
+}
+
+// parseResultRange turns "2", "1-5", "1,3,6-8" or "all" into 0-based indexes below count, in the order given.
+func parseResultRange(spec string, count int) ([]int, error) {
+	var out []int
+	if strings.EqualFold(spec, "all") {
+		for i := 0; i < count; i++ {
+			out = append(out, i)
+		}
+		return out, nil
+	}
+	for _, part := range strings.Split(spec, ",") {
+		if part == "" {
+			continue
+		}
+		lo, hi, isRange := strings.Cut(part, "-")
+		from, err := strconv.Atoi(lo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid result number: %s", part)
+		}
+		to := from
+		if isRange {
+			if to, err = strconv.Atoi(hi); err != nil {
+				return nil, fmt.Errorf("invalid result range: %s", part)
+			}
+		}
+		if from < 1 || to > count || from > to {
+			return nil, fmt.Errorf("result range %s is outside 1-%d", part, count)
+		}
+		for n := from; n <= to; n++ {
+			out = append(out, n-1)
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no results selected")
+	}
+	return out, nil
+}
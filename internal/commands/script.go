@@ -0,0 +1,175 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"gowav/internal/audio"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrScriptStopped is returned when a script is stopped from outside, e.g. by Ctrl+C.
+var ErrScriptStopped = errors.New("stopped")
+
+// endGrace is how long a script waits for the end of a track to be reported once the player stops.
+const endGrace = 50 * time.Millisecond
+
+// Script runs command lines without the UI. Each line waits for the loading and analysis
+// it starts to finish, so the next line sees its result. Messages that the UI would act on,
+// such as a queued track ending, are handled here instead; purely visual ones are dropped.
+type Script struct {
+	c    *Commander
+	out  io.Writer
+	msgs chan tea.Msg
+	// stop aborts waiting when closed.
+	stop <-chan struct{}
+}
+
+// NewScript creates a Script with its own Commander. Command output goes to out.
+func NewScript(out io.Writer, stop <-chan struct{}) *Script {
+	s := &Script{
+		c:    NewCommander(),
+		out:  out,
+		msgs: make(chan tea.Msg, 16),
+		stop: stop,
+	}
+	s.start(s.c.WaitProcessorEvent())
+	return s
+}
+
+// Run executes one command line and waits for it to settle. It reports whether the
+// script should end because the line was quit, which works in any mode here.
+func (s *Script) Run(line string) (bool, error) {
+	if fields := strings.Fields(line); len(fields) > 0 {
+		switch strings.ToLower(fields[0]) {
+		case "quit", "q", "exit":
+			return true, nil
+		}
+	}
+	out, err, cmd := s.c.Execute(line)
+	if err != nil {
+		return false, err
+	}
+	s.print(out)
+	s.start(cmd)
+	return false, s.settle()
+}
+
+// Finish waits for playback started by the script to end, including any queued tracks.
+func (s *Script) Finish() error {
+	for {
+		if s.c.player.GetState() == audio.StatePlaying || s.busy() {
+			if err := s.wait(); err != nil {
+				return err
+			}
+			continue
+		}
+		// The end of a track is reported just after the player stops, and may start the next one.
+		select {
+		case <-s.stop:
+			return ErrScriptStopped
+		case msg := <-s.msgs:
+			if err := s.handle(msg); err != nil {
+				return err
+			}
+		case <-time.After(endGrace):
+			return nil
+		}
+	}
+}
+
+// Close stops playback and any background work, and saves what the Commander writes lazily.
+func (s *Script) Close() error {
+	s.c.leaveEpisode()
+	s.c.processor.Close()
+	s.c.player.Stop()
+	return s.c.Close()
+}
+
+// start runs cmd in the background; its message is handled by the next wait.
+func (s *Script) start(cmd tea.Cmd) {
+	if cmd == nil {
+		return
+	}
+	go func() {
+		if msg := cmd(); msg != nil {
+			s.msgs <- msg
+		}
+	}()
+}
+
+// busy reports whether a load, analysis, play-on-load, library scan, feed fetch or download
+// is still running.
+func (s *Script) busy() bool {
+	state := s.c.processor.GetStatus().State
+	return state == audio.StateLoading || state == audio.StateAnalyzing || s.c.playOnLoad ||
+		s.c.scanning.Load() || s.c.fetchingFeeds.Load() || s.c.downloads.Load() > 0
+}
+
+// settle waits until the background work started so far is done, then reports whether
+// the processor failed along the way.
+func (s *Script) settle() error {
+	for s.busy() {
+		if err := s.wait(); err != nil {
+			return err
+		}
+	}
+	return s.c.processor.GetStatus().Err
+}
+
+// wait handles the next message. Every change busy looks at comes with one: a processor
+// event, a loaded track, a finished scan, fetch or download, or the end of a track.
+func (s *Script) wait() error {
+	select {
+	case <-s.stop:
+		return ErrScriptStopped
+	case msg := <-s.msgs:
+		return s.handle(msg)
+	}
+}
+
+func (s *Script) handle(msg tea.Msg) error {
+	switch msg := msg.(type) {
+	case ProcessorEventMsg:
+		s.start(s.c.WaitProcessorEvent())
+	case tea.BatchMsg:
+		for _, cmd := range msg {
+			s.start(cmd)
+		}
+	case TrackLoadedMsg:
+		out, err, cmd := s.c.HandleTrackLoaded(msg)
+		if err != nil {
+			return err
+		}
+		s.print(out)
+		s.start(cmd)
+	case TrackEndedMsg:
+		out, cmd := s.c.HandleTrackEnded()
+		s.print(out)
+		s.start(cmd)
+	case LibraryScanDoneMsg:
+		if msg.Err != nil {
+			return fmt.Errorf("library scan failed: %w", msg.Err)
+		}
+		s.print(msg.Result.String())
+	case PodcastFetchDoneMsg:
+		if msg.Err != nil {
+			return msg.Err
+		}
+		s.print(msg.Summary)
+	case DownloadDoneMsg:
+		if msg.Err != nil {
+			return msg.Err
+		}
+		s.print(msg.Summary)
+	}
+	return nil
+}
+
+func (s *Script) print(out string) {
+	if out = strings.TrimRight(out, "\n"); out != "" {
+		fmt.Fprintln(s.out, out)
+	}
+}
@@ -1,35 +1,218 @@
 package commands
 
 import (
+	"context"
 	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"gowav/pkg/api"
+	"gowav/pkg/utils"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
-func (c *Commander) handleSearch(query string) (string, error) {
-	results, err := c.apiClient.SearchSong(query)
-	if err != nil {
-		return "", fmt.Errorf("search failed: %w", err)
+// searchTimeout bounds a whole remote search request.
+const searchTimeout = 15 * time.Second
+
+// handleSearchCommand parses "search [--page N] <query>".
+func (c *Commander) handleSearchCommand(args []string) (string, error) {
+	page := 1
+	var words []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--page" || arg == "-p":
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("usage: search [--page N] <query>")
+			}
+			i++
+			arg = "--page=" + args[i]
+			fallthrough
+		case strings.HasPrefix(arg, "--page="):
+			n, err := strconv.Atoi(strings.TrimPrefix(arg, "--page="))
+			if err != nil || n < 1 {
+				return "", fmt.Errorf("invalid page: %s", strings.TrimPrefix(arg, "--page="))
+			}
+			page = n
+		default:
+			words = append(words, arg)
+		}
+	}
+	if len(words) == 0 {
+		return "", fmt.Errorf("usage: search [--page N] <query>")
+	}
+	return c.handleSearch(strings.Join(words, " "), page)
+}
+
+// handleSearch queries the local library and the remote API, then merges both result
+// sets ranked by how closely title, artist and album match the query. If the remote
+// is unreachable the library results are still shown. Library results only appear on
+// the first page; later pages are remote only.
+func (c *Commander) handleSearch(query string, page int) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), searchTimeout)
+	defer cancel()
+
+	c.searchNotice = ""
+	var local []SearchResult
+	if page == 1 {
+		local = c.searchLibrary(query)
+	}
+
+	remote, remoteErr := c.apiClient.SearchSongPage(ctx, query, page)
+	if remoteErr != nil && len(local) == 0 {
+		if api.IsNotFound(remoteErr) && page > 1 {
+			return "", fmt.Errorf("no page %d for %q", page, query)
+		}
+		return "", fmt.Errorf("search failed: %w", remoteErr)
 	}
+	c.searchQuery = query
+	c.searchPage = remote
 
-	if len(results) == 0 {
+	var songs []api.Song
+	if remote != nil {
+		songs = remote.Songs
+	}
+	c.setSearchResults(query, local, songs)
+	if len(c.searchResults) == 0 {
 		return "No results found.", nil
 	}
 
-	c.searchResults = make([]SearchResult, len(results))
-	for i, song := range results {
-		artist := "Unknown"
-		if len(song.Authors) > 0 {
-			artist = song.Authors[0].Name
+	out := c.formatSearchResults()
+	if remoteErr != nil {
+		c.searchNotice = fmt.Sprintf("Remote search unavailable (%v); showing library results only.", remoteErr)
+		out = c.searchNotice + "\n\n" + out
+	}
+	return out, nil
+}
+
+// handleMore fetches the next page of remote results for the last search.
+func (c *Commander) handleMore() (string, error, tea.Cmd) {
+	if c.searchPage == nil {
+		return "", fmt.Errorf("no search to continue (use 'search <query>' first)"), nil
+	}
+	if !c.searchPage.HasNext() {
+		return "", fmt.Errorf("no more results for %q", c.searchQuery), nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), searchTimeout)
+	defer cancel()
+	next, err := c.apiClient.NextPage(ctx, c.searchPage)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch more results: %w", err), nil
+	}
+	c.searchPage = next
+	c.searchNotice = ""
+	c.setSearchResults(c.searchQuery, nil, next.Songs)
+	if len(c.searchResults) == 0 {
+		return "No more results.", nil, nil
+	}
+	return c.formatSearchResults(), nil, nil
+}
+
+// setSearchResults ranks local and remote hits together and makes them the current results.
+func (c *Commander) setSearchResults(query string, local []SearchResult, remote []api.Song) {
+	type ranked struct {
+		SearchResult
+		score int
+	}
+	var merged []ranked
+	for _, r := range local {
+		merged = append(merged, ranked{r, matchScore(query, r)})
+	}
+	for _, song := range remote {
+		r := songResult(song)
+		// The server may match on fields we don't see, so unmatched remote results are kept, ranked last.
+		merged = append(merged, ranked{r, matchScore(query, r)})
+	}
+
+	// Stable, so equal scores keep library results first and the API's own order after.
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].score > merged[j].score })
+	c.searchResults = make([]SearchResult, len(merged))
+	for i, r := range merged {
+		c.searchResults[i] = r.SearchResult
+	}
+}
+
+// songResult converts a song from the API into a search result.
+func songResult(song api.Song) SearchResult {
+	r := SearchResult{
+		Title:     song.Name,
+		Artist:    "Unknown",
+		Album:     song.Album.Name,
+		Duration:  song.Length,
+		URL:       song.File,
+		Source:    SourceRemote,
+		AlbumSlug: song.Album.Slug,
+	}
+	if len(song.Authors) > 0 {
+		r.Artist = song.Authors[0].Name
+		r.ArtistSlug = song.Authors[0].Slug
+	}
+	return r
+}
+
+// SearchPageInfo describes which page of remote results is shown, or "" if there was no remote page.
+func (c *Commander) SearchPageInfo() string {
+	if c.searchPage == nil {
+		return ""
+	}
+	info := fmt.Sprintf("Page %d of remote results (%d matches in total).", c.searchPage.Page, c.searchPage.Count)
+	if c.searchPage.HasNext() {
+		info += " Type 'more' for the next page."
+	}
+	return info
+}
+
+// searchLibrary returns the indexed tracks that match every word of the query.
+func (c *Commander) searchLibrary(query string) []SearchResult {
+	lib, err := c.openLibrary()
+	if err != nil || lib.Len() == 0 {
+		return nil
+	}
+
+	var results []SearchResult
+	for _, t := range lib.All() {
+		r := SearchResult{
+			Title:    t.DisplayTitle(),
+			Artist:   t.ArtistName(),
+			Album:    t.AlbumName(),
+			Duration: int(t.Duration.Seconds()),
+			URL:      t.Path,
+			Source:   SourceLocal,
 		}
-		c.searchResults[i] = SearchResult{
-			Title:    song.Name,
-			Artist:   artist,
-			Album:    song.Album.Name,
-			Duration: song.Length,
-			URL:      song.File,
+		if matchScore(query, r) > 0 {
+			results = append(results, r)
 		}
 	}
+	return results
+}
+
+// matchScore fuzzy-matches each query word against the title, artist and album, weighting
+// title highest. Every word has to match somewhere, otherwise the score is 0.
+func matchScore(query string, r SearchResult) int {
+	words := strings.Fields(query)
+	if len(words) == 0 {
+		return 0
+	}
 
-	return c.formatSearchResults(), nil
+	total := 0
+	for _, w := range words {
+		best := max(
+			3*utils.FuzzyScore(w, r.Title),
+			2*utils.FuzzyScore(w, r.Artist),
+			utils.FuzzyScore(w, r.Album),
+		)
+		if best == 0 {
+			return 0
+		}
+		total += best
+	}
+	// Reward the whole phrase appearing in the title, e.g. "let it be".
+	if len(words) > 1 {
+		total += 2 * utils.FuzzyScore(query, r.Title)
+	}
+	return total
 }
 
 func (c *Commander) formatSearchResults() string {
@@ -37,13 +220,20 @@ func (c *Commander) formatSearchResults() string {
 	sb += fmt.Sprintf("Found %d results:\n\n", len(c.searchResults))
 
 	for i, r := range c.searchResults {
-		sb += fmt.Sprintf("%d. %s\n", i+1, r.Title)
+		sb += fmt.Sprintf("%d. %s [%s]\n", i+1, r.Title, r.Source)
 		sb += fmt.Sprintf("   Artist: %s\n", r.Artist)
 		sb += fmt.Sprintf("   Album: %s\n", r.Album)
 		min := r.Duration / 60
 		sec := r.Duration % 60
 		sb += fmt.Sprintf("   Duration: %d:%02d\n", min, sec)
-		sb += fmt.Sprintf("   URL: %s\n\n", r.URL)
+		if r.Source == SourceLocal {
+			sb += fmt.Sprintf("   Path: %s\n\n", r.URL)
+		} else {
+			sb += fmt.Sprintf("   URL: %s\n\n", r.URL)
+		}
+	}
+	if info := c.SearchPageInfo(); info != "" {
+		sb += info + "\n"
 	}
 	return sb
 }
@@ -0,0 +1,105 @@
+package commands
+
+import (
+	"gowav/pkg/api"
+	"reflect"
+	"testing"
+)
+
+func TestMatchScore(t *testing.T) {
+	// In each case the first result must outscore the second.
+	tests := []struct {
+		name          string
+		query         string
+		better, worse SearchResult
+	}{
+		{
+			name:   "exact title over subsequence",
+			query:  "help",
+			better: SearchResult{Title: "Help"},
+			worse:  SearchResult{Title: "Hey Elephant"},
+		},
+		{
+			name:   "title over artist",
+			query:  "yesterday",
+			better: SearchResult{Title: "Yesterday", Artist: "The Beatles"},
+			worse:  SearchResult{Title: "Hello", Artist: "Yesterday"},
+		},
+		{
+			name:   "artist over album",
+			query:  "yesterday",
+			better: SearchResult{Title: "Hello", Artist: "Yesterday"},
+			worse:  SearchResult{Title: "Hello", Album: "Yesterday"},
+		},
+		{
+			name:   "title over album",
+			query:  "abbey",
+			better: SearchResult{Title: "Abbey", Album: "Road"},
+			worse:  SearchResult{Title: "Road", Album: "Abbey"},
+		},
+		{
+			name:   "whole phrase in the title",
+			query:  "let it be",
+			better: SearchResult{Title: "Let It Be"},
+			worse:  SearchResult{Title: "Be It Let"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			better, worse := matchScore(tt.query, tt.better), matchScore(tt.query, tt.worse)
+			if worse == 0 || better <= worse {
+				t.Errorf("scores %d for %+v and %d for %+v; want both positive, the first higher",
+					better, tt.better, worse, tt.worse)
+			}
+		})
+	}
+}
+
+func TestMatchScoreNeedsEveryWord(t *testing.T) {
+	r := SearchResult{Title: "Yesterday", Artist: "The Beatles", Album: "Help!"}
+	for _, query := range []string{"", "   ", "beatles zeppelin", "yesterday x"} {
+		if got := matchScore(query, r); got != 0 {
+			t.Errorf("matchScore(%q) = %d, want 0", query, got)
+		}
+	}
+	if matchScore("beatles help yesterday", r) == 0 {
+		t.Error("words spread over title, artist and album did not match")
+	}
+}
+
+func TestSetSearchResultsRanking(t *testing.T) {
+	local := []SearchResult{
+		{Title: "Help", Artist: "The Beatles", URL: "/music/help.mp3", Source: SourceLocal},
+		{Title: "Hey Elephant", Artist: "Somebody", URL: "/music/hey.mp3", Source: SourceLocal},
+	}
+	song := func(name, file string) api.Song {
+		return api.Song{Name: name, File: file, Authors: []api.Author{{Name: "The Beatles"}}}
+	}
+	remote := []api.Song{
+		song("Nothing alike", "https://x/0.mp3"),
+		song("Help", "https://x/1.mp3"),
+		song("Hey Elephant", "https://x/2.mp3"),
+		song("Help", "https://x/3.mp3"),
+	}
+
+	var c Commander
+	c.setSearchResults("help", local, remote)
+
+	var got []string
+	for _, r := range c.searchResults {
+		got = append(got, r.URL)
+	}
+	// Equal scores keep library results first and the API's order after; remote results
+	// that don't match at all are kept, last.
+	want := []string{
+		"/music/help.mp3", "https://x/1.mp3", "https://x/3.mp3",
+		"/music/hey.mp3", "https://x/2.mp3",
+		"https://x/0.mp3",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("order = %v\nwant    %v", got, want)
+	}
+	if r := c.searchResults[1]; r.Source != SourceRemote || r.Artist != "The Beatles" {
+		t.Errorf("remote result = %+v, want source %q and the first author as artist", r, SourceRemote)
+	}
+}
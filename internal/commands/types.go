@@ -1,6 +1,9 @@
 package commands
 
-import "time"
+import (
+	"gowav/internal/audio"
+	"time"
+)
 
 type Mode int
 
@@ -16,15 +19,35 @@ type Track struct {
 	Duration int
 }
 
+// SearchResult is one hit from `search`. URL is a local path for library results.
+// The slugs link remote results to the API's album and author pages.
 type SearchResult struct {
-	Title    string
-	Artist   string
-	Album    string
-	Duration int
-	URL      string
+	Title      string
+	Artist     string
+	Album      string
+	Duration   int
+	URL        string
+	Source     string
+	AlbumSlug  string
+	ArtistSlug string
 }
 
-type playbackUpdateMsg struct{}
+// Search result sources.
+const (
+	SourceLocal  = "local"
+	SourceRemote = "remote"
+)
+
+// PlaybackUpdateMsg is a periodic tick that keeps the playback display current while a track plays.
+type PlaybackUpdateMsg struct{}
+
+// TrackEndedMsg is sent when the current track plays through to its end.
+type TrackEndedMsg struct{}
+
+// ProcessorEventMsg carries a status change of the current processor to the UI.
+type ProcessorEventMsg struct {
+	audio.Event
+}
 
 func FormatDuration(d time.Duration) string {
 	d = d.Round(time.Second)
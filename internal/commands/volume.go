@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"gowav/internal/audio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// volumeStep is how far volume-up/volume-down and the shortcuts move the volume.
+const volumeStep = 5
+
+// handleVolume shows the volume, sets it ("volume 80") or adjusts it ("volume +5", "volume -10").
+func (c *Commander) handleVolume(args []string) (string, error, tea.Cmd) {
+	if len(args) == 0 {
+		return c.volumeStatus(), nil, nil
+	}
+
+	arg := strings.TrimSuffix(args[0], "%")
+	v, err := strconv.Atoi(arg)
+	if err != nil {
+		return "", fmt.Errorf("usage: volume <0-%d> | +<n> | -<n>", audio.MaxVolume), nil
+	}
+	if strings.HasPrefix(arg, "+") || strings.HasPrefix(arg, "-") {
+		v += c.player.GetVolume()
+	} else if v > audio.MaxVolume {
+		return "", fmt.Errorf("volume must be between 0 and %d", audio.MaxVolume), nil
+	}
+	return c.setVolume(v), nil, nil
+}
+
+// handleVolumeStep nudges the volume by delta, as bound to the volume-up/volume-down shortcuts.
+func (c *Commander) handleVolumeStep(delta int) (string, error, tea.Cmd) {
+	return c.setVolume(c.player.GetVolume() + delta), nil, nil
+}
+
+func (c *Commander) handleMute(muted bool) (string, error, tea.Cmd) {
+	c.player.SetMuted(muted)
+	return c.volumeStatus(), nil, nil
+}
+
+// setVolume applies v to the player, unmuting it, and remembers it for the next session.
+func (c *Commander) setVolume(v int) string {
+	v = c.player.SetVolume(v)
+	c.player.SetMuted(false)
+	if err := saveVolume(v); err != nil {
+		return fmt.Sprintf("Volume: %d%% (not saved: %v)", v, err)
+	}
+	return c.volumeStatus()
+}
+
+func (c *Commander) volumeStatus() string {
+	if c.player.IsMuted() {
+		return fmt.Sprintf("Muted (volume %d%%)", c.player.GetVolume())
+	}
+	return fmt.Sprintf("Volume: %d%%", c.player.GetVolume())
+}
+
+// volumeFilePath is where the last volume is kept between sessions (~/.gowav/volume).
+func volumeFilePath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".gowav", "volume"), nil
+}
+
+// loadVolume reads the saved volume; ok is false if none was saved or it is unreadable.
+func loadVolume() (int, bool) {
+	path, err := volumeFilePath()
+	if err != nil {
+		return 0, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	v, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+func saveVolume(v int) error {
+	path, err := volumeFilePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(strconv.Itoa(v)+"\n"), 0644)
+}
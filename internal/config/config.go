@@ -0,0 +1,604 @@
+// Package config loads gowav's settings from ~/.config/gowav/config.toml and GOWAV_* environment variables.
+//
+// The file is a small subset of TOML: [section] headers, key = value lines whose values are
+// quoted strings, integers or booleans, and # comments. Environment variables override the
+// file: api.url is read from GOWAV_API_URL, api.token from GOWAV_API_TOKEN, and so on.
+//
+//	[api]
+//	url = "https://new.akarpov.ru/api/v1"
+//	timeout = "15s"
+//
+//	[keys.track]
+//	"ctrl+n" = "next"
+//
+//	[stations]
+//	groove = "http://ice1.somafm.com/groovesalad-128-mp3"
+package config
+
+import (
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	"gowav/pkg/api"
+	"gowav/pkg/viz"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds every setting with its defaults filled in.
+type Config struct {
+	API      API
+	Viz      Viz
+	Analysis Analysis
+	Log      Log
+	History  History
+	Cache    Cache
+	// Bindings maps a key context (see KeyContexts) to keys and what they do, on top of the
+	// built-in bindings. An empty action unbinds the key.
+	Bindings map[string]map[string]string
+	// Stations maps the names of internet radio stations to their stream URLs.
+	Stations map[string]string
+}
+
+// KeyContexts lists the contexts key bindings can be given for, from least to most specific.
+var KeyContexts = []string{"normal", "track", "search", "viz"}
+
+// API configures the remote music API and downloads from its host.
+type API struct {
+	URL        string
+	Token      string
+	AuthHeader string
+	UserAgent  string
+	Timeout    time.Duration
+}
+
+// Viz picks the visualization colors: a named theme, optionally with some colors replaced.
+type Viz struct {
+	Theme     string
+	Primary   string
+	Secondary string
+	Text      string
+}
+
+// Analysis sets the FFT sizes, in samples, used for spectrum and beat analysis.
+type Analysis struct {
+	WindowSize int
+	HopSize    int
+	FFTSize    int
+}
+
+// Log sets where debug logs are written.
+type Log struct {
+	Dir string
+}
+
+// History limits the command history kept in ~/.gowav/history.
+type History struct {
+	// Size is the number of commands remembered; 0 turns off saving them.
+	Size int
+}
+
+// Cache sets where copies of remote tracks are kept, and how much room they may take.
+type Cache struct {
+	// Dir holds the cached copies and their index, usually ~/.gowav/cache.
+	Dir string
+	// Size is the most the cached copies may take up, in bytes; 0 turns off caching.
+	// Explicit downloads don't count towards it.
+	Size int64
+}
+
+// kind decides how a setting's value is written to the file.
+type kind int
+
+const (
+	kindString kind = iota
+	kindInt
+)
+
+// setting binds a key to a field of Config.
+type setting struct {
+	kind kind
+	// secret values are masked by Display.
+	secret bool
+	get    func(*Config) string
+	set    func(*Config, string) error
+}
+
+const (
+	bindingPrefix = "keys."
+	stationPrefix = "stations."
+)
+
+var settings = map[string]setting{
+	"api.url": {
+		get: func(c *Config) string { return c.API.URL },
+		set: func(c *Config, v string) error {
+			if !strings.HasPrefix(v, "http://") && !strings.HasPrefix(v, "https://") {
+				return fmt.Errorf("must be an http(s) URL")
+			}
+			c.API.URL = strings.TrimRight(v, "/")
+			return nil
+		},
+	},
+	"api.token": {
+		secret: true,
+		get:    func(c *Config) string { return c.API.Token },
+		set:    func(c *Config, v string) error { c.API.Token = v; return nil },
+	},
+	"api.auth_header": {
+		secret: true,
+		get:    func(c *Config) string { return c.API.AuthHeader },
+		set: func(c *Config, v string) error {
+			if v != "" && !strings.Contains(v, ":") {
+				return fmt.Errorf("must look like \"Name: value\"")
+			}
+			c.API.AuthHeader = v
+			return nil
+		},
+	},
+	"api.user_agent": {
+		get: func(c *Config) string { return c.API.UserAgent },
+		set: func(c *Config, v string) error { c.API.UserAgent = v; return nil },
+	},
+	"api.timeout": {
+		get: func(c *Config) string { return c.API.Timeout.String() },
+		set: func(c *Config, v string) error {
+			d, err := parseDuration(v)
+			if err != nil || d <= 0 {
+				return fmt.Errorf("must be a positive duration such as \"10s\"")
+			}
+			c.API.Timeout = d
+			return nil
+		},
+	},
+	"viz.theme": {
+		get: func(c *Config) string { return c.Viz.Theme },
+		set: func(c *Config, v string) error {
+			if _, ok := viz.ColorSchemes[v]; !ok {
+				return fmt.Errorf("unknown theme %q (available: %s)", v, strings.Join(themeNames(), ", "))
+			}
+			c.Viz.Theme = v
+			return nil
+		},
+	},
+	"viz.primary":          colorSetting(func(c *Config) *string { return &c.Viz.Primary }),
+	"viz.secondary":        colorSetting(func(c *Config) *string { return &c.Viz.Secondary }),
+	"viz.text":             colorSetting(func(c *Config) *string { return &c.Viz.Text }),
+	"analysis.window_size": sizeSetting(func(c *Config) *int { return &c.Analysis.WindowSize }, true),
+	"analysis.hop_size":    sizeSetting(func(c *Config) *int { return &c.Analysis.HopSize }, false),
+	"analysis.fft_size":    sizeSetting(func(c *Config) *int { return &c.Analysis.FFTSize }, true),
+	"history.size": {
+		kind: kindInt,
+		get:  func(c *Config) string { return strconv.Itoa(c.History.Size) },
+		set: func(c *Config, v string) error {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return fmt.Errorf("must be a number of commands, or 0 to turn off saving")
+			}
+			c.History.Size = n
+			return nil
+		},
+	},
+	"cache.size": {
+		get: func(c *Config) string { return formatSize(c.Cache.Size) },
+		set: func(c *Config, v string) error {
+			n, err := parseSize(v)
+			if err != nil {
+				return fmt.Errorf("must be a size such as \"500MB\", \"2GB\" or 1048576 (bytes), or 0 to turn off caching")
+			}
+			c.Cache.Size = n
+			return nil
+		},
+	},
+	"cache.dir": {
+		get: func(c *Config) string { return c.Cache.Dir },
+		set: func(c *Config, v string) error {
+			if v == "" {
+				return fmt.Errorf("must not be empty")
+			}
+			c.Cache.Dir = expandHome(v)
+			return nil
+		},
+	},
+	"log.dir": {
+		get: func(c *Config) string { return c.Log.Dir },
+		set: func(c *Config, v string) error {
+			if v == "" {
+				return fmt.Errorf("must not be empty")
+			}
+			c.Log.Dir = expandHome(v)
+			return nil
+		},
+	},
+}
+
+// colorSetting accepts "#rgb" or "#rrggbb", or "" to keep the theme's color.
+func colorSetting(field func(*Config) *string) setting {
+	return setting{
+		get: func(c *Config) string { return *field(c) },
+		set: func(c *Config, v string) error {
+			if v != "" && !isHexColor(v) {
+				return fmt.Errorf("must be a hex color such as \"#00ff00\"")
+			}
+			*field(c) = v
+			return nil
+		},
+	}
+}
+
+// sizeSetting accepts a sample count between 16 and 65536, optionally a power of two.
+func sizeSetting(field func(*Config) *int, powerOfTwo bool) setting {
+	return setting{
+		kind: kindInt,
+		get:  func(c *Config) string { return strconv.Itoa(*field(c)) },
+		set: func(c *Config, v string) error {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 16 || n > 65536 {
+				return fmt.Errorf("must be a number of samples between 16 and 65536")
+			}
+			if powerOfTwo && n&(n-1) != 0 {
+				return fmt.Errorf("must be a power of two, e.g. 2048")
+			}
+			*field(c) = n
+			return nil
+		},
+	}
+}
+
+// Default returns the built-in settings.
+func Default() *Config {
+	c := &Config{
+		API: API{
+			URL:       api.DefaultBaseURL,
+			UserAgent: api.DefaultUserAgent,
+			Timeout:   api.DefaultTimeout,
+		},
+		Viz: Viz{Theme: "classic"},
+		Analysis: Analysis{
+			WindowSize: 2048,
+			HopSize:    512,
+			FFTSize:    2048,
+		},
+		History:  History{Size: 1000},
+		Cache:    Cache{Size: 1 << 30},
+		Bindings: make(map[string]map[string]string),
+		Stations: make(map[string]string),
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		c.Log.Dir = filepath.Join(home, ".gowav", "logs")
+		c.Cache.Dir = filepath.Join(home, ".gowav", "cache")
+	}
+	return c
+}
+
+// Path returns the config file location, usually ~/.config/gowav/config.toml.
+func Path() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gowav", "config.toml"), nil
+}
+
+// Load reads the config file at path over the defaults, then applies environment
+// overrides. A missing file is not an error.
+func Load(path string) (*Config, error) {
+	c := Default()
+	if err := c.readFile(path); err != nil {
+		return nil, err
+	}
+	if err := c.applyEnv(); err != nil {
+		return nil, err
+	}
+	if err := c.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+// LoadDefault loads the config from Path.
+func LoadDefault() (*Config, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	return Load(path)
+}
+
+func (c *Config) applyEnv() error {
+	for _, key := range settingKeys() {
+		name := EnvName(key)
+		if v, ok := os.LookupEnv(name); ok {
+			if err := c.Set(key, v); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validate checks rules that span several settings.
+func (c *Config) validate() error {
+	a := c.Analysis
+	if a.FFTSize < a.WindowSize {
+		return fmt.Errorf("analysis.fft_size (%d) must be at least analysis.window_size (%d)", a.FFTSize, a.WindowSize)
+	}
+	if a.HopSize > a.WindowSize {
+		return fmt.Errorf("analysis.hop_size (%d) must not exceed analysis.window_size (%d)", a.HopSize, a.WindowSize)
+	}
+	return nil
+}
+
+// Get returns the value of key as it would be written in the config file.
+func (c *Config) Get(key string) (string, error) {
+	if ctx, seq, ok := splitBinding(key); ok {
+		action, ok := c.Bindings[ctx][seq]
+		if !ok {
+			return "", fmt.Errorf("no binding configured for %s in %s", seq, ctx)
+		}
+		return action, nil
+	}
+	if name, ok := strings.CutPrefix(key, stationPrefix); ok {
+		url, ok := c.Stations[name]
+		if !ok {
+			return "", fmt.Errorf("no station named %s", name)
+		}
+		return url, nil
+	}
+	s, ok := settings[key]
+	if !ok {
+		return "", fmt.Errorf("unknown setting: %s", key)
+	}
+	return s.get(c), nil
+}
+
+// Display is Get with secrets such as api.token masked, for showing on screen.
+func (c *Config) Display(key string) (string, error) {
+	v, err := c.Get(key)
+	if err != nil || !settings[key].secret || v == "" {
+		return v, err
+	}
+	if name, value, ok := strings.Cut(v, ":"); ok && key == "api.auth_header" {
+		return name + ": " + mask(strings.TrimSpace(value)), nil
+	}
+	return mask(v), nil
+}
+
+// Set parses and stores value for key.
+func (c *Config) Set(key, value string) error {
+	if ctx, seq, ok := splitBinding(key); ok {
+		if !validContext(ctx) {
+			return fmt.Errorf("%s: unknown key context %q (available: %s)", key, ctx, strings.Join(KeyContexts, ", "))
+		}
+		if strings.TrimSpace(seq) == "" {
+			return fmt.Errorf("%s: missing key", key)
+		}
+		if c.Bindings[ctx] == nil {
+			c.Bindings[ctx] = make(map[string]string)
+		}
+		// Sequences are stored with single spaces, so "g  g" and "g g" are the same binding.
+		c.Bindings[ctx][strings.Join(strings.Fields(seq), " ")] = value
+		return nil
+	}
+	if name, ok := strings.CutPrefix(key, stationPrefix); ok {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("%s: missing station name", key)
+		}
+		if !strings.HasPrefix(value, "http://") && !strings.HasPrefix(value, "https://") {
+			return fmt.Errorf("%s must be an http(s) URL", key)
+		}
+		c.Stations[name] = value
+		return nil
+	}
+	s, ok := settings[key]
+	if !ok {
+		return fmt.Errorf("unknown setting: %s", key)
+	}
+	if err := s.set(c, value); err != nil {
+		return fmt.Errorf("%s %w", key, err)
+	}
+	return nil
+}
+
+// Keys returns every setting name in sorted order, followed by the configured key bindings
+// and stations.
+func (c *Config) Keys() []string {
+	keys := settingKeys()
+	for _, ctx := range KeyContexts {
+		seqs := make([]string, 0, len(c.Bindings[ctx]))
+		for seq := range c.Bindings[ctx] {
+			seqs = append(seqs, bindingPrefix+ctx+"."+seq)
+		}
+		sort.Strings(seqs)
+		keys = append(keys, seqs...)
+	}
+	for _, name := range c.StationNames() {
+		keys = append(keys, stationPrefix+name)
+	}
+	return keys
+}
+
+// StationNames returns the names of the configured stations in sorted order.
+func (c *Config) StationNames() []string {
+	names := make([]string, 0, len(c.Stations))
+	for name := range c.Stations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StationKey returns the key a station is stored under, for Update and Remove.
+func StationKey(name string) string {
+	return stationPrefix + name
+}
+
+// IsBinding reports whether key names a key binding rather than a setting.
+func IsBinding(key string) bool {
+	return strings.HasPrefix(key, bindingPrefix)
+}
+
+// IsStation reports whether key names a station rather than a setting.
+func IsStation(key string) bool {
+	return strings.HasPrefix(key, stationPrefix)
+}
+
+// splitBinding splits "keys.<context>.<keys>" into its context and key sequence.
+// The sequence may itself contain dots, e.g. "keys.viz.." binds the "." key.
+func splitBinding(key string) (ctx, seq string, ok bool) {
+	rest, ok := strings.CutPrefix(key, bindingPrefix)
+	if !ok {
+		return "", "", false
+	}
+	ctx, seq, _ = strings.Cut(rest, ".")
+	return ctx, seq, true
+}
+
+// splitKey returns the file section and entry name that key is stored under.
+func splitKey(key string) (section, name string) {
+	if ctx, seq, ok := splitBinding(key); ok {
+		return bindingPrefix + ctx, seq
+	}
+	if name, ok := strings.CutPrefix(key, stationPrefix); ok {
+		// Station names may contain dots.
+		return strings.TrimSuffix(stationPrefix, "."), name
+	}
+	section, name, _ = strings.Cut(key, ".")
+	return section, name
+}
+
+func validContext(ctx string) bool {
+	for _, c := range KeyContexts {
+		if c == ctx {
+			return true
+		}
+	}
+	return false
+}
+
+// ColorScheme returns the theme's colors with any configured replacements.
+func (v Viz) ColorScheme() viz.ColorScheme {
+	scheme, ok := viz.ColorSchemes[v.Theme]
+	if !ok {
+		scheme = viz.ClassicColorScheme()
+	}
+	if v.Primary != "" {
+		scheme.Primary = lipgloss.Color(v.Primary)
+	}
+	if v.Secondary != "" {
+		scheme.Secondary = lipgloss.Color(v.Secondary)
+	}
+	if v.Text != "" {
+		scheme.Text = lipgloss.Color(v.Text)
+	}
+	return scheme
+}
+
+// EnvName returns the environment variable that overrides key, e.g. GOWAV_API_URL for api.url.
+func EnvName(key string) string {
+	return "GOWAV_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+func settingKeys() []string {
+	keys := make([]string, 0, len(settings))
+	for k := range settings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func themeNames() []string {
+	names := make([]string, 0, len(viz.ColorSchemes))
+	for name := range viz.ColorSchemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseDuration accepts Go durations ("1m30s") and bare numbers of seconds.
+func parseDuration(v string) (time.Duration, error) {
+	if d, err := time.ParseDuration(v); err == nil {
+		return d, nil
+	}
+	secs, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(secs * float64(time.Second)), nil
+}
+
+// sizeUnits are the suffixes parseSize accepts, largest first, so "MB" isn't read as "B".
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"GB", 1 << 30}, {"G", 1 << 30},
+	{"MB", 1 << 20}, {"M", 1 << 20},
+	{"KB", 1 << 10}, {"K", 1 << 10},
+	{"B", 1},
+}
+
+// parseSize reads sizes such as "500MB" or "1.5GB" (in binary units), or a plain number of bytes.
+func parseSize(v string) (int64, error) {
+	v = strings.ToUpper(strings.TrimSpace(v))
+	if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+		if n < 0 {
+			return 0, fmt.Errorf("invalid size %q", v)
+		}
+		return n, nil
+	}
+	for _, u := range sizeUnits {
+		if num, ok := strings.CutSuffix(v, u.suffix); ok {
+			n, err := strconv.ParseFloat(strings.TrimSpace(num), 64)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid size %q", v)
+			}
+			return int64(n * float64(u.bytes)), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid size %q", v)
+}
+
+// formatSize writes a size the way parseSize reads it, in the largest whole unit.
+func formatSize(n int64) string {
+	for _, u := range sizeUnits {
+		if u.bytes > 1 && len(u.suffix) == 2 && n >= u.bytes && n%u.bytes == 0 {
+			return strconv.FormatInt(n/u.bytes, 10) + u.suffix
+		}
+	}
+	if n == 0 {
+		return "0"
+	}
+	return strconv.FormatInt(n, 10) + "B"
+}
+
+func isHexColor(v string) bool {
+	hex, ok := strings.CutPrefix(v, "#")
+	if !ok || (len(hex) != 3 && len(hex) != 6) {
+		return false
+	}
+	_, err := strconv.ParseUint(hex, 16, 32)
+	return err == nil
+}
+
+// mask hides all but the last few characters of a secret.
+func mask(v string) string {
+	if len(v) <= 8 {
+		return "********"
+	}
+	return "********" + v[len(v)-4:]
+}
+
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
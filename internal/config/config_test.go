@@ -0,0 +1,166 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetRejectsBadValues(t *testing.T) {
+	tests := []struct {
+		key, value string
+	}{
+		{"api.url", "ftp://a.example"},
+		{"api.url", "a.example"},
+		{"api.auth_header", "no colon"},
+		{"api.timeout", "soon"},
+		{"api.timeout", "0"},
+		{"api.timeout", "-5s"},
+		{"viz.theme", "no-such-theme"},
+		{"viz.primary", "red"},
+		{"viz.text", "#12345"},
+		{"analysis.window_size", "1000"},
+		{"analysis.fft_size", "8"},
+		{"analysis.hop_size", "131072"},
+		{"analysis.hop_size", "lots"},
+		{"history.size", "-1"},
+		{"cache.size", "lots"},
+		{"cache.size", "-1"},
+		{"cache.size", "-2GB"},
+		{"cache.dir", ""},
+		{"log.dir", ""},
+		{"keys.everywhere.x", "quit"},
+		{"keys.track.", "quit"},
+		{"keys.track.  ", "quit"},
+		{"stations.", "http://a.example"},
+		{"stations.groove", "a.example/groove"},
+		{"api.colour", "red"},
+		{"nothing", "x"},
+	}
+	for _, tt := range tests {
+		c := Default()
+		if err := c.Set(tt.key, tt.value); err == nil {
+			t.Errorf("Set(%s, %q) succeeded", tt.key, tt.value)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		wantErr string
+	}{
+		{"defaults", "", ""},
+		{"consistent sizes", "[analysis]\nwindow_size = 4096\nhop_size = 4096\nfft_size = 8192\n", ""},
+		{"fft smaller than window", "[analysis]\nwindow_size = 4096\n", "analysis.fft_size (2048) must be at least analysis.window_size (4096)"},
+		{"hop larger than window", "[analysis]\nwindow_size = 1024\nfft_size = 1024\nhop_size = 2048\n", "analysis.hop_size (2048) must not exceed analysis.window_size (1024)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeConfig(t, tt.file))
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("Load: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("Load error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadReportsLine(t *testing.T) {
+	path := writeConfig(t, "[api]\nurl = \"https://a.example\"\n\n[viz]\ntheme = \"no-such-theme\"\n")
+	_, err := Load(path)
+	if err == nil || !strings.Contains(err.Error(), path+":5:") {
+		t.Errorf("Load error = %v, want one pointing at line 5", err)
+	}
+}
+
+func TestEnvOverrides(t *testing.T) {
+	path := writeConfig(t, "[api]\nurl = \"https://file.example\"\ntimeout = \"30s\"\n\n[history]\nsize = 10\n")
+	t.Setenv("GOWAV_API_URL", "https://env.example/")
+	t.Setenv("GOWAV_API_TIMEOUT", "5")
+	t.Setenv("GOWAV_CACHE_SIZE", "0")
+
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.API.URL != "https://env.example" || c.API.Timeout != 5*time.Second {
+		t.Errorf("api = %q, %v; want the environment's values", c.API.URL, c.API.Timeout)
+	}
+	if c.History.Size != 10 {
+		t.Errorf("history.size = %d, want the file's 10", c.History.Size)
+	}
+	if c.Cache.Size != 0 {
+		t.Errorf("cache.size = %d, want 0 from the environment", c.Cache.Size)
+	}
+
+	t.Setenv("GOWAV_HISTORY_SIZE", "many")
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "GOWAV_HISTORY_SIZE") {
+		t.Errorf("Load error = %v, want one naming GOWAV_HISTORY_SIZE", err)
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	tests := map[string]string{
+		"api.url":              "GOWAV_API_URL",
+		"api.auth_header":      "GOWAV_API_AUTH_HEADER",
+		"analysis.window_size": "GOWAV_ANALYSIS_WINDOW_SIZE",
+	}
+	for key, want := range tests {
+		if got := EnvName(key); got != want {
+			t.Errorf("EnvName(%s) = %s, want %s", key, got, want)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"0", 0},
+		{"1048576", 1 << 20},
+		{" 512 ", 512},
+		{"100B", 100},
+		{"1k", 1 << 10},
+		{"500MB", 500 << 20},
+		{"1.5GB", 3 << 29},
+		{"2 G", 2 << 30},
+	}
+	for _, tt := range tests {
+		if got, err := parseSize(tt.in); err != nil || got != tt.want {
+			t.Errorf("parseSize(%q) = %d, %v; want %d", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"", "MB", "lots", "-1", "-1MB", "1TB", "1.5"} {
+		if got, err := parseSize(in); err == nil {
+			t.Errorf("parseSize(%q) = %d, want an error", in, got)
+		}
+	}
+}
+
+func TestDisplayMasksSecrets(t *testing.T) {
+	c := Default()
+	for key, value := range map[string]string{
+		"api.token":       "abcdefghijkl1234",
+		"api.auth_header": "X-Key: abcdefghijkl1234",
+		"api.user_agent":  "gowav-test",
+	} {
+		if err := c.Set(key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := map[string]string{
+		"api.token":       "********1234",
+		"api.auth_header": "X-Key: ********1234",
+		"api.user_agent":  "gowav-test",
+	}
+	for key, want := range tests {
+		if got, err := c.Display(key); err != nil || got != want {
+			t.Errorf("Display(%s) = %q, %v; want %q", key, got, err, want)
+		}
+	}
+}
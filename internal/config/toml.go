@@ -0,0 +1,340 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// line is one parsed line of the config file.
+type line struct {
+	// header is set for "[section]" lines.
+	header   string
+	isHeader bool
+	// key is the dotted key of a "key = value" line, relative to its section.
+	key   string
+	value string
+	// valueAt is the offset just past the '=', so the line can be rewritten with a new value.
+	valueAt int
+}
+
+func (c *Config) readFile(path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	section := ""
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		l, err := parseLine(scanner.Text())
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+		switch {
+		case l == nil:
+		case l.isHeader:
+			section = l.header
+		default:
+			if err := c.Set(joinKey(section, l.key), l.value); err != nil {
+				return fmt.Errorf("%s:%d: %w", path, lineNo, err)
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// Update validates value for key and writes it to the config file at path, creating the
+// file if needed. The rest of the file, comments included, is kept as it is. A file that
+// doesn't load is left alone, since the new value couldn't be checked against it.
+func Update(path, key, value string) error {
+	cfg, err := Load(path)
+	if err != nil {
+		return err
+	}
+	if err := cfg.Set(key, value); err != nil {
+		return err
+	}
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+	// Write the value the way Get reports it, e.g. "20s" rather than "20".
+	value, _ = cfg.Get(key)
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	var lines []string
+	if text := strings.TrimRight(string(data), "\n"); text != "" {
+		lines = strings.Split(text, "\n")
+	}
+
+	targetSection, name := splitKey(key)
+	formatted := formatValue(key, value)
+	section := ""
+	insertAt := -1
+	replaced := false
+	for i, text := range lines {
+		l, err := parseLine(text)
+		if err != nil || l == nil {
+			continue
+		}
+		if l.isHeader {
+			section = l.header
+			if section == targetSection {
+				insertAt = i + 1
+			}
+			continue
+		}
+		if section == targetSection {
+			insertAt = i + 1
+		}
+		if joinKey(section, l.key) == key {
+			lines[i] = text[:l.valueAt] + " " + formatted
+			replaced = true
+		}
+	}
+
+	if !replaced {
+		entry := formatKey(name) + " = " + formatted
+		if insertAt >= 0 {
+			lines = append(lines[:insertAt], append([]string{entry}, lines[insertAt:]...)...)
+		} else {
+			if len(lines) > 0 {
+				lines = append(lines, "")
+			}
+			lines = append(lines, "["+targetSection+"]", entry)
+		}
+	}
+	return writeFile(path, []byte(strings.Join(lines, "\n")+"\n"))
+}
+
+// Remove deletes key from the config file at path, keeping the rest of the file as it is.
+// It is an error if the file doesn't set key.
+func Remove(path, key string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	var lines []string
+	if text := strings.TrimRight(string(data), "\n"); text != "" {
+		lines = strings.Split(text, "\n")
+	}
+
+	kept := lines[:0]
+	section := ""
+	removed := false
+	for _, text := range lines {
+		l, err := parseLine(text)
+		if err == nil && l != nil {
+			if l.isHeader {
+				section = l.header
+			} else if joinKey(section, l.key) == key {
+				removed = true
+				continue
+			}
+		}
+		kept = append(kept, text)
+	}
+	if !removed {
+		return fmt.Errorf("%s is not set in %s", key, path)
+	}
+	return writeFile(path, []byte(strings.Join(kept, "\n")+"\n"))
+}
+
+// writeFile replaces path atomically. The file may hold credentials, so it is private.
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// parseLine parses a header or key = value line. Blank and comment lines give nil.
+func parseLine(text string) (*line, error) {
+	s := strings.TrimSpace(text)
+	if s == "" || s[0] == '#' {
+		return nil, nil
+	}
+
+	if s[0] == '[' {
+		if strings.HasPrefix(s, "[[") {
+			return nil, fmt.Errorf("arrays of tables are not supported")
+		}
+		end := strings.IndexByte(s, ']')
+		if end < 0 {
+			return nil, fmt.Errorf("missing ] in section header")
+		}
+		if rest := strings.TrimSpace(s[end+1:]); rest != "" && rest[0] != '#' {
+			return nil, fmt.Errorf("unexpected %q after section header", rest)
+		}
+		name, rest, err := parseKey(s[1:end])
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(rest) != "" {
+			return nil, fmt.Errorf("invalid section name %q", s[1:end])
+		}
+		return &line{header: name, isHeader: true}, nil
+	}
+
+	key, rest, err := parseKey(text)
+	if err != nil {
+		return nil, err
+	}
+	rest = strings.TrimLeft(rest, " \t")
+	if !strings.HasPrefix(rest, "=") {
+		return nil, fmt.Errorf("expected key = value")
+	}
+	valueAt := len(text) - len(rest) + 1
+	value, err := parseValue(text[valueAt:])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", key, err)
+	}
+	return &line{key: key, value: value, valueAt: valueAt}, nil
+}
+
+// parseKey reads a possibly dotted key of bare or quoted parts and returns it joined
+// with dots, along with the rest of s.
+func parseKey(s string) (string, string, error) {
+	var parts []string
+	for {
+		s = strings.TrimLeft(s, " \t")
+		var part string
+		switch {
+		case s == "":
+			return "", "", fmt.Errorf("missing key")
+		case s[0] == '"' || s[0] == '\'':
+			str, n, err := parseString(s)
+			if err != nil {
+				return "", "", err
+			}
+			part, s = str, s[n:]
+		default:
+			n := 0
+			for n < len(s) && isBareKeyChar(s[n]) {
+				n++
+			}
+			if n == 0 {
+				return "", "", fmt.Errorf("invalid key at %q", s)
+			}
+			part, s = s[:n], s[n:]
+		}
+		parts = append(parts, part)
+
+		s = strings.TrimLeft(s, " \t")
+		if !strings.HasPrefix(s, ".") {
+			return strings.Join(parts, "."), s, nil
+		}
+		s = s[1:]
+	}
+}
+
+// parseValue reads a quoted string, integer, float or boolean, followed by an optional comment.
+func parseValue(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", fmt.Errorf("missing value")
+	}
+
+	var value, rest string
+	if s[0] == '"' || s[0] == '\'' {
+		str, n, err := parseString(s)
+		if err != nil {
+			return "", err
+		}
+		value, rest = str, s[n:]
+	} else {
+		end := strings.IndexAny(s, " \t#")
+		if end < 0 {
+			end = len(s)
+		}
+		value, rest = s[:end], s[end:]
+		if !isBareValue(value) {
+			return "", fmt.Errorf("invalid value %s (strings must be quoted)", value)
+		}
+	}
+
+	if rest = strings.TrimSpace(rest); rest != "" && rest[0] != '#' {
+		return "", fmt.Errorf("unexpected %q after value", rest)
+	}
+	return value, nil
+}
+
+// parseString reads a "basic" or 'literal' string at the start of s and returns it with
+// the number of bytes consumed.
+func parseString(s string) (string, int, error) {
+	if s[0] == '\'' {
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return "", 0, fmt.Errorf("unterminated string")
+		}
+		return s[1 : end+1], end + 2, nil
+	}
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			str, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", 0, fmt.Errorf("invalid string %s", s[:i+1])
+			}
+			return str, i + 1, nil
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+func isBareKeyChar(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' || b == '_' || b == '-'
+}
+
+func isBareValue(v string) bool {
+	if v == "true" || v == "false" {
+		return true
+	}
+	_, err := strconv.ParseFloat(v, 64)
+	return err == nil
+}
+
+func joinKey(section, key string) string {
+	if section == "" {
+		return key
+	}
+	return section + "." + key
+}
+
+// formatKey quotes a key name unless it is a valid bare key.
+func formatKey(name string) string {
+	for i := 0; i < len(name); i++ {
+		if !isBareKeyChar(name[i]) {
+			return strconv.Quote(name)
+		}
+	}
+	if name == "" {
+		return `""`
+	}
+	return name
+}
+
+func formatValue(key, value string) string {
+	if settings[key].kind == kindInt {
+		return value
+	}
+	return strconv.Quote(value)
+}
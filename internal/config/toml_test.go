@@ -0,0 +1,240 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		text string
+		want *line
+	}{
+		{"", nil},
+		{"   # just a comment", nil},
+		{"[api]", &line{header: "api", isHeader: true}},
+		{"  [ keys.track ]  # bindings", &line{header: "keys.track", isHeader: true}},
+		{`["keys" . 'viz']`, &line{header: "keys.viz", isHeader: true}},
+		{`url = "https://x.example" # main`, &line{key: "url", value: "https://x.example", valueAt: 5}},
+		{`  size=1000`, &line{key: "size", value: "1000", valueAt: 7}},
+		{`"ctrl+n" = "next"`, &line{key: "ctrl+n", value: "next", valueAt: 10}},
+		{`'a.b'.c = true`, &line{key: "a.b.c", value: "true", valueAt: 9}},
+		{`ratio = -1.5e3`, &line{key: "ratio", value: "-1.5e3", valueAt: 7}},
+		{`s = "tab\there \"quoted\" # not a comment"`, &line{key: "s", value: "tab\there \"quoted\" # not a comment", valueAt: 3}},
+		{`path = 'C:\music\new' # literal`, &line{key: "path", value: `C:\music\new`, valueAt: 6}},
+		{`empty = ""`, &line{key: "empty", value: "", valueAt: 7}},
+		{`unicode = "café ♫"`, &line{key: "unicode", value: "café ♫", valueAt: 9}},
+	}
+	for _, tt := range tests {
+		got, err := parseLine(tt.text)
+		if err != nil {
+			t.Errorf("parseLine(%q): %v", tt.text, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseLine(%q) = %+v, want %+v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestParseLineErrors(t *testing.T) {
+	tests := []string{
+		"[[tracks]]",
+		"[api",
+		"[api] url",
+		"[api.]",
+		"[]",
+		"url",
+		"url = ",
+		"url = https://x.example",
+		`url = "unterminated`,
+		`url = 'unterminated`,
+		`url = "a" "b"`,
+		`url = "bad \q escape"`,
+		"= 1",
+		"a b = 1",
+		"size = 10 20",
+	}
+	for _, text := range tests {
+		if l, err := parseLine(text); err == nil {
+			t.Errorf("parseLine(%q) = %+v, want an error", text, l)
+		}
+	}
+}
+
+// writeConfig creates a config file holding text in a temporary directory.
+func writeConfig(t *testing.T, text string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if text != "" {
+		if err := os.WriteFile(path, []byte(text), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+func readConfig(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestUpdate(t *testing.T) {
+	const base = "# my settings\n[api]\nurl = \"https://a.example\" # main\n\n[viz]\ntheme = \"classic\"\n"
+	tests := []struct {
+		name       string
+		file       string
+		key, value string
+		want       string
+	}{
+		{
+			name: "new file",
+			key:  "api.token", value: "secret",
+			want: "[api]\ntoken = \"secret\"\n",
+		},
+		{
+			name: "inside an existing section",
+			file: base,
+			key:  "api.timeout", value: "20",
+			want: "# my settings\n[api]\nurl = \"https://a.example\" # main\ntimeout = \"20s\"\n\n[viz]\ntheme = \"classic\"\n",
+		},
+		{
+			name: "replacing a value",
+			file: base,
+			key:  "viz.theme", value: "monokai",
+			want: "# my settings\n[api]\nurl = \"https://a.example\" # main\n\n[viz]\ntheme = \"monokai\"\n",
+		},
+		{
+			name: "adding a missing section",
+			file: base,
+			key:  "history.size", value: "50",
+			want: base + "\n[history]\nsize = 50\n",
+		},
+		{
+			name: "binding with a quoted key",
+			file: "[keys.track]\n\"ctrl+n\" = \"next\"\n",
+			key:  "keys.track.ctrl+p", value: "prev",
+			want: "[keys.track]\n\"ctrl+n\" = \"next\"\n\"ctrl+p\" = \"prev\"\n",
+		},
+		{
+			name: "station named with a dot",
+			file: base,
+			key:  StationKey("radio.fm"), value: "http://radio.example/live",
+			want: base + "\n[stations]\n\"radio.fm\" = \"http://radio.example/live\"\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfig(t, tt.file)
+			if err := Update(path, tt.key, tt.value); err != nil {
+				t.Fatal(err)
+			}
+			if got := readConfig(t, path); got != tt.want {
+				t.Errorf("file is\n%s\nwant\n%s", got, tt.want)
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if perm := info.Mode().Perm(); perm != 0600 {
+				t.Errorf("file mode %v, want 0600", perm)
+			}
+		})
+	}
+}
+
+func TestUpdateLeavesFileAloneOnError(t *testing.T) {
+	tests := []struct {
+		name       string
+		file       string
+		key, value string
+	}{
+		{"invalid value", "[api]\nurl = \"https://a.example\"\n", "api.url", "ftp://a.example"},
+		{"unknown setting", "[api]\n", "api.colour", "red"},
+		{"against the rest of the file", "[analysis]\nwindow_size = 1024\n", "analysis.fft_size", "512"},
+		{"file that doesn't parse", "[api]\nurl = https://a.example\n", "viz.theme", "monokai"},
+		{"file that doesn't validate", "[analysis]\nhop_size = 4096\n", "viz.theme", "monokai"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfig(t, tt.file)
+			if err := Update(path, tt.key, tt.value); err == nil {
+				t.Error("Update succeeded")
+			}
+			if got := readConfig(t, path); got != tt.file {
+				t.Errorf("file changed to\n%s", got)
+			}
+		})
+	}
+}
+
+func TestRemove(t *testing.T) {
+	file := "[stations]\n# favourites\ngroove = \"http://a.example/groove\"\n\"radio.fm\" = \"http://b.example/live\"\n\n[viz]\ntheme = \"monokai\"\n"
+	path := writeConfig(t, file)
+
+	if err := Remove(path, StationKey("radio.fm")); err != nil {
+		t.Fatal(err)
+	}
+	want := "[stations]\n# favourites\ngroove = \"http://a.example/groove\"\n\n[viz]\ntheme = \"monokai\"\n"
+	if got := readConfig(t, path); got != want {
+		t.Errorf("file is\n%s\nwant\n%s", got, want)
+	}
+
+	for _, key := range []string{StationKey("radio.fm"), StationKey("theme"), "viz.primary"} {
+		if err := Remove(path, key); err == nil {
+			t.Errorf("removing %s, which isn't set, succeeded", key)
+		}
+	}
+	if got := readConfig(t, path); got != want {
+		t.Errorf("failed removes changed the file to\n%s", got)
+	}
+}
+
+// TestFormatValueRoundTrip writes awkward values with Update and reads them back with Load.
+func TestFormatValueRoundTrip(t *testing.T) {
+	tests := []struct {
+		key, value, want string
+	}{
+		{"api.user_agent", `gowav "beta" \ #1 'café' ♫`, `gowav "beta" \ #1 'café' ♫`},
+		{"api.user_agent", "tab\tand\nnewline", "tab\tand\nnewline"},
+		{"api.auth_header", "X-Key: a=b; c", "X-Key: a=b; c"},
+		{"api.timeout", "1m30s", "1m30s"},
+		{"api.timeout", "2.5", "2.5s"},
+		{"history.size", "0", "0"},
+		{"analysis.hop_size", "256", "256"},
+		{"cache.size", "1536MB", "1536MB"},
+		{"cache.size", "1048576", "1MB"},
+		{"cache.size", "1000", "1000B"},
+		{"cache.dir", "/srv/gowav cache", "/srv/gowav cache"},
+		{"keys.viz..", "exit-viz", "exit-viz"},
+		{"keys.normal.ctrl+g  g", "", ""},
+		{StationKey(`odd "name" # x`), "http://a.example/?q=1#frag", "http://a.example/?q=1#frag"},
+	}
+	for _, tt := range tests {
+		path := writeConfig(t, "")
+		if err := Update(path, tt.key, tt.value); err != nil {
+			t.Errorf("Update(%s, %q): %v", tt.key, tt.value, err)
+			continue
+		}
+		cfg, err := Load(path)
+		if err != nil {
+			t.Errorf("%s: loading what Update wrote: %v\n%s", tt.key, err, readConfig(t, path))
+			continue
+		}
+		key := tt.key
+		if IsBinding(key) {
+			key = strings.Join(strings.Fields(key), " ")
+		}
+		if got, err := cfg.Get(key); err != nil || got != tt.want {
+			t.Errorf("%s = %q, %v after a round trip of %q; want %q\n%s",
+				tt.key, got, err, tt.value, tt.want, readConfig(t, path))
+		}
+	}
+}
@@ -0,0 +1,256 @@
+// Package library keeps a persistent index of local music files and their tags.
+package library
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// UnknownArtist and UnknownAlbum group tracks whose tags leave those fields empty.
+const (
+	UnknownArtist = "Unknown Artist"
+	UnknownAlbum  = "Unknown Album"
+)
+
+// Track is one indexed file. Size and ModTime record the file as it was when its tags
+// were read, so a rescan can skip files that have not changed.
+type Track struct {
+	Path        string        `json:"path"`
+	Size        int64         `json:"size"`
+	ModTime     time.Time     `json:"mtime"`
+	Title       string        `json:"title,omitempty"`
+	Artist      string        `json:"artist,omitempty"`
+	AlbumArtist string        `json:"album_artist,omitempty"`
+	Album       string        `json:"album,omitempty"`
+	TrackNumber int           `json:"track,omitempty"`
+	Year        int           `json:"year,omitempty"`
+	Genre       string        `json:"genre,omitempty"`
+	Format      string        `json:"format,omitempty"`
+	Duration    time.Duration `json:"duration,omitempty"`
+}
+
+// DisplayTitle returns the tag title, or the file name if the track has none.
+func (t Track) DisplayTitle() string {
+	if t.Title != "" {
+		return t.Title
+	}
+	return filepath.Base(t.Path)
+}
+
+// ArtistName returns the album artist if set, else the track artist, for grouping.
+func (t Track) ArtistName() string {
+	switch {
+	case t.AlbumArtist != "":
+		return t.AlbumArtist
+	case t.Artist != "":
+		return t.Artist
+	default:
+		return UnknownArtist
+	}
+}
+
+// AlbumName returns the album tag or UnknownAlbum.
+func (t Track) AlbumName() string {
+	if t.Album != "" {
+		return t.Album
+	}
+	return UnknownAlbum
+}
+
+// unreadableFile records a file whose tags could not be read, as it was at the time, so a
+// rescan leaves it alone until it changes.
+type unreadableFile struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mtime"`
+}
+
+// Library is the in-memory index, saved as JSON at path. It is safe for concurrent use.
+type Library struct {
+	mu         sync.RWMutex
+	path       string
+	roots      []string
+	tracks     map[string]*Track
+	unreadable map[string]unreadableFile
+}
+
+// indexFile is the on-disk layout of the index.
+type indexFile struct {
+	Version    int              `json:"version"`
+	Roots      []string         `json:"roots"`
+	Tracks     []*Track         `json:"tracks"`
+	Unreadable []unreadableFile `json:"unreadable,omitempty"`
+}
+
+const indexVersion = 1
+
+// DefaultPath returns the standard index location, ~/.gowav/library.json.
+func DefaultPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".gowav", "library.json"), nil
+}
+
+// Open loads the index at path. A missing file gives an empty library.
+func Open(path string) (*Library, error) {
+	l := &Library{path: path, tracks: make(map[string]*Track), unreadable: make(map[string]unreadableFile)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var idx indexFile
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return nil, fmt.Errorf("corrupt library index %s: %w", path, err)
+	}
+	l.roots = idx.Roots
+	for _, t := range idx.Tracks {
+		l.tracks[t.Path] = t
+	}
+	for _, u := range idx.Unreadable {
+		l.unreadable[u.Path] = u
+	}
+	return l, nil
+}
+
+// Save writes the index atomically via a temporary file.
+func (l *Library) Save() error {
+	l.mu.RLock()
+	idx := indexFile{Version: indexVersion, Roots: l.roots}
+	for _, t := range l.tracks {
+		idx.Tracks = append(idx.Tracks, t)
+	}
+	for _, u := range l.unreadable {
+		idx.Unreadable = append(idx.Unreadable, u)
+	}
+	l.mu.RUnlock()
+	sort.Slice(idx.Tracks, func(i, j int) bool { return idx.Tracks[i].Path < idx.Tracks[j].Path })
+	sort.Slice(idx.Unreadable, func(i, j int) bool { return idx.Unreadable[i].Path < idx.Unreadable[j].Path })
+
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return err
+	}
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.path)
+}
+
+// Len returns the number of indexed tracks.
+func (l *Library) Len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.tracks)
+}
+
+// Roots returns the directories that have been scanned.
+func (l *Library) Roots() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return append([]string(nil), l.roots...)
+}
+
+// All returns every indexed track sorted by artist, album and track number.
+func (l *Library) All() []Track {
+	l.mu.RLock()
+	out := make([]Track, 0, len(l.tracks))
+	for _, t := range l.tracks {
+		out = append(out, *t)
+	}
+	l.mu.RUnlock()
+	sortTracks(out)
+	return out
+}
+
+// Artists lists the distinct artists with their track counts, sorted by name.
+func (l *Library) Artists() ([]string, map[string]int) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, t := range l.tracks {
+		counts[t.ArtistName()]++
+	}
+	return sortedKeys(counts), counts
+}
+
+// Albums lists the albums by artist (case-insensitive) with their track counts.
+func (l *Library) Albums(artist string) ([]string, map[string]int) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, t := range l.tracks {
+		if strings.EqualFold(t.ArtistName(), artist) {
+			counts[t.AlbumName()]++
+		}
+	}
+	return sortedKeys(counts), counts
+}
+
+// Tracks returns the tracks of album (case-insensitive) in track-number order.
+func (l *Library) Tracks(album string) []Track {
+	l.mu.RLock()
+	var out []Track
+	for _, t := range l.tracks {
+		if strings.EqualFold(t.AlbumName(), album) {
+			out = append(out, *t)
+		}
+	}
+	l.mu.RUnlock()
+	sortTracks(out)
+	return out
+}
+
+// sortTracks orders by artist, album, track number, then path.
+func sortTracks(tracks []Track) {
+	sort.Slice(tracks, func(i, j int) bool {
+		a, b := tracks[i], tracks[j]
+		if x, y := strings.ToLower(a.ArtistName()), strings.ToLower(b.ArtistName()); x != y {
+			return x < y
+		}
+		if x, y := strings.ToLower(a.AlbumName()), strings.ToLower(b.AlbumName()); x != y {
+			return x < y
+		}
+		if a.TrackNumber != b.TrackNumber {
+			return a.TrackNumber < b.TrackNumber
+		}
+		return a.Path < b.Path
+	})
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return strings.ToLower(keys[i]) < strings.ToLower(keys[j]) })
+	return keys
+}
+
+// parseTrackNumber reads the leading number of tags like "3" or "3/12".
+func parseTrackNumber(s string) int {
+	s, _, _ = strings.Cut(strings.TrimSpace(s), "/")
+	n, _ := strconv.Atoi(s)
+	return n
+}
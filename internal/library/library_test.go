@@ -0,0 +1,263 @@
+package library
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"gowav/internal/audio"
+)
+
+// wavFile returns a mono 16-bit 8 kHz WAV file of silence lasting frames samples.
+func wavFile(frames int) []byte {
+	const rate = 8000
+	data := make([]byte, frames*2)
+	b := []byte("RIFF")
+	b = binary.LittleEndian.AppendUint32(b, uint32(36+len(data)))
+	b = append(b, "WAVEfmt "...)
+	b = binary.LittleEndian.AppendUint32(b, 16)
+	b = binary.LittleEndian.AppendUint16(b, 1) // PCM
+	b = binary.LittleEndian.AppendUint16(b, 1)
+	b = binary.LittleEndian.AppendUint32(b, rate)
+	b = binary.LittleEndian.AppendUint32(b, rate*2)
+	b = binary.LittleEndian.AppendUint16(b, 2)
+	b = binary.LittleEndian.AppendUint16(b, 16)
+	b = append(b, "data"...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(data)))
+	return append(b, data...)
+}
+
+// writeFile creates path and its parent directories with the given contents.
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// countReads makes readMetadata record the files it is asked for until the test ends.
+// Each call of the returned function gives the paths read since the previous call.
+func countReads(t *testing.T) func() []string {
+	var (
+		mu    sync.Mutex
+		paths []string
+	)
+	orig := readMetadata
+	readMetadata = func(path string) (*audio.Metadata, error) {
+		mu.Lock()
+		paths = append(paths, path)
+		mu.Unlock()
+		return orig(path)
+	}
+	t.Cleanup(func() { readMetadata = orig })
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		out := paths
+		paths = nil
+		sort.Strings(out)
+		return out
+	}
+}
+
+func scan(t *testing.T, l *Library, root string) ScanResult {
+	t.Helper()
+	res, err := l.Scan(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Root = ""
+	return res
+}
+
+func TestScanIsIncremental(t *testing.T) {
+	dir := t.TempDir()
+	a, b, bad := filepath.Join(dir, "a.wav"), filepath.Join(dir, "sub", "b.wav"), filepath.Join(dir, "bad.mp3")
+	writeFile(t, a, wavFile(8000))
+	writeFile(t, b, wavFile(4000))
+	// An empty ID3 tag passes the music file check, but no audio follows it.
+	writeFile(t, bad, []byte("ID3\x03\x00\x00\x00\x00\x00\x00not audio at all"))
+	writeFile(t, filepath.Join(dir, ".hidden", "c.wav"), wavFile(8000))
+	writeFile(t, filepath.Join(dir, "notes.txt"), []byte("hello"))
+
+	l, err := Open(filepath.Join(dir, "index.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reads := countReads(t)
+
+	steps := []struct {
+		name      string
+		change    func()
+		want      ScanResult
+		wantReads []string
+	}{
+		{
+			name:      "first scan",
+			want:      ScanResult{Added: 2, Failed: 1},
+			wantReads: []string{a, bad, b},
+		},
+		{
+			name: "nothing changed",
+			want: ScanResult{Unchanged: 2, Failed: 1},
+		},
+		{
+			name: "track rewritten",
+			change: func() {
+				writeFile(t, a, wavFile(16000))
+				later := time.Now().Add(time.Hour)
+				if err := os.Chtimes(a, later, later); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want:      ScanResult{Updated: 1, Unchanged: 1, Failed: 1},
+			wantReads: []string{a},
+		},
+		{
+			name: "touched without a size change",
+			change: func() {
+				later := time.Now().Add(2 * time.Hour)
+				if err := os.Chtimes(b, later, later); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want:      ScanResult{Updated: 1, Unchanged: 1, Failed: 1},
+			wantReads: []string{b},
+		},
+		{
+			name:      "unreadable file fixed",
+			change:    func() { writeFile(t, bad, wavFile(800)) },
+			want:      ScanResult{Added: 1, Unchanged: 2},
+			wantReads: []string{bad},
+		},
+	}
+	for _, step := range steps {
+		if step.change != nil {
+			step.change()
+		}
+		if got := scan(t, l, dir); got != step.want {
+			t.Errorf("%s: got %+v, want %+v", step.name, got, step.want)
+		}
+		if got := reads(); !reflect.DeepEqual(got, step.wantReads) {
+			t.Errorf("%s: read %v, want %v", step.name, got, step.wantReads)
+		}
+	}
+
+	if got := l.tracks[a].Duration; got != 2*time.Second {
+		t.Errorf("duration after the rewrite = %v, want 2s", got)
+	}
+	if len(l.unreadable) != 0 {
+		t.Errorf("still unreadable after the fix: %v", l.unreadable)
+	}
+}
+
+func TestScanRemovesDeletedFiles(t *testing.T) {
+	dir := t.TempDir()
+	music, other := filepath.Join(dir, "music"), filepath.Join(dir, "music2")
+	for _, p := range []string{"a.wav", "b.wav", "sub/c.wav"} {
+		writeFile(t, filepath.Join(music, p), wavFile(800))
+	}
+	writeFile(t, filepath.Join(music, "bad.flac"), []byte("fLaC but not really"))
+	writeFile(t, filepath.Join(other, "d.wav"), wavFile(800))
+
+	l, err := Open(filepath.Join(dir, "index.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scan(t, l, music)
+	scan(t, l, other)
+	if len(l.unreadable) != 1 {
+		t.Fatalf("unreadable = %v, want bad.flac", l.unreadable)
+	}
+
+	for _, p := range []string{"b.wav", "sub", "bad.flac"} {
+		if err := os.RemoveAll(filepath.Join(music, p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := scan(t, l, music), (ScanResult{Removed: 2, Unchanged: 1}); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	var paths []string
+	for _, tr := range l.All() {
+		paths = append(paths, tr.Path)
+	}
+	// The sibling directory sharing the root's name as a prefix is left alone.
+	want := []string{filepath.Join(music, "a.wav"), filepath.Join(other, "d.wav")}
+	sort.Strings(paths)
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("indexed %v, want %v", paths, want)
+	}
+	if len(l.unreadable) != 0 {
+		t.Errorf("the deleted unreadable file is still recorded: %v", l.unreadable)
+	}
+}
+
+func TestIndexRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	music := filepath.Join(dir, "music")
+	writeFile(t, filepath.Join(music, "a.wav"), wavFile(8000))
+	writeFile(t, filepath.Join(music, "b.wav"), wavFile(2000))
+	writeFile(t, filepath.Join(music, "bad.ogg"), []byte("OggS garbage"))
+	index := filepath.Join(dir, "state", "library.json")
+
+	l, err := Open(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scan(t, l, music)
+
+	reopened, err := Open(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := reopened.Roots(), []string{music}; !reflect.DeepEqual(got, want) {
+		t.Errorf("roots = %v, want %v", got, want)
+	}
+	if got, want := normalize(reopened.All()), normalize(l.All()); !reflect.DeepEqual(got, want) {
+		t.Errorf("tracks after reopening:\n got  %+v\n want %+v", got, want)
+	}
+	if len(reopened.unreadable) != 1 {
+		t.Errorf("unreadable after reopening = %v, want bad.ogg", reopened.unreadable)
+	}
+
+	// Nothing needs reading again, the unreadable file included.
+	reads := countReads(t)
+	if got, want := scan(t, reopened, music), (ScanResult{Unchanged: 2, Failed: 1}); got != want {
+		t.Errorf("rescan got %+v, want %+v", got, want)
+	}
+	if got := reads(); len(got) != 0 {
+		t.Errorf("rescan read %v", got)
+	}
+}
+
+func TestOpenMissingAndCorruptIndex(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(filepath.Join(dir, "missing.json"))
+	if err != nil || l.Len() != 0 {
+		t.Fatalf("Open of a missing index = %v, %v; want an empty library", l, err)
+	}
+
+	corrupt := filepath.Join(dir, "corrupt.json")
+	writeFile(t, corrupt, []byte("{not json"))
+	if _, err := Open(corrupt); err == nil {
+		t.Error("Open accepted a corrupt index")
+	}
+}
+
+// normalize drops the time zone of each track's modification time, which JSON doesn't keep.
+func normalize(tracks []Track) []Track {
+	for i := range tracks {
+		tracks[i].ModTime = tracks[i].ModTime.UTC()
+	}
+	return tracks
+}
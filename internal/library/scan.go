@@ -0,0 +1,203 @@
+package library
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"gowav/internal/audio"
+	"gowav/pkg/utils"
+)
+
+// ScanResult summarizes what a scan changed in the index.
+type ScanResult struct {
+	Root      string
+	Added     int
+	Updated   int
+	Removed   int
+	Unchanged int
+	Failed    int
+}
+
+func (r ScanResult) String() string {
+	s := fmt.Sprintf("Scanned %s: %d added, %d updated, %d removed, %d unchanged",
+		r.Root, r.Added, r.Updated, r.Removed, r.Unchanged)
+	if r.Failed > 0 {
+		s += fmt.Sprintf(", %d unreadable", r.Failed)
+	}
+	return s
+}
+
+// candidate is a music file found by the walk whose tags need (re)reading.
+type candidate struct {
+	path  string
+	info  fs.FileInfo
+	isNew bool
+}
+
+// Scan walks root for music files and brings the index up to date. Files whose size and
+// modification time match the index, including those found unreadable before, are
+// skipped; the rest have their tags read in parallel. Indexed files under root that no
+// longer exist are dropped. The index is saved before returning.
+func (l *Library) Scan(root string) (ScanResult, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return ScanResult{}, err
+	}
+	if info, err := os.Stat(root); err != nil {
+		return ScanResult{}, err
+	} else if !info.IsDir() {
+		return ScanResult{}, fmt.Errorf("%s is not a directory", root)
+	}
+
+	res := ScanResult{Root: root}
+	seen := make(map[string]bool)
+	var todo []candidate
+
+	l.mu.RLock()
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable subdirectories are skipped rather than aborting the scan.
+			if d != nil && d.IsDir() && path != root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			if path != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || !utils.IsMusicFile(path) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		seen[path] = true
+
+		old, ok := l.tracks[path]
+		if ok && old.Size == info.Size() && old.ModTime.Equal(info.ModTime()) {
+			res.Unchanged++
+			return nil
+		}
+		if u, ok := l.unreadable[path]; ok && u.Size == info.Size() && u.ModTime.Equal(info.ModTime()) {
+			res.Failed++
+			return nil
+		}
+		todo = append(todo, candidate{path: path, info: info, isNew: !ok})
+		return nil
+	})
+	l.mu.RUnlock()
+	if err != nil {
+		return res, fmt.Errorf("failed to walk %s: %w", root, err)
+	}
+
+	tracks := readTracks(todo)
+
+	l.mu.Lock()
+	for i, c := range todo {
+		t := tracks[i]
+		if t == nil {
+			delete(l.tracks, c.path)
+			l.unreadable[c.path] = unreadableFile{Path: c.path, Size: c.info.Size(), ModTime: c.info.ModTime()}
+			res.Failed++
+			continue
+		}
+		delete(l.unreadable, c.path)
+		l.tracks[c.path] = t
+		if c.isNew {
+			res.Added++
+		} else {
+			res.Updated++
+		}
+	}
+	prefix := root + string(filepath.Separator)
+	for path := range l.tracks {
+		if strings.HasPrefix(path, prefix) && !seen[path] {
+			delete(l.tracks, path)
+			res.Removed++
+		}
+	}
+	for path := range l.unreadable {
+		if strings.HasPrefix(path, prefix) && !seen[path] {
+			delete(l.unreadable, path)
+		}
+	}
+	if !containsString(l.roots, root) {
+		l.roots = append(l.roots, root)
+	}
+	l.mu.Unlock()
+
+	if err := l.Save(); err != nil {
+		return res, fmt.Errorf("failed to save library index: %w", err)
+	}
+	return res, nil
+}
+
+// readTracks extracts tags for each candidate on a pool of workers. A nil entry
+// means the file could not be read or decoded.
+func readTracks(todo []candidate) []*Track {
+	out := make([]*Track, len(todo))
+	jobs := make(chan int)
+
+	workers := runtime.NumCPU()
+	if workers > len(todo) {
+		workers = len(todo)
+	}
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				out[i] = readTrack(todo[i])
+			}
+		}()
+	}
+	for i := range todo {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return out
+}
+
+// readMetadata reads the tags of one file; tests replace it to count reads.
+var readMetadata = audio.ReadFileMetadata
+
+func readTrack(c candidate) *Track {
+	md, err := readMetadata(c.path)
+	if err != nil {
+		return nil
+	}
+	return &Track{
+		Path:        c.path,
+		Size:        c.info.Size(),
+		ModTime:     c.info.ModTime(),
+		Title:       strings.TrimSpace(md.Title),
+		Artist:      strings.TrimSpace(md.Artist),
+		AlbumArtist: strings.TrimSpace(md.AlbumArtist),
+		Album:       strings.TrimSpace(md.Album),
+		TrackNumber: parseTrackNumber(md.Track),
+		Year:        md.Year,
+		Genre:       strings.TrimSpace(md.Genre),
+		Format:      md.Format,
+		Duration:    md.Duration,
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
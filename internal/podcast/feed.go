@@ -0,0 +1,251 @@
+package podcast
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"golang.org/x/text/encoding/htmlindex"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxFeedSize bounds how much of a feed is read; long-running shows can have large feeds.
+const maxFeedSize = 32 << 20
+
+// text is an element's character data along with its name, so elements that share a
+// local name across namespaces can be told apart.
+type text struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
+// plain returns the first of ts without a namespace, so <itunes:title> doesn't shadow <title>.
+func plain(ts []text) string {
+	for _, t := range ts {
+		if t.XMLName.Space == "" {
+			return strings.TrimSpace(t.Value)
+		}
+	}
+	return ""
+}
+
+type rssFeed struct {
+	Channel struct {
+		Titles []text    `xml:"title"`
+		Author string    `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd author"`
+		Items  []rssItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type rssItem struct {
+	Titles    []text `xml:"title"`
+	GUID      string `xml:"guid"`
+	PubDate   string `xml:"pubDate"`
+	Duration  string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+	Enclosure *struct {
+		URL    string `xml:"url,attr"`
+		Type   string `xml:"type,attr"`
+		Length string `xml:"length,attr"`
+	} `xml:"enclosure"`
+}
+
+type atomFeed struct {
+	Title  string `xml:"title"`
+	Author struct {
+		Name string `xml:"name"`
+	} `xml:"author"`
+	Entries []struct {
+		ID        string `xml:"id"`
+		Title     string `xml:"title"`
+		Published string `xml:"published"`
+		Updated   string `xml:"updated"`
+		Links     []struct {
+			Rel    string `xml:"rel,attr"`
+			Href   string `xml:"href,attr"`
+			Type   string `xml:"type,attr"`
+			Length string `xml:"length,attr"`
+		} `xml:"link"`
+	} `xml:"entry"`
+}
+
+// Parse reads an RSS 2.0 or Atom feed. Items without an enclosure are left out, and
+// the episodes come newest first.
+func Parse(r io.Reader) (*Podcast, error) {
+	dec := xml.NewDecoder(r)
+	dec.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		// Older feeds are often Latin-1 or Windows-1252; read them as a browser would.
+		enc, err := htmlindex.Get(charset)
+		if err != nil {
+			return nil, fmt.Errorf("unsupported feed encoding %q", charset)
+		}
+		return enc.NewDecoder().Reader(input), nil
+	}
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("not a podcast feed: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "rss":
+			var f rssFeed
+			if err := dec.DecodeElement(&f, &start); err != nil {
+				return nil, fmt.Errorf("invalid RSS feed: %w", err)
+			}
+			return f.podcast(), nil
+		case "feed":
+			var f atomFeed
+			if err := dec.DecodeElement(&f, &start); err != nil {
+				return nil, fmt.Errorf("invalid Atom feed: %w", err)
+			}
+			return f.podcast(), nil
+		default:
+			return nil, fmt.Errorf("not a podcast feed: <%s> is neither RSS nor Atom", start.Name.Local)
+		}
+	}
+}
+
+func (f *rssFeed) podcast() *Podcast {
+	p := &Podcast{Title: plain(f.Channel.Titles), Author: strings.TrimSpace(f.Channel.Author)}
+	for _, item := range f.Channel.Items {
+		if item.Enclosure == nil || item.Enclosure.URL == "" {
+			continue
+		}
+		ep := Episode{
+			GUID:      strings.TrimSpace(item.GUID),
+			Title:     plain(item.Titles),
+			URL:       strings.TrimSpace(item.Enclosure.URL),
+			Type:      item.Enclosure.Type,
+			Published: parseDate(item.PubDate),
+			Duration:  parseDuration(item.Duration),
+		}
+		ep.Size, _ = strconv.ParseInt(strings.TrimSpace(item.Enclosure.Length), 10, 64)
+		p.Episodes = append(p.Episodes, ep)
+	}
+	p.finish()
+	return p
+}
+
+func (f *atomFeed) podcast() *Podcast {
+	p := &Podcast{Title: strings.TrimSpace(f.Title), Author: strings.TrimSpace(f.Author.Name)}
+	for _, e := range f.Entries {
+		for _, link := range e.Links {
+			if link.Rel != "enclosure" || link.Href == "" {
+				continue
+			}
+			published := e.Published
+			if published == "" {
+				published = e.Updated
+			}
+			ep := Episode{
+				GUID:      strings.TrimSpace(e.ID),
+				Title:     strings.TrimSpace(e.Title),
+				URL:       strings.TrimSpace(link.Href),
+				Type:      link.Type,
+				Published: parseDate(published),
+			}
+			ep.Size, _ = strconv.ParseInt(strings.TrimSpace(link.Length), 10, 64)
+			p.Episodes = append(p.Episodes, ep)
+			break
+		}
+	}
+	p.finish()
+	return p
+}
+
+// finish fills in what the feed left out and puts the newest episodes first.
+func (p *Podcast) finish() {
+	for i := range p.Episodes {
+		ep := &p.Episodes[i]
+		if ep.GUID == "" {
+			ep.GUID = ep.URL
+		}
+		if ep.Title == "" {
+			ep.Title = ep.URL[strings.LastIndex(ep.URL, "/")+1:]
+		}
+	}
+	sort.SliceStable(p.Episodes, func(i, j int) bool {
+		return p.Episodes[i].Published.After(p.Episodes[j].Published)
+	})
+}
+
+// dateLayouts are the date formats seen in feeds: RFC 822 with its common variations, and
+// RFC 3339 for Atom.
+var dateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"Mon, 02 Jan 2006 15:04 -0700",
+	time.RFC3339,
+}
+
+// parseDate returns the zero time for dates it can't read.
+func parseDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// parseDuration reads itunes:duration, which is either seconds or [hh:]mm:ss.
+func parseDuration(s string) time.Duration {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0
+	}
+	var total float64
+	for _, part := range strings.Split(s, ":") {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil || v < 0 {
+			return 0
+		}
+		total = total*60 + v
+	}
+	return time.Duration(total * float64(time.Second))
+}
+
+// fetch downloads and parses the feed at feedURL. Relative enclosure links are resolved
+// against the address the feed was finally served from.
+func fetch(ctx context.Context, client *http.Client, feedURL string) (*Podcast, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/xml;q=0.9, */*;q=0.8")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", feedURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: %s", feedURL, resp.Status)
+	}
+
+	p, err := Parse(io.LimitReader(resp.Body, maxFeedSize))
+	if err != nil {
+		return nil, err
+	}
+	for i := range p.Episodes {
+		if ref, err := url.Parse(p.Episodes[i].URL); err == nil {
+			p.Episodes[i].URL = resp.Request.URL.ResolveReference(ref).String()
+		}
+	}
+	p.URL = feedURL
+	if p.Title == "" {
+		p.Title = feedURL
+	}
+	return p, nil
+}
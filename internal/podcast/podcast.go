@@ -0,0 +1,294 @@
+// Package podcast keeps podcast subscriptions, their episodes and how far each episode
+// has been played.
+package podcast
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Episode is one item of a feed. Position and Played are kept across refreshes.
+type Episode struct {
+	GUID      string        `json:"guid"`
+	Title     string        `json:"title"`
+	URL       string        `json:"url"`
+	Type      string        `json:"type,omitempty"`
+	Size      int64         `json:"size,omitempty"`
+	Published time.Time     `json:"published,omitempty"`
+	Duration  time.Duration `json:"duration,omitempty"`
+	Position  time.Duration `json:"position,omitempty"`
+	Played    bool          `json:"played,omitempty"`
+}
+
+// Podcast is a subscribed feed. URL is the address it was added with; Updated is when it
+// was last fetched.
+type Podcast struct {
+	URL      string    `json:"url"`
+	Title    string    `json:"title"`
+	Author   string    `json:"author,omitempty"`
+	Updated  time.Time `json:"updated"`
+	Episodes []Episode `json:"episodes"`
+}
+
+// Unplayed counts the episodes that haven't been played to the end.
+func (p Podcast) Unplayed() int {
+	n := 0
+	for _, ep := range p.Episodes {
+		if !ep.Played {
+			n++
+		}
+	}
+	return n
+}
+
+// RefreshResult summarizes a refresh of every subscription.
+type RefreshResult struct {
+	Podcasts int
+	New      int
+	// Failed maps the title of each podcast that couldn't be fetched to the reason.
+	Failed map[string]error
+}
+
+func (r RefreshResult) String() string {
+	s := fmt.Sprintf("Refreshed %d podcasts: %d new episodes", r.Podcasts, r.New)
+	titles := make([]string, 0, len(r.Failed))
+	for title := range r.Failed {
+		titles = append(titles, title)
+	}
+	sort.Strings(titles)
+	for _, title := range titles {
+		s += fmt.Sprintf("\n  %s: %v", title, r.Failed[title])
+	}
+	return s
+}
+
+// Store is the list of subscriptions, saved as JSON at path. It is safe for concurrent use.
+type Store struct {
+	mu       sync.RWMutex
+	path     string
+	podcasts []*Podcast
+}
+
+// storeFile is the on-disk layout of the store.
+type storeFile struct {
+	Version  int        `json:"version"`
+	Podcasts []*Podcast `json:"podcasts"`
+}
+
+const storeVersion = 1
+
+// DefaultPath returns the standard store location, ~/.gowav/podcasts.json.
+func DefaultPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".gowav", "podcasts.json"), nil
+}
+
+// Open loads the store at path. A missing file gives no subscriptions.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var f storeFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("corrupt podcast list %s: %w", path, err)
+	}
+	s.podcasts = f.Podcasts
+	return s, nil
+}
+
+// Save writes the store atomically via a temporary file.
+func (s *Store) Save() error {
+	s.mu.RLock()
+	data, err := json.MarshalIndent(storeFile{Version: storeVersion, Podcasts: s.podcasts}, "", "  ")
+	s.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// Podcasts returns the subscriptions sorted by title.
+func (s *Store) Podcasts() []Podcast {
+	s.mu.RLock()
+	out := make([]Podcast, len(s.podcasts))
+	for i, p := range s.podcasts {
+		out[i] = p.copy()
+	}
+	s.mu.RUnlock()
+	sort.SliceStable(out, func(i, j int) bool { return strings.ToLower(out[i].Title) < strings.ToLower(out[j].Title) })
+	return out
+}
+
+// Find looks a podcast up by its number in Podcasts, its title (case-insensitive) or its URL.
+func (s *Store) Find(ref string) (Podcast, error) {
+	podcasts := s.Podcasts()
+	if n, err := strconv.Atoi(ref); err == nil {
+		if n < 1 || n > len(podcasts) {
+			return Podcast{}, fmt.Errorf("no podcast %d (there are %d)", n, len(podcasts))
+		}
+		return podcasts[n-1], nil
+	}
+	for _, p := range podcasts {
+		if strings.EqualFold(p.Title, ref) || p.URL == ref {
+			return p, nil
+		}
+	}
+	return Podcast{}, fmt.Errorf("no podcast named %s (see 'podcast list')", ref)
+}
+
+// Add fetches the feed at feedURL and subscribes to it.
+func (s *Store) Add(ctx context.Context, client *http.Client, feedURL string) (Podcast, error) {
+	s.mu.RLock()
+	_, subscribed := s.lookup(feedURL)
+	s.mu.RUnlock()
+	if subscribed {
+		return Podcast{}, fmt.Errorf("already subscribed to %s", feedURL)
+	}
+
+	p, err := fetch(ctx, client, feedURL)
+	if err != nil {
+		return Podcast{}, err
+	}
+	p.Updated = time.Now()
+
+	s.mu.Lock()
+	if _, subscribed := s.lookup(feedURL); subscribed {
+		s.mu.Unlock()
+		return Podcast{}, fmt.Errorf("already subscribed to %s", feedURL)
+	}
+	s.podcasts = append(s.podcasts, p)
+	out := p.copy()
+	s.mu.Unlock()
+	return out, s.Save()
+}
+
+// Refresh fetches every feed again. New episodes are added; the position and played state
+// of known ones are kept. A feed that fails to load keeps its episodes.
+func (s *Store) Refresh(ctx context.Context, client *http.Client) (RefreshResult, error) {
+	s.mu.RLock()
+	urls := make([]string, len(s.podcasts))
+	for i, p := range s.podcasts {
+		urls[i] = p.URL
+	}
+	s.mu.RUnlock()
+
+	res := RefreshResult{Podcasts: len(urls), Failed: make(map[string]error)}
+	for _, u := range urls {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		fresh, err := fetch(ctx, client, u)
+
+		s.mu.Lock()
+		old, ok := s.lookup(u)
+		switch {
+		case !ok:
+			// Removed while we were fetching it.
+		case err != nil:
+			res.Failed[old.Title] = err
+		default:
+			res.New += old.merge(fresh)
+		}
+		s.mu.Unlock()
+	}
+	return res, s.Save()
+}
+
+// merge takes the title and episodes from fresh, carrying over what has been played, and
+// returns how many episodes are new.
+func (p *Podcast) merge(fresh *Podcast) int {
+	known := make(map[string]Episode, len(p.Episodes))
+	for _, ep := range p.Episodes {
+		known[ep.GUID] = ep
+	}
+	added := 0
+	for i := range fresh.Episodes {
+		ep := &fresh.Episodes[i]
+		if old, ok := known[ep.GUID]; ok {
+			ep.Position, ep.Played = old.Position, old.Played
+		} else {
+			added++
+		}
+	}
+	p.Title, p.Author, p.Episodes = fresh.Title, fresh.Author, fresh.Episodes
+	p.Updated = time.Now()
+	return added
+}
+
+// SetPosition records how far into an episode playback got.
+func (s *Store) SetPosition(feedURL, guid string, pos time.Duration) error {
+	return s.update(feedURL, guid, func(ep *Episode) {
+		ep.Position = pos
+	})
+}
+
+// MarkPlayed records that an episode was played to the end.
+func (s *Store) MarkPlayed(feedURL, guid string) error {
+	return s.update(feedURL, guid, func(ep *Episode) {
+		ep.Played, ep.Position = true, 0
+	})
+}
+
+// update applies change to an episode and saves the store.
+func (s *Store) update(feedURL, guid string, change func(ep *Episode)) error {
+	s.mu.Lock()
+	p, ok := s.lookup(feedURL)
+	if !ok {
+		s.mu.Unlock()
+		return fmt.Errorf("not subscribed to %s", feedURL)
+	}
+	for i := range p.Episodes {
+		if p.Episodes[i].GUID == guid {
+			change(&p.Episodes[i])
+			s.mu.Unlock()
+			return s.Save()
+		}
+	}
+	title := p.Title
+	s.mu.Unlock()
+	return fmt.Errorf("%s has no episode %s", title, guid)
+}
+
+// lookup finds a subscription by URL. The caller holds s.mu.
+func (s *Store) lookup(feedURL string) (*Podcast, bool) {
+	for _, p := range s.podcasts {
+		if p.URL == feedURL {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+func (p *Podcast) copy() Podcast {
+	out := *p
+	out.Episodes = append([]Episode(nil), p.Episodes...)
+	return out
+}
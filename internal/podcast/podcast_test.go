@@ -0,0 +1,268 @@
+package podcast
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const rssFeedXML = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd">
+<channel>
+  <title>Test Show</title>
+  <itunes:author>Jo Host</itunes:author>
+  <image><title>Cover</title></image>
+  <item>
+    <title>Episode 1</title>
+    <itunes:title>Short 1</itunes:title>
+    <guid>ep-1</guid>
+    <pubDate>Mon, 02 Jan 2006 15:04:05 -0700</pubDate>
+    <itunes:duration>1:02:03</itunes:duration>
+    <enclosure url="/media/ep1.mp3" type="audio/mpeg" length="1234"/>
+  </item>
+  <item>
+    <title>Trailer without audio</title>
+    <guid>trailer</guid>
+  </item>
+  %s
+</channel>
+</rss>`
+
+const secondEpisode = `<item>
+    <title>Episode 2</title>
+    <guid>ep-2</guid>
+    <pubDate>Tue, 3 Jan 2006 10:00:00 GMT</pubDate>
+    <itunes:duration>1800</itunes:duration>
+    <enclosure url="https://cdn.example.com/ep2.mp3" type="audio/mpeg"/>
+  </item>`
+
+const atomFeedXML = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Atom Show</title>
+  <author><name>Sam</name></author>
+  <entry>
+    <id>urn:ep:a</id>
+    <title>First</title>
+    <updated>2024-03-01T10:00:00Z</updated>
+    <link rel="alternate" href="https://example.com/first"/>
+    <link rel="enclosure" href="https://example.com/first.ogg" type="audio/ogg" length="99"/>
+  </entry>
+  <entry>
+    <id>urn:ep:b</id>
+    <title>Second</title>
+    <published>2024-04-01T10:00:00Z</published>
+    <link rel="enclosure" href="https://example.com/second.ogg"/>
+  </entry>
+</feed>`
+
+// serveFeed serves the feed returned by body at /feed.xml, so tests can change it between requests.
+func serveFeed(t *testing.T, body func() string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/feed.xml" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(body()))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestAddParsesRSS(t *testing.T) {
+	srv := serveFeed(t, func() string { return strings.Replace(rssFeedXML, "%s", "", 1) })
+	store, err := Open(filepath.Join(t.TempDir(), "podcasts.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := store.Add(context.Background(), srv.Client(), srv.URL+"/feed.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Title != "Test Show" || p.Author != "Jo Host" {
+		t.Errorf("podcast = %q by %q", p.Title, p.Author)
+	}
+	if len(p.Episodes) != 1 {
+		t.Fatalf("got %d episodes, want 1 (items without an enclosure are skipped)", len(p.Episodes))
+	}
+	ep := p.Episodes[0]
+	want := Episode{
+		GUID:      "ep-1",
+		Title:     "Episode 1",
+		URL:       srv.URL + "/media/ep1.mp3",
+		Type:      "audio/mpeg",
+		Size:      1234,
+		Published: time.Date(2006, 1, 2, 22, 4, 5, 0, time.UTC),
+		Duration:  time.Hour + 2*time.Minute + 3*time.Second,
+	}
+	if ep.GUID != want.GUID || ep.Title != want.Title || ep.URL != want.URL || ep.Type != want.Type ||
+		ep.Size != want.Size || !ep.Published.Equal(want.Published) || ep.Duration != want.Duration {
+		t.Errorf("episode = %+v\nwant %+v", ep, want)
+	}
+
+	if _, err := store.Add(context.Background(), srv.Client(), srv.URL+"/feed.xml"); err == nil {
+		t.Error("subscribing twice succeeded")
+	}
+}
+
+func TestParseAtom(t *testing.T) {
+	p, err := Parse(strings.NewReader(atomFeedXML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Title != "Atom Show" || p.Author != "Sam" {
+		t.Errorf("podcast = %q by %q", p.Title, p.Author)
+	}
+	if len(p.Episodes) != 2 {
+		t.Fatalf("got %d episodes, want 2", len(p.Episodes))
+	}
+	// Newest first, whatever the feed order.
+	if first := p.Episodes[0]; first.GUID != "urn:ep:b" || first.URL != "https://example.com/second.ogg" {
+		t.Errorf("first episode = %+v", first)
+	}
+	if second := p.Episodes[1]; second.Title != "First" || second.Size != 99 || second.Published.IsZero() {
+		t.Errorf("second episode = %+v", second)
+	}
+}
+
+func TestParseDecodesDeclaredEncoding(t *testing.T) {
+	const feed = "<?xml version=\"1.0\" encoding=\"%s\"?>\n<rss><channel><title>Caf\xe9 Cr\xe8me</title>" +
+		"<item><title>\xc9pisode \xbd</title><enclosure url=\"https://example.com/1.mp3\"/></item></channel></rss>"
+	for _, charset := range []string{"ISO-8859-1", "latin1", "windows-1252"} {
+		p, err := Parse(strings.NewReader(fmt.Sprintf(feed, charset)))
+		if err != nil {
+			t.Errorf("%s: %v", charset, err)
+			continue
+		}
+		if p.Title != "Café Crème" || len(p.Episodes) != 1 || p.Episodes[0].Title != "Épisode ½" {
+			t.Errorf("%s: podcast = %q, episodes %+v", charset, p.Title, p.Episodes)
+		}
+	}
+
+	if _, err := Parse(strings.NewReader(fmt.Sprintf(feed, "x-made-up"))); err == nil || !strings.Contains(err.Error(), "x-made-up") {
+		t.Errorf("Parse with an unknown encoding = %v", err)
+	}
+}
+
+func TestParseRejectsOtherXML(t *testing.T) {
+	if _, err := Parse(strings.NewReader(`<html><body>Not a feed</body></html>`)); err == nil {
+		t.Error("parsed an HTML page as a feed")
+	}
+}
+
+func TestRefreshKeepsProgress(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		extra string
+	)
+	srv := serveFeed(t, func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return strings.Replace(rssFeedXML, "%s", extra, 1)
+	})
+	path := filepath.Join(t.TempDir(), "podcasts.json")
+	store, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	feedURL := srv.URL + "/feed.xml"
+	if _, err := store.Add(context.Background(), srv.Client(), feedURL); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetPosition(feedURL, "ep-1", 90*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	extra = secondEpisode
+	mu.Unlock()
+	res, err := store.Refresh(context.Background(), srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Podcasts != 1 || res.New != 1 || len(res.Failed) != 0 {
+		t.Errorf("refresh = %+v", res)
+	}
+
+	// Reopen to check what was saved.
+	store, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := store.Find("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Episodes) != 2 || p.Episodes[0].GUID != "ep-2" {
+		t.Fatalf("episodes after refresh = %+v", p.Episodes)
+	}
+	if ep := p.Episodes[1]; ep.Position != 90*time.Second || ep.Played {
+		t.Errorf("ep-1 after refresh = %+v, want its position kept", ep)
+	}
+
+	if err := store.MarkPlayed(feedURL, "ep-1"); err != nil {
+		t.Fatal(err)
+	}
+	p, _ = store.Find("test show")
+	if ep := p.Episodes[1]; !ep.Played || ep.Position != 0 {
+		t.Errorf("ep-1 after MarkPlayed = %+v", ep)
+	}
+	if p.Unplayed() != 1 {
+		t.Errorf("Unplayed() = %d, want 1", p.Unplayed())
+	}
+}
+
+func TestRefreshReportsFailedFeeds(t *testing.T) {
+	var down atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			http.Error(w, "gone", http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(strings.Replace(rssFeedXML, "%s", "", 1)))
+	}))
+	defer srv.Close()
+
+	store, err := Open(filepath.Join(t.TempDir(), "podcasts.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Add(context.Background(), srv.Client(), srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	down.Store(true)
+	res, err := store.Refresh(context.Background(), srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Failed["Test Show"] == nil {
+		t.Errorf("refresh = %+v, want Test Show to have failed", res)
+	}
+	if p, _ := store.Find("1"); len(p.Episodes) != 1 {
+		t.Error("a failed refresh dropped the episodes")
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"":        0,
+		"95":      95 * time.Second,
+		"12:34":   12*time.Minute + 34*time.Second,
+		"1:00:00": time.Hour,
+		"abc":     0,
+	}
+	for in, want := range tests {
+		if got := parseDuration(in); got != want {
+			t.Errorf("parseDuration(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// cmdHistory is the command line history, oldest first, kept in ~/.gowav/history.
+// Running a command again moves it to the end instead of adding a duplicate.
+type cmdHistory struct {
+	entries []string
+	// path is where the history is saved; empty keeps it in memory only.
+	path string
+	// limit caps the number of entries; 0 keeps nothing on disk.
+	limit int
+}
+
+// historyPath returns the history file location, ~/.gowav/history.
+func historyPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".gowav", "history"), nil
+}
+
+// newHistory loads the saved history, keeping at most limit entries.
+func newHistory(limit int) *cmdHistory {
+	path, err := historyPath()
+	if err != nil {
+		path = ""
+	}
+	return loadHistory(path, limit)
+}
+
+// loadHistory reads the history file at path, if there is one.
+func loadHistory(path string, limit int) *cmdHistory {
+	h := &cmdHistory{path: path, limit: limit}
+	if path == "" {
+		return h
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				h.push(line)
+			}
+		}
+	}
+	return h
+}
+
+// add records a command and saves the history.
+func (h *cmdHistory) add(cmd string) error {
+	cmd = strings.TrimSpace(cmd)
+	if cmd == "" {
+		return nil
+	}
+	h.push(cmd)
+	return h.save()
+}
+
+// setLimit changes the size limit, dropping the oldest entries if needed.
+func (h *cmdHistory) setLimit(limit int) {
+	h.limit = limit
+	h.trim()
+}
+
+func (h *cmdHistory) push(cmd string) {
+	for i, e := range h.entries {
+		if e == cmd {
+			h.entries = append(h.entries[:i], h.entries[i+1:]...)
+			break
+		}
+	}
+	h.entries = append(h.entries, cmd)
+	h.trim()
+}
+
+func (h *cmdHistory) trim() {
+	// A limit of 0 only turns off saving; the session still remembers its own commands.
+	if h.limit > 0 && len(h.entries) > h.limit {
+		h.entries = append([]string(nil), h.entries[len(h.entries)-h.limit:]...)
+	}
+}
+
+func (h *cmdHistory) save() error {
+	if h.path == "" || h.limit == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return err
+	}
+	// Commands can include tokens (config set api.token ...), so keep the file private.
+	tmp := h.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strings.Join(h.entries, "\n")+"\n"), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, h.path)
+}
+
+// searchBefore returns the index of the newest entry before index before that contains
+// query, or -1 if there is none.
+func (h *cmdHistory) searchBefore(query string, before int) int {
+	if before > len(h.entries) {
+		before = len(h.entries)
+	}
+	for i := before - 1; i >= 0; i-- {
+		if strings.Contains(h.entries[i], query) {
+			return i
+		}
+	}
+	return -1
+}
+
+// startHistorySearch begins a Ctrl+R reverse search, remembering the current input so
+// cancelling can restore it.
+func (m *AudioModel) startHistorySearch() {
+	m.searchMode = true
+	m.searchMatch = -1
+	m.searchFailed = false
+	m.searchSaved = m.getInputValue()
+	m.clearTabCompletion()
+	m.setInputValue("")
+	m.setInputPlaceholder("")
+}
+
+// endHistorySearch leaves search mode with value on the command line.
+func (m *AudioModel) endHistorySearch(value string) {
+	m.searchMode = false
+	m.setInputPlaceholder("Enter command (type 'help' for list)")
+	m.setInputValue(value)
+	m.input.CursorEnd()
+}
+
+// searchResult is the current match, or "" if there is none.
+func (m AudioModel) searchResult() string {
+	if m.searchMatch < 0 || m.searchMatch >= len(m.history.entries) {
+		return ""
+	}
+	return m.history.entries[m.searchMatch]
+}
+
+// handleHistorySearchKey handles keys during a reverse search, like readline: typing narrows
+// the search, Ctrl+R again finds an older match, Enter runs the match, and Tab or an arrow
+// key puts it on the command line for editing. Esc, Ctrl+G or Ctrl+C cancel.
+func (m *AudioModel) handleHistorySearchKey(msg tea.KeyMsg) tea.Cmd {
+	query := m.getInputValue()
+
+	switch msg.Type {
+	case tea.KeyCtrlR:
+		if query == "" {
+			return nil
+		}
+		start := len(m.history.entries)
+		if m.searchMatch >= 0 {
+			start = m.searchMatch
+		}
+		if i := m.history.searchBefore(query, start); i >= 0 {
+			m.searchMatch, m.searchFailed = i, false
+		} else {
+			m.searchFailed = true
+		}
+		return nil
+
+	case tea.KeyEnter:
+		cmdStr := m.searchResult()
+		m.endHistorySearch("")
+		if cmdStr == "" {
+			return nil
+		}
+		return m.submit(cmdStr)
+
+	case tea.KeyTab, tea.KeyLeft, tea.KeyRight, tea.KeyHome, tea.KeyEnd, tea.KeyUp, tea.KeyDown:
+		if match := m.searchResult(); match != "" {
+			query = match
+		}
+		m.endHistorySearch(query)
+		return nil
+
+	case tea.KeyEsc, tea.KeyCtrlG, tea.KeyCtrlC:
+		m.endHistorySearch(m.searchSaved)
+		return nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	query = m.getInputValue()
+	if query == "" {
+		m.searchMatch, m.searchFailed = -1, false
+		return cmd
+	}
+	// Keep the current match while it still fits, as readline does; otherwise look further back.
+	start := len(m.history.entries)
+	if m.searchMatch >= 0 {
+		start = m.searchMatch + 1
+	}
+	m.searchMatch = m.history.searchBefore(query, start)
+	m.searchFailed = m.searchMatch < 0
+	return cmd
+}
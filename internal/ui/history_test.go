@@ -0,0 +1,172 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestHistoryAdd(t *testing.T) {
+	tests := []struct {
+		name     string
+		limit    int
+		add      []string
+		want     []string
+		wantFile string
+	}{
+		{
+			name:     "in order",
+			limit:    10,
+			add:      []string{"load a.mp3", "play", "stop"},
+			want:     []string{"load a.mp3", "play", "stop"},
+			wantFile: "load a.mp3\nplay\nstop\n",
+		},
+		{
+			name:     "repeat moves to the end",
+			limit:    10,
+			add:      []string{"play", "stop", "seek 1:00", "play", "play"},
+			want:     []string{"stop", "seek 1:00", "play"},
+			wantFile: "stop\nseek 1:00\nplay\n",
+		},
+		{
+			name:     "blank and padded commands",
+			limit:    10,
+			add:      []string{"  play  ", "", "   ", "play"},
+			want:     []string{"play"},
+			wantFile: "play\n",
+		},
+		{
+			name:     "size limit drops the oldest",
+			limit:    3,
+			add:      []string{"a", "b", "c", "d", "b", "e"},
+			want:     []string{"d", "b", "e"},
+			wantFile: "d\nb\ne\n",
+		},
+		{
+			name:  "limit 0 remembers the session only",
+			limit: 0,
+			add:   []string{"a", "b", "a"},
+			want:  []string{"b", "a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".gowav", "history")
+			h := loadHistory(path, tt.limit)
+			for _, cmd := range tt.add {
+				if err := h.add(cmd); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if !reflect.DeepEqual(h.entries, tt.want) {
+				t.Errorf("entries = %q, want %q", h.entries, tt.want)
+			}
+
+			data, err := os.ReadFile(path)
+			if tt.wantFile == "" {
+				if !os.IsNotExist(err) {
+					t.Errorf("history file written: %q, %v", data, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.wantFile {
+				t.Errorf("file = %q, want %q", data, tt.wantFile)
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if perm := info.Mode().Perm(); perm != 0600 {
+				t.Errorf("file mode %v, want 0600", perm)
+			}
+		})
+	}
+}
+
+func TestLoadHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	if err := os.WriteFile(path, []byte("a\n\n  b  \nc\na\nd\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := loadHistory(path, 10).entries, []string{"b", "c", "a", "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("entries = %q, want %q", got, want)
+	}
+	if got, want := loadHistory(path, 2).entries, []string{"a", "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("entries with limit 2 = %q, want %q", got, want)
+	}
+
+	h := loadHistory(path, 10)
+	h.setLimit(1)
+	if got, want := h.entries, []string{"d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("entries after setLimit(1) = %q, want %q", got, want)
+	}
+	if h := loadHistory(filepath.Join(t.TempDir(), "missing"), 10); len(h.entries) != 0 {
+		t.Errorf("missing file gave entries %q", h.entries)
+	}
+}
+
+func TestHistorySearch(t *testing.T) {
+	ctrlR := tea.KeyMsg{Type: tea.KeyCtrlR}
+	backspace := tea.KeyMsg{Type: tea.KeyBackspace}
+	steps := []struct {
+		key        tea.KeyMsg
+		wantQuery  string
+		wantMatch  string
+		wantFailed bool
+	}{
+		{ctrlR, "", "", false},
+		{runes("l"), "l", "volume 50", false},
+		{runes("oad"), "load", "load c.mp3", false},
+		// Repeated Ctrl+R steps back through older matches.
+		{ctrlR, "load", "load b.mp3", false},
+		{ctrlR, "load", "load a.mp3", false},
+		// Past the oldest match the search fails but keeps the last match shown.
+		{ctrlR, "load", "load a.mp3", true},
+		// Typing narrows from the current match back, as readline does.
+		{runes(" b"), "load b", "", true},
+		// After a failed search, deleting starts again from the newest entry.
+		{backspace, "load ", "load c.mp3", false},
+		{runes("b"), "load b", "load b.mp3", false},
+		{backspace, "load ", "load b.mp3", false},
+	}
+
+	m := &AudioModel{input: textinput.New(), history: &cmdHistory{entries: []string{
+		"load a.mp3", "play", "load b.mp3", "seek 1:00", "load c.mp3", "volume 50",
+	}}}
+	m.input.Focus()
+	m.setInputValue("half typed")
+	m.startHistorySearch()
+
+	for i, s := range steps {
+		m.handleHistorySearchKey(s.key)
+		if got := m.getInputValue(); got != s.wantQuery {
+			t.Fatalf("step %d (%s): query %q, want %q", i+1, s.key, got, s.wantQuery)
+		}
+		if got := m.searchResult(); got != s.wantMatch || m.searchFailed != s.wantFailed {
+			t.Errorf("step %d (%s): match %q, failed %v; want %q, %v",
+				i+1, s.key, got, m.searchFailed, s.wantMatch, s.wantFailed)
+		}
+	}
+
+	// Tab puts the match on the command line for editing.
+	m.handleHistorySearchKey(tea.KeyMsg{Type: tea.KeyTab})
+	if m.searchMode || m.getInputValue() != "load b.mp3" {
+		t.Errorf("after Tab: search mode %v, input %q; want the match to edit", m.searchMode, m.getInputValue())
+	}
+
+	// Esc gives back what was typed before the search.
+	m.setInputValue("half typed")
+	m.startHistorySearch()
+	m.handleHistorySearchKey(runes("seek"))
+	m.handleHistorySearchKey(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.searchMode || m.getInputValue() != "half typed" {
+		t.Errorf("after Esc: search mode %v, input %q; want %q", m.searchMode, m.getInputValue(), "half typed")
+	}
+}
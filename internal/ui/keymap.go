@@ -0,0 +1,335 @@
+package ui
+
+import (
+	"fmt"
+	"gowav/internal/config"
+	"sort"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Key contexts, from least to most specific. Bindings in a more specific context win.
+const (
+	// ctxNormal bindings apply everywhere.
+	ctxNormal = "normal"
+	// ctxTrack bindings apply while a track is loaded.
+	ctxTrack = "track"
+	// ctxSearch bindings apply while the search result list is open.
+	ctxSearch = "search"
+	// ctxViz bindings apply in visualization mode.
+	ctxViz = "viz"
+)
+
+// keymap maps each context to its key bindings. A binding's key is a single key such as
+// "ctrl+p", or a space-separated sequence such as "ctrl+g g"; its action is one of the built-in
+// actions below or a command line such as "seek +30s".
+type keymap map[string]map[string]string
+
+// keyActions describes the built-in actions. Anything else is run as a command.
+var keyActions = map[string]string{
+	"toggle-mode":    "Switch between the full and mini layouts",
+	"toggle-viz":     "Enter or leave visualization mode",
+	"exit-viz":       "Leave visualization mode",
+	"next-viz":       "Next visualization type",
+	"prev-viz":       "Previous visualization type",
+	"zoom-in":        "Zoom in",
+	"zoom-out":       "Zoom out",
+	"scroll-left":    "Move backward in time",
+	"scroll-right":   "Move forward in time",
+	"reset-viz":      "Reset zoom and position",
+	"clear":          "Clear the output",
+	"show-keys":      "Show the active key bindings",
+	"result-up":      "Select the previous result",
+	"result-down":    "Select the next result",
+	"result-play":    "Play the selected result",
+	"result-enqueue": "Add the selected result to the queue",
+	"result-close":   "Close the result list",
+}
+
+// defaultKeymap returns the built-in key bindings.
+func defaultKeymap() keymap {
+	return keymap{
+		ctxNormal: {
+			"ctrl+q":   "quit",
+			"ctrl+t":   "toggle-mode",
+			"ctrl+l":   "clear",
+			"ctrl+u":   "volume-up",
+			"ctrl+d":   "volume-down",
+			"alt+up":   "volume-up",
+			"alt+down": "volume-down",
+			"?":        "show-keys",
+		},
+		ctxTrack: {
+			"ctrl+p":     "play",
+			"ctrl+s":     "stop",
+			"ctrl+space": "pause",
+			"alt+v":      "toggle-viz",
+			"left":       "seek -10s",
+			"right":      "seek +10s",
+		},
+		ctxSearch: {
+			"up":     "result-up",
+			"down":   "result-down",
+			"enter":  "result-play",
+			"ctrl+e": "result-enqueue",
+			"esc":    "result-close",
+		},
+		ctxViz: {
+			"esc":       "exit-viz",
+			"q":         "exit-viz",
+			"tab":       "next-viz",
+			"shift+tab": "prev-viz",
+			"+":         "zoom-in",
+			"=":         "zoom-in",
+			"-":         "zoom-out",
+			"_":         "zoom-out",
+			"left":      "scroll-left",
+			"h":         "scroll-left",
+			"right":     "scroll-right",
+			"l":         "scroll-right",
+			"0":         "reset-viz",
+		},
+	}
+}
+
+// newKeymap applies the configured bindings over the defaults; an empty action unbinds a key.
+func newKeymap(configured map[string]map[string]string) keymap {
+	km := defaultKeymap()
+	for ctx, bindings := range configured {
+		if km[ctx] == nil {
+			km[ctx] = make(map[string]string)
+		}
+		for seq, action := range bindings {
+			if action == "" {
+				delete(km[ctx], seq)
+			} else {
+				km[ctx][seq] = action
+			}
+		}
+	}
+	return km
+}
+
+// keyMatch is the outcome of looking up a key sequence.
+type keyMatch int
+
+const (
+	keyNone keyMatch = iota
+	// keyPrefix means the sequence starts a longer binding; wait for the next key.
+	keyPrefix
+	keyBound
+)
+
+// lookup finds seq in the given contexts, most specific first. A complete binding wins
+// over a longer sequence starting with the same keys.
+func (km keymap) lookup(contexts []string, seq string) (string, keyMatch) {
+	for _, ctx := range contexts {
+		if action, ok := km[ctx][seq]; ok {
+			return action, keyBound
+		}
+	}
+	for _, ctx := range contexts {
+		for bound := range km[ctx] {
+			if strings.HasPrefix(bound, seq+" ") {
+				return "", keyPrefix
+			}
+		}
+	}
+	return "", keyNone
+}
+
+// keyContexts returns the active contexts, most specific first.
+func (m AudioModel) keyContexts() []string {
+	var contexts []string
+	inTrack := m.commander.IsInTrackMode()
+	if m.uiMode == ModeViz && inTrack {
+		contexts = append(contexts, ctxViz)
+	}
+	if m.showResults {
+		contexts = append(contexts, ctxSearch)
+	}
+	if inTrack {
+		contexts = append(contexts, ctxTrack)
+	}
+	return append(contexts, ctxNormal)
+}
+
+// keyName names a key the way bindings are written.
+func keyName(msg tea.KeyMsg) string {
+	switch s := msg.String(); s {
+	case " ":
+		return "space"
+	case "ctrl+@":
+		// Terminals send ctrl+space as NUL.
+		return "ctrl+space"
+	default:
+		return s
+	}
+}
+
+// editsInput reports whether msg is a key that types or moves within the command line.
+// Such keys only act as bindings while the command line is empty.
+func editsInput(msg tea.KeyMsg) bool {
+	switch msg.Type {
+	case tea.KeyRunes, tea.KeySpace:
+		return !msg.Alt
+	case tea.KeyLeft, tea.KeyRight, tea.KeyUp, tea.KeyDown, tea.KeyHome, tea.KeyEnd,
+		tea.KeyEnter, tea.KeyBackspace, tea.KeyDelete:
+		return !msg.Alt
+	}
+	return false
+}
+
+// startsCommand reports whether msg is a letter or digit, either of which may begin a
+// command. Outside visualization mode such keys always type, so they can't start a binding.
+func startsCommand(msg tea.KeyMsg) bool {
+	if msg.Type != tea.KeyRunes || msg.Alt || len(msg.Runes) != 1 {
+		return false
+	}
+	return unicode.IsLetter(msg.Runes[0]) || unicode.IsDigit(msg.Runes[0])
+}
+
+// handleKeyBinding runs the binding for msg, or records it as the start of a key sequence.
+// It reports whether the key was consumed.
+func (m *AudioModel) handleKeyBinding(msg tea.KeyMsg) (bool, tea.Cmd) {
+	if m.searchMode {
+		return false, nil
+	}
+	if m.pendingKeys == "" && editsInput(msg) && m.getInputValue() != "" {
+		return false, nil
+	}
+
+	contexts := m.keyContexts()
+	if m.pendingKeys == "" && startsCommand(msg) {
+		if contexts[0] != ctxViz {
+			return false, nil
+		}
+		contexts = contexts[:1]
+	}
+
+	seq := keyName(msg)
+	if m.pendingKeys != "" {
+		seq = m.pendingKeys + " " + seq
+	}
+	action, match := m.keymap.lookup(contexts, seq)
+	switch match {
+	case keyBound:
+		m.pendingKeys = ""
+		return true, m.runKeyAction(action)
+	case keyPrefix:
+		m.pendingKeys = seq
+		return true, nil
+	}
+
+	// An unbound sequence is dropped; the last key may still be bound on its own.
+	if m.pendingKeys != "" {
+		m.pendingKeys = ""
+		return m.handleKeyBinding(msg)
+	}
+	return false, nil
+}
+
+// runKeyAction performs a built-in action, or runs action as a command line.
+func (m *AudioModel) runKeyAction(action string) tea.Cmd {
+	switch action {
+	case "toggle-mode":
+		if m.uiMode == ModeFull {
+			m.uiMode = ModeMini
+		} else {
+			m.uiMode = ModeFull
+		}
+	case "toggle-viz":
+		if m.uiMode == ModeViz {
+			m.uiMode = ModeFull
+			return nil
+		}
+		if !m.commander.IsInTrackMode() {
+			m.mainOutput = "Error: no track loaded"
+			return nil
+		}
+		return m.runCommand("viz")
+	case "exit-viz":
+		m.uiMode = ModeFull
+	case "next-viz", "prev-viz", "zoom-in", "zoom-out", "scroll-left", "scroll-right", "reset-viz":
+		input := map[string]string{
+			"next-viz":     "next",
+			"prev-viz":     "prev",
+			"zoom-in":      "zoom-in",
+			"zoom-out":     "zoom-out",
+			"scroll-left":  "left",
+			"scroll-right": "right",
+			"reset-viz":    "reset",
+		}[action]
+		m.commander.GetProcessor().HandleVisualizationInput(input)
+	case "clear":
+		m.mainOutput = ""
+	case "show-keys":
+		m.mainOutput = m.showKeys()
+	case "result-up":
+		m.moveResultCursor(-1)
+	case "result-down":
+		m.moveResultCursor(1)
+	case "result-play":
+		cmdStr := fmt.Sprintf("play %d", m.resultCursor+1)
+		m.addHistory(cmdStr)
+		return m.runCommand(cmdStr)
+	case "result-enqueue":
+		out, err, _ := m.commander.Execute(fmt.Sprintf("enqueue %d", m.resultCursor+1))
+		m.mainOutput = m.renderResults()
+		if err != nil {
+			m.mainOutput += "\nError: " + err.Error()
+		} else {
+			m.mainOutput += "\n" + out
+		}
+	case "result-close":
+		m.showResults = false
+	default:
+		return m.runCommand(action)
+	}
+	return nil
+}
+
+// showKeys lists the bindings active right now, most specific context first. Bindings
+// hidden by a more specific context are left out.
+func (m AudioModel) showKeys() string {
+	titles := map[string]string{
+		ctxNormal: "General",
+		ctxTrack:  "Track",
+		ctxSearch: "Search Results",
+		ctxViz:    "Visualization",
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\nActive Key Bindings:\n")
+	seen := make(map[string]bool)
+	for _, ctx := range m.keyContexts() {
+		seqs := make([]string, 0, len(m.keymap[ctx]))
+		for seq := range m.keymap[ctx] {
+			if !seen[seq] {
+				seqs = append(seqs, seq)
+			}
+		}
+		if len(seqs) == 0 {
+			continue
+		}
+		sort.Strings(seqs)
+
+		sb.WriteString(fmt.Sprintf("\n%s:\n", titles[ctx]))
+		for _, seq := range seqs {
+			seen[seq] = true
+			action := m.keymap[ctx][seq]
+			if desc, ok := keyActions[action]; ok {
+				action = desc
+			}
+			sb.WriteString(fmt.Sprintf("  %-12s: %s\n", seq, action))
+		}
+	}
+
+	sb.WriteString("\nKeys that type text act as bindings only while the command line is empty,\n")
+	sb.WriteString("and letters and digits only in visualization mode.\n")
+	sb.WriteString(fmt.Sprintf("Rebind keys under [keys.<context>] in the config file (contexts: %s).\n",
+		strings.Join(config.KeyContexts, ", ")))
+	return sb.String()
+}
@@ -0,0 +1,280 @@
+package ui
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestKeymapLookup(t *testing.T) {
+	km := keymap{
+		ctxNormal: {"ctrl+g g": "general-seq", "ctrl+x": "general-x", "esc": "general-esc"},
+		ctxTrack:  {"ctrl+x": "track-x", "ctrl+g h": "track-seq"},
+		ctxViz:    {"ctrl+g": "viz-g"},
+	}
+	track := []string{ctxTrack, ctxNormal}
+	viz := []string{ctxViz, ctxTrack, ctxNormal}
+
+	tests := []struct {
+		name       string
+		contexts   []string
+		seq        string
+		wantAction string
+		wantMatch  keyMatch
+	}{
+		{"general only", []string{ctxNormal}, "ctrl+x", "general-x", keyBound},
+		{"specific overrides general", track, "ctrl+x", "track-x", keyBound},
+		{"general reached through specific", track, "esc", "general-esc", keyBound},
+		{"prefix in either context", track, "ctrl+g", "", keyPrefix},
+		{"sequence in the general context", track, "ctrl+g g", "general-seq", keyBound},
+		{"sequence in the specific context", track, "ctrl+g h", "track-seq", keyBound},
+		{"binding beats a longer sequence", viz, "ctrl+g", "viz-g", keyBound},
+		{"unbound sequence", track, "ctrl+g z", "", keyNone},
+		{"sequence in an inactive context", []string{ctxNormal}, "ctrl+g h", "", keyNone},
+		{"unbound key", viz, "ctrl+y", "", keyNone},
+	}
+	for _, tt := range tests {
+		action, match := km.lookup(tt.contexts, tt.seq)
+		if action != tt.wantAction || match != tt.wantMatch {
+			t.Errorf("%s: lookup(%v, %q) = %q, %v; want %q, %v",
+				tt.name, tt.contexts, tt.seq, action, match, tt.wantAction, tt.wantMatch)
+		}
+	}
+}
+
+func TestNewKeymap(t *testing.T) {
+	km := newKeymap(map[string]map[string]string{
+		ctxViz:    {"q": "", "x": "exit-viz"},
+		ctxTrack:  {"left": "seek -5s", "ctrl+l": "stop", "ctrl+q": ""},
+		ctxNormal: {"ctrl+g g": "toggle-viz"},
+	})
+	defaults := defaultKeymap()
+
+	tests := []struct {
+		contexts   []string
+		seq        string
+		wantAction string
+		wantMatch  keyMatch
+	}{
+		// An empty action unbinds the key; the other defaults stay.
+		{[]string{ctxViz}, "q", "", keyNone},
+		{[]string{ctxViz}, "esc", "exit-viz", keyBound},
+		{[]string{ctxViz}, "x", "exit-viz", keyBound},
+		{[]string{ctxTrack}, "left", "seek -5s", keyBound},
+		{[]string{ctxTrack, ctxNormal}, "ctrl+l", "stop", keyBound},
+		{[]string{ctxNormal}, "ctrl+l", "clear", keyBound},
+		// Unbinding a key the context doesn't bind leaves the general binding in place.
+		{[]string{ctxTrack, ctxNormal}, "ctrl+q", "quit", keyBound},
+		{[]string{ctxNormal}, "ctrl+g", "", keyPrefix},
+	}
+	for _, tt := range tests {
+		action, match := km.lookup(tt.contexts, tt.seq)
+		if action != tt.wantAction || match != tt.wantMatch {
+			t.Errorf("lookup(%v, %q) = %q, %v; want %q, %v",
+				tt.contexts, tt.seq, action, match, tt.wantAction, tt.wantMatch)
+		}
+	}
+	if defaults[ctxViz]["q"] != "exit-viz" || defaultKeymap()[ctxTrack]["left"] != "seek -10s" {
+		t.Error("newKeymap changed the defaults")
+	}
+	if _, ok := defaults[ctxViz]["v"]; ok {
+		t.Error(`"v" is still bound in visualization mode`)
+	}
+}
+
+// testWAV returns a mono 16-bit 8 kHz WAV file of a second of silence.
+func testWAV() []byte {
+	data := make([]byte, 16000)
+	b := []byte("RIFF")
+	b = binary.LittleEndian.AppendUint32(b, uint32(36+len(data)))
+	b = append(b, "WAVEfmt "...)
+	b = binary.LittleEndian.AppendUint32(b, 16)
+	b = binary.LittleEndian.AppendUint16(b, 1)
+	b = binary.LittleEndian.AppendUint16(b, 1)
+	b = binary.LittleEndian.AppendUint32(b, 8000)
+	b = binary.LittleEndian.AppendUint32(b, 16000)
+	b = binary.LittleEndian.AppendUint16(b, 2)
+	b = binary.LittleEndian.AppendUint16(b, 16)
+	b = append(b, "data"...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(data)))
+	return append(b, data...)
+}
+
+// testModel returns a model with the given bindings configured and a track loaded, using
+// a fresh home directory so the user's settings and history stay out of it.
+func testModel(t *testing.T, configured map[string]map[string]string) *AudioModel {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+
+	m := NewModel()
+	t.Cleanup(func() { m.commander.GetProcessor().Close() })
+	m.keymap = newKeymap(configured)
+
+	path := filepath.Join(home, "track.wav")
+	if err := os.WriteFile(path, testWAV(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err, _ := m.commander.Execute("load " + path); err != nil {
+		t.Fatal(err)
+	}
+	if !m.commander.IsInTrackMode() {
+		t.Fatal("loading the track didn't enter track mode")
+	}
+	return &m
+}
+
+func runes(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func TestHandleKeyBinding(t *testing.T) {
+	configured := map[string]map[string]string{
+		ctxNormal: {"ctrl+g g": "toggle-mode", "ctrl+g h": "show-keys", "z": "toggle-mode", "ctrl+l": "clear"},
+		ctxTrack:  {"ctrl+o": "clear", "ctrl+g ctrl+o": "clear"},
+		ctxViz:    {"ctrl+o": "exit-viz"},
+	}
+	type step struct {
+		key      tea.KeyMsg
+		consumed bool
+		pending  string
+	}
+	tests := []struct {
+		name    string
+		mode    UIMode
+		input   string
+		results bool
+		steps   []step
+		want    UIMode
+		cleared bool
+	}{
+		{
+			name: "sequence from the general context",
+			mode: ModeFull,
+			steps: []step{
+				{tea.KeyMsg{Type: tea.KeyCtrlG}, true, "ctrl+g"},
+				{runes("g"), true, ""},
+			},
+			want: ModeMini,
+		},
+		{
+			name: "sequence from the specific context",
+			mode: ModeFull,
+			steps: []step{
+				{tea.KeyMsg{Type: tea.KeyCtrlG}, true, "ctrl+g"},
+				{tea.KeyMsg{Type: tea.KeyCtrlO}, true, ""},
+			},
+			want:    ModeFull,
+			cleared: true,
+		},
+		{
+			name: "unbound sequence replays its last key",
+			mode: ModeFull,
+			steps: []step{
+				{tea.KeyMsg{Type: tea.KeyCtrlG}, true, "ctrl+g"},
+				{tea.KeyMsg{Type: tea.KeyCtrlL}, true, ""},
+			},
+			want:    ModeFull,
+			cleared: true,
+		},
+		{
+			name: "unbound sequence whose last key types",
+			mode: ModeFull,
+			steps: []step{
+				{tea.KeyMsg{Type: tea.KeyCtrlG}, true, "ctrl+g"},
+				{runes("x"), false, ""},
+			},
+			want: ModeFull,
+		},
+		{
+			name:  "specific context overrides general",
+			mode:  ModeViz,
+			steps: []step{{tea.KeyMsg{Type: tea.KeyCtrlO}, true, ""}},
+			want:  ModeFull,
+		},
+		{
+			name:  "letters type outside visualization mode",
+			mode:  ModeFull,
+			steps: []step{{runes("z"), false, ""}, {runes("q"), false, ""}},
+			want:  ModeFull,
+		},
+		{
+			name:  "letters act in visualization mode",
+			mode:  ModeViz,
+			steps: []step{{runes("q"), true, ""}},
+			want:  ModeFull,
+		},
+		{
+			name:  "only visualization bindings take letters there",
+			mode:  ModeViz,
+			steps: []step{{runes("z"), false, ""}, {runes("v"), false, ""}},
+			want:  ModeViz,
+		},
+		{
+			name:  "symbols act with an empty command line",
+			mode:  ModeViz,
+			steps: []step{{runes("+"), true, ""}},
+			want:  ModeViz,
+		},
+		{
+			name:  "editing keys type into a command line",
+			mode:  ModeViz,
+			input: "seek",
+			steps: []step{{runes("+"), false, ""}, {tea.KeyMsg{Type: tea.KeyLeft}, false, ""}},
+			want:  ModeViz,
+		},
+		{
+			name:  "other keys act over a command line",
+			mode:  ModeViz,
+			input: "seek",
+			steps: []step{{tea.KeyMsg{Type: tea.KeyEsc}, true, ""}},
+			want:  ModeFull,
+		},
+		{
+			name:    "result list over a command line",
+			mode:    ModeFull,
+			input:   "play",
+			results: true,
+			steps:   []step{{tea.KeyMsg{Type: tea.KeyUp}, false, ""}, {tea.KeyMsg{Type: tea.KeyEsc}, true, ""}},
+			want:    ModeFull,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := testModel(t, configured)
+			m.uiMode = tt.mode
+			m.setInputValue(tt.input)
+			m.showResults = tt.results
+			m.mainOutput = "output"
+
+			for i, s := range tt.steps {
+				consumed, _ := m.handleKeyBinding(s.key)
+				if consumed != s.consumed || m.pendingKeys != s.pending {
+					t.Errorf("key %d (%s): consumed %v, pending %q; want %v, %q",
+						i+1, s.key, consumed, m.pendingKeys, s.consumed, s.pending)
+				}
+			}
+			if m.uiMode != tt.want {
+				t.Errorf("mode = %v, want %v", m.uiMode, tt.want)
+			}
+			if cleared := m.mainOutput == ""; cleared != tt.cleared {
+				t.Errorf("output cleared = %v, want %v", cleared, tt.cleared)
+			}
+			if tt.results && m.showResults {
+				t.Error("esc left the result list open")
+			}
+		})
+	}
+}
+
+func TestHandleKeyBindingIgnoresKeysDuringHistorySearch(t *testing.T) {
+	m := testModel(t, nil)
+	m.searchMode = true
+	if consumed, _ := m.handleKeyBinding(tea.KeyMsg{Type: tea.KeyCtrlL}); consumed {
+		t.Error("a binding ran during Ctrl+R search")
+	}
+}
@@ -59,14 +59,23 @@ type AudioModel struct {
 	tabOutput  string
 
 	// History
-	history    []string
+	history    *cmdHistory
 	historyPos int
 
 	// Key states
-	exitPrompt  bool
-	uiMode      UIMode
-	searchMode  bool
-	searchQuery string // used if we want to store typed query
+	exitPrompt bool
+	uiMode     UIMode
+
+	// Ctrl+R reverse history search: the index of the current match (-1 for none), whether
+	// the last search found nothing, and the input to restore when the search is cancelled
+	searchMode   bool
+	searchMatch  int
+	searchFailed bool
+	searchSaved  string
+
+	// Interactive list of the last search results
+	showResults  bool
+	resultCursor int
 
 	// Visualization
 	vizEnabled     bool
@@ -81,11 +90,14 @@ type AudioModel struct {
 	// Tab-completion
 	tabState *TabState
 
-	// Keyboard shortcuts map
-	shortcuts map[string]string
+	// Key bindings per context, and the keys typed so far of a multi-key sequence
+	keymap      keymap
+	pendingKeys string
 
 	// Whether to show the "full info" (raw tags, no artwork) vs. partial
 	showFullInfo bool
+	// The track info last built, to tell whether it is still on screen
+	trackInfo string
 }
 
 // NewModel creates a new TUI model with defaults.
@@ -114,63 +126,57 @@ func NewModel() AudioModel {
 		BorderStyle(lipgloss.NormalBorder()).
 		BorderForeground(lipgloss.Color("240"))
 
-	// Define default keyboard shortcuts
-	defaultShortcuts := map[string]string{
-		"ctrl+q":     "quit",
-		"ctrl+p":     "play",
-		"ctrl+s":     "stop",
-		"ctrl+space": "pause",
-		"ctrl+m":     "toggle-mode",
-		"ctrl+l":     "clear",
-		"ctrl+u":     "volume-up",
-		"ctrl+d":     "volume-down",
-		"v":          "toggle-viz",
-		"tab":        "next-viz",
-		"shift+tab":  "prev-viz",
-		"+":          "zoom-in",
-		"-":          "zoom-out",
-		"left":       "scroll-left",
-		"right":      "scroll-right",
-		"0":          "reset-viz",
-		"esc":        "exit-viz",
+	commander := commands.NewCommander()
+	welcome := "Welcome to gowav! Type 'help' for commands.\nPress '?' to show key bindings."
+	if err := commander.ConfigError(); err != nil {
+		welcome += fmt.Sprintf("\n\nWarning: using default settings: %v", err)
 	}
 
 	return AudioModel{
 		input:          input,
-		commander:      commands.NewCommander(),
+		commander:      commander,
 		progress:       p,
 		spinner:        s,
 		style:          style,
-		history:        make([]string, 0),
+		history:        newHistory(commander.Config().History.Size),
 		historyPos:     -1,
-		mainOutput:     "Welcome to gowav! Type 'help' for commands.\nPress '?' to show shortcuts.",
+		mainOutput:     welcome,
 		lastUpdateTime: time.Now(),
 		uiMode:         ModeFull,
 		loadingState:   &types.LoadingState{},
-		shortcuts:      defaultShortcuts,
+		keymap:         newKeymap(commander.Config().Bindings),
 	}
 }
 
+// Close saves what the player writes to disk lazily. Call it once the program has exited.
+func (m AudioModel) Close() error {
+	return m.commander.Close()
+}
+
 // Init returns any initial commands to run.
 func (m AudioModel) Init() tea.Cmd {
 	return tea.Batch(
 		textinput.Blink,
 		spinner.Tick,
+		m.commander.WaitProcessorEvent(),
 	)
 }
 
 // BuildMetadataOutput chooses partial or full table based on m.showFullInfo.
 // If full, we show raw tags (no artwork). If partial, we show artwork if there's space.
+// The result is remembered in m.trackInfo.
 func (m *AudioModel) BuildMetadataOutput(meta *audio.Metadata) string {
 	if m.showFullInfo {
 		// Full table with raw tags, no artwork
 		out := meta.AdaptiveStringWithRaw(m.width, m.height)
 		out += m.buildPlaybackStatus()
+		m.trackInfo = out
 		return out
 	} else {
 		// Partial table with optional side-by-side artwork
 		out := meta.BuildLoadInfo(m.width, m.height)
 		out += m.buildPlaybackStatus()
+		m.trackInfo = out
 		return out
 	}
 }
@@ -188,9 +194,19 @@ func (m *AudioModel) buildPlaybackStatus() string {
 	var sb strings.Builder
 	sb.WriteString("\n\nPlayback Status:\n")
 	sb.WriteString(fmt.Sprintf("State: %s\n", formatPlaybackState(state)))
-	sb.WriteString(fmt.Sprintf("Position: %s\n", localFormatDuration(position)))
-	sb.WriteString(fmt.Sprintf("Duration: %s\n", localFormatDuration(duration)))
+	if player.IsLive() {
+		sb.WriteString(fmt.Sprintf("Listening for: %s\n", localFormatDuration(position)))
+	} else {
+		sb.WriteString(fmt.Sprintf("Position: %s\n", localFormatDuration(position)))
+		sb.WriteString(fmt.Sprintf("Duration: %s\n", localFormatDuration(duration)))
+	}
 	sb.WriteString("\n" + player.RenderTrackBar(60))
+	if health := m.commander.BufferHealth(); health != "" {
+		sb.WriteString("\n" + health)
+	}
+	if q := m.commander.QueueSummary(); q != "" {
+		sb.WriteString("\n" + q)
+	}
 	return sb.String()
 }
 
@@ -0,0 +1,73 @@
+package ui
+
+import (
+	"fmt"
+	"gowav/internal/commands"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// resultHeaderLines is how many lines renderResults prints before the first result.
+const resultHeaderLines = 2
+
+var selectedResultStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("205"))
+
+// openResults shows the interactive list for the results of the search that just ran.
+func (m *AudioModel) openResults() {
+	m.showResults = true
+	m.resultCursor = 0
+	m.mainOutput = m.renderResults()
+	m.viewport.SetContent(m.mainOutput)
+	m.viewport.GotoTop()
+}
+
+// moveResultCursor moves the selection by delta, wrapping around, and scrolls it into view.
+func (m *AudioModel) moveResultCursor(delta int) {
+	n := len(m.commander.GetSearchResults())
+	if n == 0 {
+		m.showResults = false
+		return
+	}
+	m.resultCursor = ((m.resultCursor+delta)%n + n) % n
+	m.mainOutput = m.renderResults()
+
+	m.viewport.SetContent(m.mainOutput)
+	line := resultHeaderLines + m.resultCursor
+	if notice := m.commander.SearchNotice(); notice != "" {
+		line += 2
+	}
+	if line < m.viewport.YOffset {
+		m.viewport.SetYOffset(line)
+	} else if line >= m.viewport.YOffset+m.viewport.Height {
+		m.viewport.SetYOffset(line - m.viewport.Height + 1)
+	}
+}
+
+// renderResults lists the search results one per line with the selection highlighted.
+func (m *AudioModel) renderResults() string {
+	results := m.commander.GetSearchResults()
+
+	var sb strings.Builder
+	if notice := m.commander.SearchNotice(); notice != "" {
+		sb.WriteString(notice + "\n\n")
+	}
+	sb.WriteString(fmt.Sprintf("Found %d results (↑/↓ select, Enter play, Ctrl+E enqueue, Esc close):\n\n", len(results)))
+	for i, r := range results {
+		line := fmt.Sprintf("%2d. %s", i+1, r.Label())
+		if r.Album != "" {
+			line += " · " + r.Album
+		}
+		line += fmt.Sprintf("  %s [%s]", commands.FormatDuration(time.Duration(r.Duration)*time.Second), r.Source)
+		if i == m.resultCursor {
+			sb.WriteString(selectedResultStyle.Render("▶ "+line) + "\n")
+		} else {
+			sb.WriteString("  " + line + "\n")
+		}
+	}
+	if info := m.commander.SearchPageInfo(); info != "" {
+		sb.WriteString("\n" + info + "\n")
+	}
+	return sb.String()
+}
@@ -2,9 +2,11 @@ package ui
 
 import (
 	"fmt"
+	"gowav/pkg/utils"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -17,6 +19,7 @@ const (
 	CompletionFile
 	CompletionVisualization
 	CompletionPlayback
+	CompletionResult
 )
 
 // TabState holds the current state of partial completions in progress, such as which suggestion index we’re on.
@@ -51,7 +54,31 @@ var completionDefs = []CompletionDef{
 		Command:     "play",
 		Aliases:     []string{"p"},
 		Type:        CompletionPlayback,
-		Description: "Play current track",
+		Description: "Play current track or search result <n>",
+	},
+	{
+		Command:     "album",
+		Aliases:     []string{},
+		Type:        CompletionCommand,
+		Description: "Browse an online album",
+	},
+	{
+		Command:     "artist",
+		Aliases:     []string{},
+		Type:        CompletionCommand,
+		Description: "Browse an online artist",
+	},
+	{
+		Command:     "more",
+		Aliases:     []string{},
+		Type:        CompletionCommand,
+		Description: "Next page of search results",
+	},
+	{
+		Command:     "enqueue",
+		Aliases:     []string{},
+		Type:        CompletionPlayback,
+		Description: "Queue search results",
 	},
 	{
 		Command:     "viz",
@@ -84,12 +111,116 @@ var completionDefs = []CompletionDef{
 		Type:        CompletionPlayback,
 		Description: "Stop playback",
 	},
+	{
+		Command:     "seek",
+		Aliases:     []string{},
+		Type:        CompletionPlayback,
+		Description: "Seek to a position",
+	},
+	{
+		Command:     "volume",
+		Aliases:     []string{"vol"},
+		Type:        CompletionPlayback,
+		Description: "Set playback volume",
+	},
+	{
+		Command:     "mute",
+		Aliases:     []string{},
+		Type:        CompletionPlayback,
+		Description: "Mute output",
+	},
+	{
+		Command:     "unmute",
+		Aliases:     []string{},
+		Type:        CompletionPlayback,
+		Description: "Unmute output",
+	},
+	{
+		Command:     "queue",
+		Aliases:     []string{},
+		Type:        CompletionCommand,
+		SubCommands: []string{"add", "list", "remove", "move", "play", "clear"},
+		Description: "Manage the play queue",
+	},
+	{
+		Command:     "next",
+		Aliases:     []string{},
+		Type:        CompletionPlayback,
+		Description: "Next queued track",
+	},
+	{
+		Command:     "prev",
+		Aliases:     []string{},
+		Type:        CompletionPlayback,
+		Description: "Previous queued track",
+	},
+	{
+		Command:     "shuffle",
+		Aliases:     []string{},
+		Type:        CompletionPlayback,
+		SubCommands: []string{"on", "off"},
+		Description: "Shuffle the queue",
+	},
+	{
+		Command:     "repeat",
+		Aliases:     []string{},
+		Type:        CompletionPlayback,
+		SubCommands: []string{"off", "one", "all"},
+		Description: "Set repeat mode",
+	},
+	{
+		Command:     "playlist",
+		Aliases:     []string{"pl"},
+		Type:        CompletionCommand,
+		SubCommands: []string{"load", "save"},
+		Description: "Load or save a playlist",
+	},
+	{
+		Command:     "library",
+		Aliases:     []string{"lib"},
+		Type:        CompletionCommand,
+		SubCommands: []string{"scan", "artists", "albums", "tracks"},
+		Description: "Scan and browse the local library",
+	},
+	{
+		Command:     "config",
+		Aliases:     []string{},
+		Type:        CompletionCommand,
+		SubCommands: []string{"get", "set", "reload"},
+		Description: "Show or change settings",
+	},
+	{
+		Command:     "podcast",
+		Aliases:     []string{"pod"},
+		Type:        CompletionCommand,
+		SubCommands: []string{"add", "list", "episodes", "play", "refresh"},
+		Description: "Subscribe to podcasts and play episodes",
+	},
+	{
+		Command:     "download",
+		Aliases:     []string{},
+		Type:        CompletionCommand,
+		Description: "Keep a search result or URL for offline play",
+	},
+	{
+		Command:     "cache",
+		Aliases:     []string{},
+		Type:        CompletionCommand,
+		SubCommands: []string{"ls", "clear"},
+		Description: "List or clear cached tracks",
+	},
 	{
 		Command:     "artwork",
 		Aliases:     []string{"art"},
 		Type:        CompletionCommand,
 		Description: "Show album artwork",
 	},
+	{
+		Command:     "export",
+		Aliases:     []string{},
+		Type:        CompletionCommand,
+		Description: "Save the current visualization as text",
+	},
 }
 
 // handleTabCompletion decides how to autocomplete the user’s input, depending on whether it’s a command or subcommand.
@@ -121,6 +252,12 @@ func (m *AudioModel) handleTabCompletion() {
 		return
 	}
 
+	// After a search, "play", "load" and "enqueue" complete over the numbered results.
+	if m.wantsResultCompletion(matchingDef, parts) {
+		m.handleResultCompletion(parts)
+		return
+	}
+
 	// Depending on the type (File, Visualization, etc.), handle completions.
 	switch matchingDef.Type {
 	case CompletionFile:
@@ -164,6 +301,59 @@ func (m *AudioModel) handleCommandCompletion(partial string) {
 	m.updateTabState(completions, CompletionCommand, "", "")
 }
 
+// wantsResultCompletion reports whether the argument being typed refers to a search result
+// rather than, for "load", a file path.
+func (m *AudioModel) wantsResultCompletion(def *CompletionDef, parts []string) bool {
+	if len(m.commander.GetSearchResults()) == 0 {
+		return false
+	}
+	switch def.Command {
+	case "play", "enqueue":
+		return true
+	case "load":
+		if len(parts) == 1 {
+			return true
+		}
+		arg := strings.Join(parts[1:], " ")
+		return !strings.ContainsAny(arg[:1], "/.~") && !strings.Contains(arg, string(os.PathSeparator))
+	}
+	return false
+}
+
+// handleResultCompletion offers search results matching the typed number or title words,
+// and fills in the chosen result's number.
+func (m *AudioModel) handleResultCompletion(parts []string) {
+	partial := strings.Join(parts[1:], " ")
+	var completions []string
+	for i, r := range m.commander.GetSearchResults() {
+		num := strconv.Itoa(i + 1)
+		label := r.Label()
+		if partial == "" || strings.HasPrefix(num, partial) || utils.FuzzyScore(partial, label) > 0 {
+			completions = append(completions, num+". "+label)
+		}
+	}
+	if len(completions) == 0 {
+		m.clearTabCompletion()
+		return
+	}
+
+	// Like viz completion, repeated Tabs cycle through the list while the command stays the same.
+	if m.tabState == nil || m.tabState.Command != parts[0] || m.tabState.Type != CompletionResult {
+		m.tabState = &TabState{
+			Completions:   completions,
+			OriginalInput: partial,
+			Command:       parts[0],
+			Type:          CompletionResult,
+		}
+	} else {
+		m.tabState.CurrentIndex = (m.tabState.CurrentIndex + 1) % len(m.tabState.Completions)
+		m.tabState.HasTabbed = true
+	}
+
+	m.updateInputWithCompletion()
+	m.formatCompletionsDisplay()
+}
+
 // handleVizCompletion autocompletes subcommands like "viz wave", "viz spectrum", etc.
 func (m *AudioModel) handleVizCompletion(def *CompletionDef, parts []string) {
 	var partial string
@@ -274,6 +464,9 @@ func (m *AudioModel) updateInputWithCompletion() {
 		m.input.SetValue(fmt.Sprintf("%s %s", m.tabState.Command, current))
 	case CompletionVisualization:
 		m.input.SetValue(fmt.Sprintf("%s %s", m.tabState.Command, current))
+	case CompletionResult:
+		num, _, _ := strings.Cut(current, ".")
+		m.input.SetValue(fmt.Sprintf("%s %s", m.tabState.Command, num))
 	default:
 		// For other types (e.g. no completions), do nothing special.
 	}
@@ -295,6 +488,8 @@ func (m *AudioModel) formatCompletionsDisplay() {
 		sb.WriteString("\nFiles:\n")
 	case CompletionVisualization:
 		sb.WriteString("\nVisualization Types:\n")
+	case CompletionResult:
+		sb.WriteString("\nSearch Results:\n")
 	default:
 		sb.WriteString("\nCompletions:\n")
 	}
@@ -342,6 +537,9 @@ func (m *AudioModel) formatCompletionsDisplay() {
 				}
 			}
 			sb.WriteString("\n")
+		} else if m.tabState.Type == CompletionResult {
+			// Titles vary too much in length for columns.
+			sb.WriteString("\n")
 		} else {
 			// Try a multi-column layout
 			if (i+1)%columns != 0 && i < len(m.tabState.Completions)-1 {
@@ -405,7 +603,7 @@ func getFilesystemCompletions(path string) []string {
 func isAudioFile(name string) bool {
 	ext := strings.ToLower(filepath.Ext(name))
 	switch ext {
-	case ".mp3", ".wav", ".flac", ".ogg", ".m4a", ".aac":
+	case ".mp3", ".wav", ".flac", ".ogg", ".aif", ".aiff", ".m4a", ".aac":
 		return true
 	default:
 		return false
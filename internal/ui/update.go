@@ -12,13 +12,6 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-// downloadMsg is used for streaming or downloading progress updates.
-type downloadMsg struct {
-	url      string
-	progress float64
-	err      error
-}
-
 // progressMsg is for manual progress (rarely used).
 type progressMsg float64
 
@@ -40,20 +33,62 @@ func (m AudioModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	//----------------------------------------------------------------------
-	// downloadMsg: streaming or download progress
+	// Playback ticks and end of track
 	//----------------------------------------------------------------------
-	case downloadMsg:
-		if msg.err != nil {
-			m.loadingState.IsLoading = false
-			m.mainOutput = fmt.Sprintf("Download/Stream error: %v", msg.err)
-			return m, nil
+	case commands.PlaybackUpdateMsg:
+		return m, m.commander.NextPlaybackUpdate()
+
+	case commands.TrackEndedMsg:
+		out, c2 := m.commander.HandleTrackEnded()
+		if meta := m.commander.GetProcessor().GetMetadata(); meta != nil && m.uiMode != ModeViz {
+			m.mainOutput = m.BuildMetadataOutput(meta)
 		}
-		m.loadingState.IsLoading = true
-		m.loadingState.Message = fmt.Sprintf("Downloading... %.1f%%", msg.progress*100)
-		m.loadingState.Progress = msg.progress
-		if msg.progress >= 1.0 {
-			m.loadingState.IsLoading = false
-			m.mainOutput = "Download complete."
+		if out != "" {
+			m.mainOutput = strings.TrimRight(m.mainOutput, "\n") + "\n\n" + out
+		}
+		return m, c2
+
+	case commands.TrackLoadedMsg:
+		out, err, c2 := m.commander.HandleTrackLoaded(msg)
+		m.syncLoadingStateFromProcessor(m.commander.GetProcessor().GetStatus())
+		if err != nil {
+			m.mainOutput = fmt.Sprintf("Error: %v", err)
+		} else if meta := m.commander.GetProcessor().GetMetadata(); meta != nil && m.uiMode != ModeViz {
+			m.mainOutput = m.BuildMetadataOutput(meta)
+		} else if out != "" {
+			m.mainOutput = out
+		}
+		return m, c2
+
+	case commands.ProcessorEventMsg:
+		return m, tea.Batch(m.handleProcessorEvent(msg.Event), m.commander.WaitProcessorEvent())
+
+	case commands.ConfigChangedMsg:
+		m.keymap = newKeymap(m.commander.Config().Bindings)
+		m.history.setLimit(m.commander.Config().History.Size)
+		return m, nil
+
+	case commands.LibraryScanDoneMsg:
+		if msg.Err != nil {
+			m.mainOutput = fmt.Sprintf("Error: library scan failed: %v", msg.Err)
+		} else {
+			m.mainOutput = msg.Result.String()
+		}
+		return m, nil
+
+	case commands.PodcastFetchDoneMsg:
+		if msg.Err != nil {
+			m.mainOutput = fmt.Sprintf("Error: %v", msg.Err)
+		} else {
+			m.mainOutput = msg.Summary
+		}
+		return m, nil
+
+	case commands.DownloadDoneMsg:
+		if msg.Err != nil {
+			m.mainOutput = fmt.Sprintf("Error: %v", msg.Err)
+		} else {
+			m.mainOutput = msg.Summary
 		}
 		return m, nil
 
@@ -68,34 +103,12 @@ func (m AudioModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	// Key events
 	//----------------------------------------------------------------------
 	case tea.KeyMsg:
-		if m.uiMode == ModeViz && m.commander.IsInTrackMode() {
-			// Visualization shortcuts
-			switch msg.String() {
-			case "esc", "q":
-				m.uiMode = ModeFull
-				return m, nil
-			case "tab":
-				m.commander.GetProcessor().HandleVisualizationInput("next")
-				return m, nil
-			case "shift+tab":
-				m.commander.GetProcessor().HandleVisualizationInput("prev")
-				return m, nil
-			case "+", "=":
-				m.commander.GetProcessor().HandleVisualizationInput("zoom-in")
-				return m, nil
-			case "-", "_":
-				m.commander.GetProcessor().HandleVisualizationInput("zoom-out")
-				return m, nil
-			case "left", "h":
-				m.commander.GetProcessor().HandleVisualizationInput("left")
-				return m, nil
-			case "right", "l":
-				m.commander.GetProcessor().HandleVisualizationInput("right")
-				return m, nil
-			case "0":
-				m.commander.GetProcessor().HandleVisualizationInput("reset")
-				return m, nil
-			}
+		if m.searchMode {
+			return m, m.handleHistorySearchKey(msg)
+		}
+		if consumed, c2 := m.handleKeyBinding(msg); consumed {
+			m.exitPrompt = false
+			return m, c2
 		}
 
 		// Normal keys
@@ -136,125 +149,36 @@ func (m AudioModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 
 		case tea.KeyUp:
-			if msg.Alt {
-				out, err, cmd := m.commander.Execute("volume-up")
-				if err != nil {
-					m.mainOutput = fmt.Sprintf("Error: %v", err)
-				} else if out != "" {
-					m.mainOutput = out
-				}
-				if cmd != nil {
-					cmds = append(cmds, cmd)
-				}
-			} else {
-				if m.historyPos < len(m.history)-1 {
-					m.historyPos++
-					m.setInputValue(m.history[len(m.history)-1-m.historyPos])
-				}
+			if m.historyPos < len(m.history.entries)-1 {
+				m.historyPos++
+				m.setInputValue(m.history.entries[len(m.history.entries)-1-m.historyPos])
 			}
 
 		case tea.KeyDown:
-			if msg.Alt {
-				out, err, cmd := m.commander.Execute("volume-down")
-				if err != nil {
-					m.mainOutput = fmt.Sprintf("Error: %v", err)
-				} else if out != "" {
-					m.mainOutput = out
-				}
-				if cmd != nil {
-					cmds = append(cmds, cmd)
-				}
-			} else {
-				if m.historyPos > 0 {
-					m.historyPos--
-					m.setInputValue(m.history[len(m.history)-1-m.historyPos])
-				} else if m.historyPos == 0 {
-					m.historyPos = -1
-					m.setInputValue("")
-				}
+			if m.historyPos > 0 {
+				m.historyPos--
+				m.setInputValue(m.history.entries[len(m.history.entries)-1-m.historyPos])
+			} else if m.historyPos == 0 {
+				m.historyPos = -1
+				m.setInputValue("")
 			}
 
 		case tea.KeyCtrlR:
-			if !m.searchMode {
-				m.searchMode = true
-				m.searchQuery = ""
-				m.setInputValue("")
-				m.setInputPlaceholder("Search history...")
-			}
+			m.startHistorySearch()
+			return m, nil
 
 		case tea.KeyTab:
-			if !m.searchMode {
-				m.handleTabCompletion()
-			}
+			m.handleTabCompletion()
 
 		case tea.KeyEnter:
 			m.exitPrompt = false
-			m.mainOutput = strings.TrimSuffix(m.mainOutput, m.tabOutput)
-			cmdStr := m.getInputValue()
-			if cmdStr != "" {
-				if m.searchMode {
-					m.searchMode = false
-					m.setInputPlaceholder("Enter command (type 'help' for list)")
-					for i := len(m.history) - 1; i >= 0; i-- {
-						if strings.Contains(m.history[i], cmdStr) {
-							m.setInputValue(m.history[i])
-							break
-						}
-					}
-				} else {
-					if m.uiMode == ModeViz {
-						switch cmdStr {
-						case "q", "quit", "exit":
-							m.uiMode = ModeFull
-							m.setInputValue("")
-							return m, nil
-						case "help", "h", "?":
-							m.mainOutput = m.showVisualizationShortcuts()
-							m.setInputValue("")
-							return m, nil
-						}
-					}
-
-					if strings.HasPrefix(cmdStr, "http://") || strings.HasPrefix(cmdStr, "https://") {
-						return m, m.handleStreamStart(cmdStr)
-					}
-
-					out, err, c2 := m.commander.Execute(cmdStr)
-					if err != nil {
-						if !strings.Contains(err.Error(), "analysis in progress") &&
-							!strings.Contains(err.Error(), "analysis not complete") {
-							m.mainOutput = "Error: " + err.Error()
-						}
-					} else {
-						m.mainOutput = out
-						if strings.HasPrefix(cmdStr, "viz ") || cmdStr == "viz" {
-							m.uiMode = ModeViz
-						}
-					}
-					if c2 != nil {
-						cmds = append(cmds, c2)
-					}
-					m.history = append(m.history, cmdStr)
-					m.historyPos = -1
-					m.clearTabCompletion()
-					m.setInputValue("")
-				}
-			}
-
-		case tea.KeyRunes:
-			if msg.Runes[0] == '?' {
-				if m.uiMode == ModeViz {
-					m.mainOutput = m.showVisualizationShortcuts()
-				} else {
-					m.mainOutput = m.showShortcuts()
-				}
+			if cmdStr := m.getInputValue(); cmdStr != "" {
+				cmds = append(cmds, m.submit(cmdStr))
 			}
 
 		case tea.KeyEsc:
-			if m.searchMode {
-				m.searchMode = false
-				m.setInputPlaceholder("Enter command (type 'help' for list)")
-				m.setInputValue("")
+			if m.showResults {
+				m.showResults = false
 			}
 			if m.uiMode == ModeViz {
 				m.uiMode = ModeFull
@@ -268,19 +192,7 @@ func (m AudioModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.clearTabCompletion()
 			}
 		default:
-			out, err, c2 := m.handleShortcut(msg.String())
-			if err != nil {
-				m.mainOutput = fmt.Sprintf("Error: %v", err)
-			} else if out != "" {
-				m.mainOutput = out
-			}
-			if c2 != nil {
-				cmds = append(cmds, c2)
-			}
 			m.exitPrompt = false
-			if m.searchMode {
-				m.searchQuery = m.getInputValue()
-			}
 		}
 
 	//----------------------------------------------------------------------
@@ -322,36 +234,6 @@ func (m AudioModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	}
 
-	// Check Processor status
-	if p := m.commander.GetProcessor(); p != nil {
-		st := p.GetStatus()
-		switch st.State {
-		case audio.StateIdle:
-			if m.loadingState.IsLoading {
-				m.loadingState.IsLoading = false
-				m.loadingState.Message = ""
-				m.loadingState.Progress = 0
-				m.loadingState.CanCancel = false
-
-				// Show partial after load
-				m.showFullInfo = false
-				if meta := p.GetMetadata(); meta != nil {
-					m.mainOutput = m.BuildMetadataOutput(meta)
-				}
-			}
-
-		case audio.StateLoading:
-			m.loadingState.IsLoading = true
-			m.loadingState.Message = st.Message
-			m.loadingState.Progress = st.Progress
-			m.loadingState.StartTime = st.StartTime
-			m.loadingState.BytesLoaded = st.BytesLoaded
-			m.loadingState.FileSize = st.TotalBytes
-			m.loadingState.CanCancel = st.CanCancel
-		}
-		m.syncLoadingStateFromProcessor(st)
-	}
-
 	// Update input
 	var cmd tea.Cmd
 	m.input, cmd = m.input.Update(msg)
@@ -367,6 +249,34 @@ func (m AudioModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// handleProcessorEvent updates the loading state from a processor event, showing the
+// metadata once a load completes and any error. It restarts the spinner when work begins.
+func (m *AudioModel) handleProcessorEvent(ev audio.Event) tea.Cmd {
+	wasLoading := m.loadingState.IsLoading
+	m.syncLoadingStateFromProcessor(ev.Status)
+
+	switch ev.Kind {
+	case audio.EventMetadataReady:
+		// Show partial after load
+		m.showFullInfo = false
+		if meta := m.commander.GetProcessor().GetMetadata(); meta != nil && m.uiMode != ModeViz {
+			m.mainOutput = m.BuildMetadataOutput(meta)
+		}
+	case audio.EventMetadataChanged:
+		// A live station moved on to another song; refresh the track info if it is showing.
+		if meta := m.commander.GetProcessor().GetMetadata(); meta != nil && m.mainOutput == m.trackInfo {
+			m.mainOutput = m.BuildMetadataOutput(meta)
+		}
+	case audio.EventError:
+		m.mainOutput = "Error: " + ev.Status.Message
+	}
+
+	if !wasLoading && m.loadingState.IsLoading {
+		return m.spinner.Tick
+	}
+	return nil
+}
+
 // syncLoadingStateFromProcessor updates progress or message from processor status.
 func (m *AudioModel) syncLoadingStateFromProcessor(st audio.ProcessingStatus) {
 	switch st.State {
@@ -402,6 +312,59 @@ func (m *AudioModel) syncLoadingStateFromProcessor(st audio.ProcessingStatus) {
 	}
 }
 
+// submit runs a command line entered by the user and records it in the history.
+func (m *AudioModel) submit(cmdStr string) tea.Cmd {
+	m.mainOutput = strings.TrimSuffix(m.mainOutput, m.tabOutput)
+	m.clearTabCompletion()
+	m.setInputValue("")
+	m.addHistory(cmdStr)
+
+	if m.uiMode == ModeViz {
+		switch cmdStr {
+		case "q", "quit", "exit":
+			m.uiMode = ModeFull
+			return nil
+		case "help", "h", "?":
+			m.mainOutput = m.showKeys()
+			return nil
+		}
+	}
+	// A bare URL streams it, as with `load`.
+	if strings.HasPrefix(cmdStr, "http://") || strings.HasPrefix(cmdStr, "https://") {
+		return m.runCommand("load " + cmdStr)
+	}
+	return m.runCommand(cmdStr)
+}
+
+// runCommand executes a command line and shows its output, entering viz mode or opening
+// the result list when the command calls for it.
+func (m *AudioModel) runCommand(cmdStr string) tea.Cmd {
+	out, err, c2 := m.commander.Execute(cmdStr)
+	m.showResults = false
+	if err != nil {
+		if !strings.Contains(err.Error(), "analysis in progress") &&
+			!strings.Contains(err.Error(), "analysis not complete") {
+			m.mainOutput = "Error: " + err.Error()
+		}
+		return c2
+	}
+	m.mainOutput = out
+	if fields := strings.Fields(cmdStr); len(fields) > 0 && (fields[0] == "viz" || fields[0] == "v") {
+		m.uiMode = ModeViz
+	}
+	if isSearchCommand(cmdStr) && len(m.commander.GetSearchResults()) > 0 {
+		m.openResults()
+	}
+	return c2
+}
+
+// addHistory records an executed command line. Failing to save the history file isn't
+// worth interrupting the user for; the entry is still kept for this session.
+func (m *AudioModel) addHistory(cmdStr string) {
+	_ = m.history.add(cmdStr)
+	m.historyPos = -1
+}
+
 func (m *AudioModel) setInputValue(val string) {
 	m.input.SetValue(val)
 }
@@ -413,3 +376,16 @@ func (m *AudioModel) setInputPlaceholder(ph string) {
 func (m *AudioModel) getInputValue() string {
 	return m.input.Value()
 }
+
+// isSearchCommand reports whether cmdStr runs a search or fetches another page, whose results then open as a list.
+func isSearchCommand(cmdStr string) bool {
+	fields := strings.Fields(strings.TrimPrefix(cmdStr, ":"))
+	if len(fields) == 0 {
+		return false
+	}
+	switch strings.ToLower(fields[0]) {
+	case "search", "s", "more":
+		return true
+	}
+	return false
+}
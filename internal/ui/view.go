@@ -56,17 +56,16 @@ func (m AudioModel) miniView() string {
 	var sb strings.Builder
 
 	if m.commander.IsInTrackMode() {
-		track := m.commander.GetCurrentTrack()
-		sb.WriteString(fmt.Sprintf("\n%s - %s\n", track.Artist, track.Title))
+		if track := m.commander.GetCurrentTrack(); track != nil {
+			sb.WriteString(fmt.Sprintf("\n%s - %s\n", track.Artist, track.Title))
+		}
 		sb.WriteString(m.commander.GetPlaybackStatus())
 	}
-
-	inputPrefix := "> "
-	if m.searchMode {
-		inputPrefix = "search> "
+	if q := m.commander.QueueSummary(); q != "" {
+		sb.WriteString("\n" + q)
 	}
 
-	sb.WriteString(fmt.Sprintf("\n%s%s", inputPrefix, m.input.View()))
+	sb.WriteString(fmt.Sprintf("\n%s%s%s", m.getPrompt(), m.input.View(), m.searchSuffix()))
 	return sb.String()
 }
 
@@ -82,11 +81,7 @@ func (m AudioModel) fullView() string {
 	m.viewport.SetContent(content)
 	sb.WriteString(m.viewport.View())
 
-	inputPrefix := "> "
-	if m.searchMode {
-		inputPrefix = "search> "
-	}
-	sb.WriteString(fmt.Sprintf("\n%s%s", inputPrefix, m.input.View()))
+	sb.WriteString(fmt.Sprintf("\n%s%s%s", m.getPrompt(), m.input.View(), m.searchSuffix()))
 
 	if m.exitPrompt {
 		sb.WriteString("\nPress Ctrl+C again to exit or any other key to continue...")
@@ -100,8 +95,10 @@ func (m AudioModel) vizView() string {
 	var sb strings.Builder
 
 	if m.commander.IsInTrackMode() {
-		track := m.commander.GetCurrentTrack()
-		sb.WriteString(fmt.Sprintf("\n%s - %s\n", track.Artist, track.Title))
+		// The track is unset while the next one loads.
+		if track := m.commander.GetCurrentTrack(); track != nil {
+			sb.WriteString(fmt.Sprintf("\n%s - %s\n", track.Artist, track.Title))
+		}
 
 		// Render the visualization
 		vizContent := m.commander.GetProcessor().GetVisualization()
@@ -113,16 +110,30 @@ func (m AudioModel) vizView() string {
 	}
 
 	// Input line at bottom
-	sb.WriteString(fmt.Sprintf("\n%s%s", m.getPrompt(), m.input.View()))
+	sb.WriteString(fmt.Sprintf("\n%s%s%s", m.getPrompt(), m.input.View(), m.searchSuffix()))
 	return sb.String()
 }
 
 func (m AudioModel) getPrompt() string {
+	if m.pendingKeys != "" {
+		return m.pendingKeys + " -> "
+	}
 	if m.searchMode {
-		return "search> "
+		if m.searchFailed {
+			return "(failing reverse-i-search)`"
+		}
+		return "(reverse-i-search)`"
 	}
 	if m.uiMode == ModeViz {
 		return "viz> "
 	}
 	return "> "
 }
+
+// searchSuffix shows the current reverse search match after the query being typed.
+func (m AudioModel) searchSuffix() string {
+	if !m.searchMode {
+		return ""
+	}
+	return "': " + m.searchResult()
+}
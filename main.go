@@ -5,13 +5,22 @@ import (
 	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"gowav/internal/cli"
 	"gowav/internal/ui"
 )
 
 func main() {
-	p := tea.NewProgram(ui.NewModel())
+	if len(os.Args) > 1 {
+		os.Exit(cli.Run(os.Args[1:], os.Stdout, os.Stderr))
+	}
+
+	m := ui.NewModel()
+	p := tea.NewProgram(m)
 	if err := p.Start(); err != nil {
 		fmt.Printf("Error running program: %v\n", err)
 		os.Exit(1)
 	}
+	if err := m.Close(); err != nil {
+		fmt.Printf("Error saving state: %v\n", err)
+	}
 }
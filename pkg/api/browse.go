@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// AlbumDetail is an album together with its songs.
+type AlbumDetail struct {
+	Album
+	Songs []Song `json:"songs"`
+}
+
+// AuthorDetail is an author together with their albums and songs.
+type AuthorDetail struct {
+	Author
+	Albums []Album `json:"albums"`
+	Songs  []Song  `json:"songs"`
+}
+
+// maxImageSize caps artwork downloads.
+const maxImageSize = 10 << 20
+
+// GetAlbum fetches the album with the given slug and its track list.
+func (c *Client) GetAlbum(ctx context.Context, slug string) (*AlbumDetail, error) {
+	if slug == "" {
+		return nil, fmt.Errorf("empty album slug")
+	}
+	var album AlbumDetail
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/music/album/%s/", c.baseURL, url.PathEscape(slug)), &album); err != nil {
+		return nil, err
+	}
+	// Songs listed under an album usually omit the album itself.
+	for i := range album.Songs {
+		if album.Songs[i].Album.Slug == "" {
+			album.Songs[i].Album = album.Album
+		}
+	}
+	return &album, nil
+}
+
+// GetAuthor fetches the author with the given slug, with their albums and songs.
+func (c *Client) GetAuthor(ctx context.Context, slug string) (*AuthorDetail, error) {
+	if slug == "" {
+		return nil, fmt.Errorf("empty author slug")
+	}
+	var author AuthorDetail
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/music/author/%s/", c.baseURL, url.PathEscape(slug)), &author); err != nil {
+		return nil, err
+	}
+	for i := range author.Songs {
+		if len(author.Songs[i].Authors) == 0 {
+			author.Songs[i].Authors = []Author{author.Author}
+		}
+	}
+	return &author, nil
+}
+
+// FetchImage downloads an image such as an ImageCropped link. Relative links are
+// resolved against the API host.
+func (c *Client) FetchImage(ctx context.Context, link string) ([]byte, error) {
+	if link == "" {
+		return nil, fmt.Errorf("no image")
+	}
+	endpoint, err := c.resolve(link)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, newError(req, resp)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	if len(data) > maxImageSize {
+		return nil, fmt.Errorf("image larger than %d MB", maxImageSize>>20)
+	}
+	return data, nil
+}
+
+// resolve turns a possibly host-relative link into an absolute URL.
+func (c *Client) resolve(link string) (string, error) {
+	if strings.HasPrefix(link, "http://") || strings.HasPrefix(link, "https://") {
+		return link, nil
+	}
+	base, err := url.Parse(c.baseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL: %w", err)
+	}
+	ref, err := url.Parse(link)
+	if err != nil {
+		return "", fmt.Errorf("invalid link %q: %w", link, err)
+	}
+	return base.ResolveReference(ref).String(), nil
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func fakeBrowseAPI(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/music/album/abbey-road/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{
+			"name": "Abbey Road", "slug": "abbey-road", "image_cropped": "/media/abbey.png",
+			"authors": [{"name": "The Beatles", "slug": "the-beatles"}],
+			"songs": [
+				{"name": "Come Together", "slug": "come-together", "file": "https://cdn/1.mp3", "length": 259,
+				 "authors": [{"name": "The Beatles", "slug": "the-beatles"}]},
+				{"name": "Something", "slug": "something", "file": "https://cdn/2.mp3", "length": 182}
+			]
+		}`)
+	})
+	mux.HandleFunc("/music/author/the-beatles/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{
+			"name": "The Beatles", "slug": "the-beatles",
+			"albums": [{"name": "Abbey Road", "slug": "abbey-road"}, {"name": "Help!", "slug": "help"}],
+			"songs": [{"name": "Help!", "slug": "help", "file": "https://cdn/3.mp3", "length": 138,
+			           "album": {"name": "Help!", "slug": "help"}}]
+		}`)
+	})
+	mux.HandleFunc("/media/abbey.png", func(w http.ResponseWriter, r *http.Request) {
+		img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+		img.Set(1, 1, color.RGBA{R: 255, A: 255})
+		w.Header().Set("Content-Type", "image/png")
+		png.Encode(w, img)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGetAlbum(t *testing.T) {
+	c := testClient(fakeBrowseAPI(t))
+
+	album, err := c.GetAlbum(context.Background(), "abbey-road")
+	if err != nil {
+		t.Fatalf("GetAlbum: %v", err)
+	}
+	if album.Name != "Abbey Road" || len(album.Authors) != 1 || len(album.Songs) != 2 {
+		t.Fatalf("got %+v", album)
+	}
+	// Songs inherit the album they were listed under.
+	for _, s := range album.Songs {
+		if s.Album.Slug != "abbey-road" {
+			t.Errorf("song %q has album slug %q", s.Name, s.Album.Slug)
+		}
+	}
+}
+
+func TestGetAuthor(t *testing.T) {
+	c := testClient(fakeBrowseAPI(t))
+
+	author, err := c.GetAuthor(context.Background(), "the-beatles")
+	if err != nil {
+		t.Fatalf("GetAuthor: %v", err)
+	}
+	if author.Name != "The Beatles" || len(author.Albums) != 2 || len(author.Songs) != 1 {
+		t.Fatalf("got %+v", author)
+	}
+	if s := author.Songs[0]; len(s.Authors) != 1 || s.Authors[0].Slug != "the-beatles" || s.Album.Slug != "help" {
+		t.Errorf("song = %+v", s)
+	}
+}
+
+func TestGetAlbumNotFound(t *testing.T) {
+	c := testClient(fakeBrowseAPI(t))
+
+	if _, err := c.GetAlbum(context.Background(), "nope"); !IsNotFound(err) {
+		t.Errorf("err = %v, want not found", err)
+	}
+	if _, err := c.GetAuthor(context.Background(), ""); err == nil {
+		t.Error("empty slug accepted")
+	}
+}
+
+func TestFetchImageRelative(t *testing.T) {
+	srv := fakeBrowseAPI(t)
+	// The API base has a path; image links are relative to the host.
+	c := NewClientWithConfig(Config{BaseURL: srv.URL + "/api/v1"})
+
+	data, err := c.FetchImage(context.Background(), "/media/abbey.png")
+	if err != nil {
+		t.Fatalf("FetchImage: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if img.Bounds().Dx() != 4 {
+		t.Errorf("image width = %d, want 4", img.Bounds().Dx())
+	}
+
+	if _, err := c.FetchImage(context.Background(), "/media/missing.png"); !IsNotFound(err) {
+		t.Errorf("missing image: err = %v, want not found", err)
+	}
+}
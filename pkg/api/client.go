@@ -1,11 +1,14 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type Song struct {
@@ -38,33 +41,152 @@ type SearchResponse struct {
 	Results  []Song `json:"results"`
 }
 
+// SearchPage is one page of song search results. Next and Previous are the API's
+// links to the neighbouring pages, empty at either end.
+type SearchPage struct {
+	Songs    []Song
+	Count    int
+	Page     int
+	Next     string
+	Previous string
+}
+
+// HasNext reports whether another page follows this one.
+func (p *SearchPage) HasNext() bool {
+	return p.Next != ""
+}
+
 type Client struct {
 	baseURL    string
+	timeout    time.Duration
+	transport  *authTransport
 	httpClient *http.Client
 }
 
+// NewClient creates a client for the public API with default settings.
 func NewClient() *Client {
+	return NewClientWithConfig(DefaultConfig())
+}
+
+// NewClientWithConfig creates a client for the instance described by cfg. Zero fields take their defaults.
+func NewClientWithConfig(cfg Config) *Client {
+	cfg = cfg.withDefaults()
+	t := newAuthTransport(cfg)
 	return &Client{
-		baseURL:    "https://new.akarpov.ru/api/v1",
-		httpClient: &http.Client{},
+		baseURL:    strings.TrimRight(cfg.BaseURL, "/"),
+		timeout:    cfg.Timeout,
+		transport:  t,
+		httpClient: &http.Client{Transport: t, Timeout: cfg.Timeout},
 	}
 }
 
-func (c *Client) SearchSong(query string) ([]Song, error) {
-	endpoint := fmt.Sprintf("%s/music/song/?search=%s", c.baseURL, url.QueryEscape(query))
+// BaseURL returns the API root requests are made against.
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
 
-	resp, err := c.httpClient.Get(endpoint)
+// DownloadClient returns an HTTP client for fetching media. It sends the same user agent,
+// and the same credentials to the API's own host, but has no overall time limit so large
+// files can finish; only the wait for response headers is bounded.
+func (c *Client) DownloadClient() *http.Client {
+	return &http.Client{Transport: c.transport}
+}
+
+// SearchSong returns the first page of songs matching query.
+func (c *Client) SearchSong(ctx context.Context, query string) ([]Song, error) {
+	page, err := c.SearchSongPage(ctx, query, 1)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
+	return page.Songs, nil
+}
+
+// SearchSongPage returns page n (counted from 1) of the songs matching query.
+func (c *Client) SearchSongPage(ctx context.Context, query string, n int) (*SearchPage, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("invalid page %d", n)
+	}
+	params := url.Values{"search": {query}}
+	if n > 1 {
+		params.Set("page", strconv.Itoa(n))
+	}
+	return c.fetchPage(ctx, c.baseURL+"/music/song/?"+params.Encode(), n)
+}
 
+// NextPage follows p's Next link. It fails if p is the last page.
+func (c *Client) NextPage(ctx context.Context, p *SearchPage) (*SearchPage, error) {
+	if !p.HasNext() {
+		return nil, fmt.Errorf("no more results")
+	}
+	return c.fetchPage(ctx, p.Next, pageNumber(p.Next, p.Page+1))
+}
+
+// PrevPage follows p's Previous link. It fails if p is the first page.
+func (c *Client) PrevPage(ctx context.Context, p *SearchPage) (*SearchPage, error) {
+	if p.Previous == "" {
+		return nil, fmt.Errorf("already on the first page")
+	}
+	return c.fetchPage(ctx, p.Previous, pageNumber(p.Previous, p.Page-1))
+}
+
+func (c *Client) fetchPage(ctx context.Context, endpoint string, n int) (*SearchPage, error) {
 	var searchResp SearchResponse
-	if err := json.NewDecoder(resp.Body).Decode(&searchResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := c.getJSON(ctx, endpoint, &searchResp); err != nil {
+		return nil, err
+	}
+	return &SearchPage{
+		Songs:    searchResp.Results,
+		Count:    searchResp.Count,
+		Page:     n,
+		Next:     searchResp.Next,
+		Previous: searchResp.Previous,
+	}, nil
+}
+
+// getJSON performs a GET and decodes a 2xx JSON body into v. Other statuses become an *Error.
+func (c *Client) getJSON(ctx context.Context, endpoint string, v interface{}) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("invalid request: %w", err)
 	}
+	req.Header.Set("Accept", "application/json")
 
-	return searchResp.Results, nil
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newError(req, resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// pageNumber reads the page query parameter of a pagination link. The API leaves it
+// out for the first page.
+func pageNumber(link string, fallback int) int {
+	u, err := url.Parse(link)
+	if err != nil {
+		return fallback
+	}
+	p := u.Query().Get("page")
+	if p == "" {
+		return 1
+	}
+	if n, err := strconv.Atoi(p); err == nil {
+		return n
+	}
+	return fallback
 }
 
 func formatSearchResults(songs []Song) string {
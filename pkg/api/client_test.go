@@ -0,0 +1,255 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// fakeMusicAPI serves /music/song/ with total songs split into pages of pageSize,
+// linking pages the way the real API does (no page parameter for page 1).
+func fakeMusicAPI(t *testing.T, total, pageSize int) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/music/song/" {
+			http.NotFound(w, r)
+			return
+		}
+		query := r.URL.Query().Get("search")
+		page := 1
+		if p := r.URL.Query().Get("page"); p != "" {
+			page, _ = strconv.Atoi(p)
+		}
+		pages := (total + pageSize - 1) / pageSize
+		if page < 1 || page > pages {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"detail":"Invalid page."}`)
+			return
+		}
+
+		link := func(n int) string {
+			if n < 1 || n > pages {
+				return ""
+			}
+			if n == 1 {
+				return fmt.Sprintf("%s/music/song/?search=%s", srv.URL, query)
+			}
+			return fmt.Sprintf("%s/music/song/?page=%d&search=%s", srv.URL, n, query)
+		}
+		resp := SearchResponse{Count: total, Next: link(page + 1), Previous: link(page - 1)}
+		for i := (page - 1) * pageSize; i < total && i < page*pageSize; i++ {
+			resp.Results = append(resp.Results, Song{
+				Name:    fmt.Sprintf("%s %d", query, i+1),
+				File:    fmt.Sprintf("%s/media/%d.mp3", srv.URL, i+1),
+				Length:  180,
+				Authors: []Author{{Name: "Artist"}},
+			})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func testClient(srv *httptest.Server) *Client {
+	return NewClientWithConfig(Config{BaseURL: srv.URL})
+}
+
+func TestSearchSongPageFirstPage(t *testing.T) {
+	c := testClient(fakeMusicAPI(t, 25, 10))
+
+	page, err := c.SearchSongPage(context.Background(), "song", 1)
+	if err != nil {
+		t.Fatalf("SearchSongPage: %v", err)
+	}
+	if page.Count != 25 || page.Page != 1 || len(page.Songs) != 10 {
+		t.Fatalf("got count=%d page=%d songs=%d, want 25/1/10", page.Count, page.Page, len(page.Songs))
+	}
+	if !page.HasNext() || page.Previous != "" {
+		t.Errorf("first page: HasNext=%v Previous=%q", page.HasNext(), page.Previous)
+	}
+	if got := page.Songs[0].Name; got != "song 1" {
+		t.Errorf("first song = %q, want %q", got, "song 1")
+	}
+}
+
+func TestSearchSongPageByNumber(t *testing.T) {
+	c := testClient(fakeMusicAPI(t, 25, 10))
+
+	page, err := c.SearchSongPage(context.Background(), "song", 3)
+	if err != nil {
+		t.Fatalf("SearchSongPage: %v", err)
+	}
+	if page.Page != 3 || len(page.Songs) != 5 || page.HasNext() {
+		t.Errorf("got page=%d songs=%d HasNext=%v, want 3/5/false", page.Page, len(page.Songs), page.HasNext())
+	}
+}
+
+func TestNextAndPrevPage(t *testing.T) {
+	c := testClient(fakeMusicAPI(t, 25, 10))
+	ctx := context.Background()
+
+	page, err := c.SearchSongPage(ctx, "song", 1)
+	if err != nil {
+		t.Fatalf("SearchSongPage: %v", err)
+	}
+	var names []string
+	for {
+		for _, s := range page.Songs {
+			names = append(names, s.Name)
+		}
+		if !page.HasNext() {
+			break
+		}
+		if page, err = c.NextPage(ctx, page); err != nil {
+			t.Fatalf("NextPage: %v", err)
+		}
+	}
+	if len(names) != 25 || names[24] != "song 25" {
+		t.Fatalf("walked %d songs ending in %q, want 25 ending in %q", len(names), names[len(names)-1], "song 25")
+	}
+	if page.Page != 3 {
+		t.Errorf("last page number = %d, want 3", page.Page)
+	}
+	if _, err := c.NextPage(ctx, page); err == nil {
+		t.Error("NextPage past the last page succeeded")
+	}
+
+	prev, err := c.PrevPage(ctx, page)
+	if err != nil {
+		t.Fatalf("PrevPage: %v", err)
+	}
+	if prev.Page != 2 || prev.Songs[0].Name != "song 11" {
+		t.Errorf("PrevPage gave page %d starting %q", prev.Page, prev.Songs[0].Name)
+	}
+}
+
+func TestSearchEscapesQuery(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.Query().Get("search")
+		fmt.Fprint(w, `{"count":0,"results":[]}`)
+	}))
+	defer srv.Close()
+
+	query := "AC/DC & friends?"
+	if _, err := testClient(srv).SearchSong(context.Background(), query); err != nil {
+		t.Fatalf("SearchSong: %v", err)
+	}
+	if got != query {
+		t.Errorf("server saw search=%q, want %q", got, query)
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	c := testClient(fakeMusicAPI(t, 5, 10))
+
+	_, err := c.SearchSongPage(context.Background(), "song", 4)
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v (%T), want *Error", err, err)
+	}
+	if apiErr.StatusCode != http.StatusNotFound || apiErr.Detail != "Invalid page." {
+		t.Errorf("got status %d detail %q", apiErr.StatusCode, apiErr.Detail)
+	}
+	if !IsNotFound(err) || apiErr.Temporary() {
+		t.Errorf("IsNotFound=%v Temporary=%v", IsNotFound(err), apiErr.Temporary())
+	}
+}
+
+func TestErrorStatusNonJSON(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upstream unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	_, err := testClient(srv).SearchSong(context.Background(), "x")
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v, want *Error", err)
+	}
+	if apiErr.StatusCode != http.StatusServiceUnavailable || apiErr.Detail != "upstream unavailable" || !apiErr.Temporary() {
+		t.Errorf("got %+v", apiErr)
+	}
+	if IsNotFound(err) {
+		t.Error("503 reported as not found")
+	}
+}
+
+func TestMalformedJSON(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"results": [`)
+	}))
+	defer srv.Close()
+
+	_, err := testClient(srv).SearchSong(context.Background(), "x")
+	var apiErr *Error
+	if err == nil || errors.As(err, &apiErr) {
+		t.Errorf("err = %v, want a decode error", err)
+	}
+}
+
+func TestContextTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := testClient(srv).SearchSong(ctx, "slow")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("request took %v after the deadline", elapsed)
+	}
+}
+
+func TestContextCancel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	if _, err := testClient(srv).SearchSong(ctx, "x"); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
+
+func TestPageNumber(t *testing.T) {
+	tests := []struct {
+		link string
+		want int
+	}{
+		{"https://example.com/music/song/?page=4&search=x", 4},
+		{"https://example.com/music/song/?search=x", 1},
+		{"https://example.com/music/song/?page=abc", 7},
+	}
+	for _, tt := range tests {
+		if got := pageNumber(tt.link, 7); got != tt.want {
+			t.Errorf("pageNumber(%q) = %d, want %d", tt.link, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"gowav/pkg/utils"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Default connection settings for the public instance.
+const (
+	DefaultBaseURL   = "https://new.akarpov.ru/api/v1"
+	DefaultUserAgent = "gowav"
+	DefaultTimeout   = 10 * time.Second
+)
+
+// Config describes which API instance to talk to and how to authenticate.
+type Config struct {
+	BaseURL string
+	// Token is sent as "Authorization: Bearer <token>". A value that already names its
+	// scheme, such as "Token abc123", is sent as is.
+	Token string
+	// AuthHeader is a complete "Name: value" header for instances that expect something
+	// other than Authorization, e.g. "X-Api-Key: abc123".
+	AuthHeader string
+	UserAgent  string
+	// Timeout bounds each API request, and the wait for response headers on downloads.
+	Timeout time.Duration
+}
+
+// DefaultConfig returns the settings for the public instance without authentication.
+func DefaultConfig() Config {
+	return Config{
+		BaseURL:   DefaultBaseURL,
+		UserAgent: DefaultUserAgent,
+		Timeout:   DefaultTimeout,
+	}
+}
+
+func (c Config) withDefaults() Config {
+	d := DefaultConfig()
+	if c.BaseURL == "" {
+		c.BaseURL = d.BaseURL
+	}
+	if c.UserAgent == "" {
+		c.UserAgent = d.UserAgent
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = d.Timeout
+	}
+	return c
+}
+
+// authTransport adds the user agent to every request and credentials to requests for
+// the API's own host, so tokens are never sent to third-party media hosts.
+type authTransport struct {
+	base      http.RoundTripper
+	host      string
+	userAgent string
+	authName  string
+	authValue string
+}
+
+func newAuthTransport(cfg Config) *authTransport {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.ResponseHeaderTimeout = cfg.Timeout
+	// Media URLs may be SHOUTcast v1 stations.
+	utils.AcceptICY(base)
+
+	t := &authTransport{base: base, userAgent: cfg.UserAgent}
+	if u, err := url.Parse(cfg.BaseURL); err == nil {
+		t.host = strings.ToLower(u.Host)
+	}
+	switch {
+	case cfg.AuthHeader != "":
+		name, value, _ := strings.Cut(cfg.AuthHeader, ":")
+		t.authName, t.authValue = strings.TrimSpace(name), strings.TrimSpace(value)
+	case cfg.Token != "":
+		t.authName, t.authValue = "Authorization", cfg.Token
+		if !strings.Contains(cfg.Token, " ") {
+			t.authValue = "Bearer " + cfg.Token
+		}
+	}
+	return t
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrippers must not modify the caller's request.
+	req = req.Clone(req.Context())
+	if req.Header.Get("User-Agent") == "" && t.userAgent != "" {
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+	if t.authName != "" && t.host != "" && strings.EqualFold(req.URL.Host, t.host) {
+		req.Header.Set(t.authName, t.authValue)
+	}
+	return t.base.RoundTrip(req)
+}
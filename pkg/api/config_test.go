@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// headerEcho replies with the request's Authorization, X-Api-Key and User-Agent headers.
+func headerEcho(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"count":1,"results":[{"name":%q,"file":%q,"slug":%q}]}`,
+			r.Header.Get("Authorization"), r.Header.Get("X-Api-Key"), r.Header.Get("User-Agent"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestAuthToken(t *testing.T) {
+	tests := []struct {
+		token string
+		want  string
+	}{
+		{"abc123", "Bearer abc123"},
+		{"Token abc123", "Token abc123"},
+		{"", ""},
+	}
+	srv := headerEcho(t)
+	for _, tt := range tests {
+		c := NewClientWithConfig(Config{BaseURL: srv.URL, Token: tt.token, UserAgent: "gowav-test"})
+		songs, err := c.SearchSong(context.Background(), "x")
+		if err != nil {
+			t.Fatalf("SearchSong: %v", err)
+		}
+		if got := songs[0].Name; got != tt.want {
+			t.Errorf("token %q: Authorization = %q, want %q", tt.token, got, tt.want)
+		}
+		if got := songs[0].Slug; got != "gowav-test" {
+			t.Errorf("User-Agent = %q, want gowav-test", got)
+		}
+	}
+}
+
+func TestAuthHeader(t *testing.T) {
+	srv := headerEcho(t)
+	c := NewClientWithConfig(Config{BaseURL: srv.URL, AuthHeader: "X-Api-Key: s3cret", Token: "ignored"})
+
+	songs, err := c.SearchSong(context.Background(), "x")
+	if err != nil {
+		t.Fatalf("SearchSong: %v", err)
+	}
+	if songs[0].File != "s3cret" || songs[0].Name != "" {
+		t.Errorf("X-Api-Key = %q, Authorization = %q", songs[0].File, songs[0].Name)
+	}
+	if songs[0].Slug != DefaultUserAgent {
+		t.Errorf("User-Agent = %q, want default %q", songs[0].Slug, DefaultUserAgent)
+	}
+}
+
+func TestDownloadClientCredentialsStayOnAPIHost(t *testing.T) {
+	var apiAuth, otherAuth string
+	apiHost := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiAuth = r.Header.Get("Authorization")
+	}))
+	defer apiHost.Close()
+	otherHost := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		otherAuth = r.Header.Get("Authorization")
+	}))
+	defer otherHost.Close()
+
+	dl := NewClientWithConfig(Config{BaseURL: apiHost.URL + "/api/v1", Token: "abc"}).DownloadClient()
+	for _, u := range []string{apiHost.URL + "/media/song.mp3", otherHost.URL + "/song.mp3"} {
+		resp, err := dl.Get(u)
+		if err != nil {
+			t.Fatalf("GET %s: %v", u, err)
+		}
+		resp.Body.Close()
+	}
+	if apiAuth != "Bearer abc" {
+		t.Errorf("API host got Authorization %q", apiAuth)
+	}
+	if otherAuth != "" {
+		t.Errorf("other host got Authorization %q", otherAuth)
+	}
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Error is returned when the API answers with a non-2xx status.
+type Error struct {
+	StatusCode int
+	Status     string
+	Method     string
+	URL        string
+	// Detail is the API's own explanation, taken from a JSON "detail" field or the raw body.
+	Detail string
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("api: %s %s: %s", e.Method, e.URL, e.Status)
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	return msg
+}
+
+// Temporary reports whether retrying the request later might succeed.
+func (e *Error) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// IsNotFound reports whether err is an API 404, e.g. a page past the last one.
+func IsNotFound(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// maxErrorBody caps how much of an error response is kept as Detail.
+const maxErrorBody = 512
+
+func newError(req *http.Request, resp *http.Response) *Error {
+	e := &Error{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Method:     req.Method,
+		URL:        req.URL.Redacted(),
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	var payload struct {
+		Detail string `json:"detail"`
+	}
+	if json.Unmarshal(body, &payload) == nil && payload.Detail != "" {
+		e.Detail = payload.Detail
+	} else if text := strings.TrimSpace(string(body)); !strings.HasPrefix(text, "<") {
+		// Proxies answer with HTML error pages, which are no use on a terminal.
+		e.Detail = text
+	}
+	return e
+}
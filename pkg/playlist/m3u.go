@@ -0,0 +1,81 @@
+package playlist
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// parseM3U reads plain or extended M3U. #EXTINF lines give the duration and title of
+// the entry that follows; other comments are ignored. Files that are not valid UTF-8
+// are taken to be Windows-1252, the usual encoding of legacy .m3u files.
+func parseM3U(r io.Reader) (*Playlist, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if !utf8.Valid(data) {
+		if data, err = charmap.Windows1252.NewDecoder().Bytes(data); err != nil {
+			return nil, err
+		}
+	}
+
+	pl := &Playlist{}
+	var pending Entry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#EXTINF:"):
+			pending.Duration, pending.Title = parseEXTINF(strings.TrimPrefix(line, "#EXTINF:"))
+		case strings.HasPrefix(line, "#PLAYLIST:"):
+			pl.Title = strings.TrimSpace(strings.TrimPrefix(line, "#PLAYLIST:"))
+		case strings.HasPrefix(line, "#"):
+		default:
+			pending.Location = line
+			pl.Entries = append(pl.Entries, pending)
+			pending = Entry{}
+		}
+	}
+	return pl, scanner.Err()
+}
+
+// parseEXTINF splits "123 tvg-id=\"x\",Artist - Title" into a duration and a title.
+func parseEXTINF(s string) (time.Duration, string) {
+	info, title, _ := strings.Cut(s, ",")
+	// Attributes may follow the duration, separated by spaces.
+	if fields := strings.Fields(info); len(fields) > 0 {
+		info = fields[0]
+	}
+	var d time.Duration
+	if secs, err := strconv.ParseFloat(info, 64); err == nil && secs > 0 {
+		d = time.Duration(secs * float64(time.Second))
+	}
+	return d, strings.TrimSpace(title)
+}
+
+func writeM3U(w io.Writer, pl *Playlist) error {
+	if _, err := fmt.Fprintln(w, "#EXTM3U"); err != nil {
+		return err
+	}
+	if pl.Title != "" {
+		if _, err := fmt.Fprintf(w, "#PLAYLIST:%s\n", pl.Title); err != nil {
+			return err
+		}
+	}
+	for _, e := range pl.Entries {
+		if _, err := fmt.Fprintf(w, "#EXTINF:%d,%s\n%s\n", seconds(e.Duration), e.Title, e.Location); err != nil {
+			return err
+		}
+	}
+	return nil
+}
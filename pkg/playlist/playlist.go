@@ -0,0 +1,193 @@
+// Package playlist reads and writes M3U/M3U8, PLS and XSPF playlists.
+package playlist
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Format identifies a playlist file format.
+type Format int
+
+const (
+	FormatM3U Format = iota
+	FormatPLS
+	FormatXSPF
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatPLS:
+		return "pls"
+	case FormatXSPF:
+		return "xspf"
+	default:
+		return "m3u"
+	}
+}
+
+// Entry is one track of a playlist. Location is an absolute path or an http(s) URL
+// once the playlist has been loaded. A zero Duration means unknown.
+type Entry struct {
+	Location string
+	Title    string
+	Duration time.Duration
+}
+
+// Playlist is an ordered list of entries with an optional title.
+type Playlist struct {
+	Title   string
+	Entries []Entry
+}
+
+// FormatFromPath picks the format from the file extension.
+func FormatFromPath(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".m3u", ".m3u8":
+		return FormatM3U, nil
+	case ".pls":
+		return FormatPLS, nil
+	case ".xspf":
+		return FormatXSPF, nil
+	default:
+		return 0, fmt.Errorf("unsupported playlist format: %s (use .m3u, .m3u8, .pls or .xspf)", path)
+	}
+}
+
+// Load reads the playlist at path, resolving relative entries against its directory.
+func Load(path string) (*Playlist, error) {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(f, format, filepath.Dir(abs))
+}
+
+// Parse reads a playlist in the given format. Relative locations are resolved against
+// base, which may be a directory or an http(s) URL; pass "" to leave them as they are.
+func Parse(r io.Reader, format Format, base string) (*Playlist, error) {
+	var (
+		pl  *Playlist
+		err error
+	)
+	switch format {
+	case FormatM3U:
+		pl, err = parseM3U(r)
+	case FormatPLS:
+		pl, err = parsePLS(r)
+	case FormatXSPF:
+		pl, err = parseXSPF(r)
+	default:
+		return nil, fmt.Errorf("unknown playlist format %d", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", format, err)
+	}
+
+	for i := range pl.Entries {
+		pl.Entries[i].Location = Resolve(base, pl.Entries[i].Location)
+	}
+	return pl, nil
+}
+
+// Save writes the playlist to path in the format given by its extension.
+func Save(path string, pl *Playlist) error {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if err := Write(w, format, pl); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Write serializes the playlist in the given format.
+func Write(w io.Writer, format Format, pl *Playlist) error {
+	switch format {
+	case FormatM3U:
+		return writeM3U(w, pl)
+	case FormatPLS:
+		return writePLS(w, pl)
+	case FormatXSPF:
+		return writeXSPF(w, pl)
+	default:
+		return fmt.Errorf("unknown playlist format %d", format)
+	}
+}
+
+// Resolve turns a playlist location into an absolute path or URL. URLs are kept,
+// file:// URLs become paths, and relative references are joined onto base.
+func Resolve(base, loc string) string {
+	loc = strings.TrimSpace(loc)
+	if loc == "" {
+		return loc
+	}
+
+	if u, err := url.Parse(loc); err == nil && len(u.Scheme) > 1 {
+		switch strings.ToLower(u.Scheme) {
+		case "file":
+			return filepath.FromSlash(u.Path)
+		default:
+			return loc
+		}
+	}
+
+	if base == "" {
+		return loc
+	}
+	// On a server, "/a.mp3" is relative to the host rather than a local path.
+	if isURL(base) {
+		b, err := url.Parse(base)
+		if err != nil {
+			return loc
+		}
+		ref, err := url.Parse(filepath.ToSlash(loc))
+		if err != nil {
+			return loc
+		}
+		return b.ResolveReference(ref).String()
+	}
+	if filepath.IsAbs(loc) {
+		return loc
+	}
+	return filepath.Join(base, filepath.FromSlash(loc))
+}
+
+func isURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
+
+// seconds renders a duration in whole seconds, or -1 when unknown, as M3U and PLS expect.
+func seconds(d time.Duration) int {
+	if d <= 0 {
+		return -1
+	}
+	return int(d.Round(time.Second) / time.Second)
+}
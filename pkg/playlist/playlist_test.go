@@ -0,0 +1,190 @@
+package playlist
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		base   string
+		input  string
+		want   *Playlist
+	}{
+		{
+			name:   "extended m3u",
+			format: FormatM3U,
+			base:   "/music",
+			input: "\xef\xbb\xbf#EXTM3U\n#PLAYLIST:Road Trip\n" +
+				"#EXTINF:259 tvg-id=\"x\" group-title=\"Rock\",The Beatles - Come Together\n" +
+				"beatles/come-together.mp3\n" +
+				"# a comment\n\n" +
+				"#EXTINF:-1,Live\nhttp://radio.example/live\n" +
+				"/abs/untitled.flac\n",
+			want: &Playlist{Title: "Road Trip", Entries: []Entry{
+				{Location: "/music/beatles/come-together.mp3", Title: "The Beatles - Come Together", Duration: 259 * time.Second},
+				{Location: "http://radio.example/live", Title: "Live"},
+				{Location: "/abs/untitled.flac"},
+			}},
+		},
+		{
+			name:   "m3u in windows-1252",
+			format: FormatM3U,
+			input:  "#EXTINF:61.5,Caf\xe9 del Mar \x96 Intro\nintro.mp3\n",
+			want: &Playlist{Entries: []Entry{
+				{Location: "intro.mp3", Title: "Café del Mar – Intro", Duration: 61500 * time.Millisecond},
+			}},
+		},
+		{
+			name:   "m3u from a URL",
+			format: FormatM3U,
+			base:   "http://example.com/lists/all.m3u",
+			input:  "songs/a.mp3\n../b.mp3\nfile:///home/me/c.mp3\n",
+			want: &Playlist{Entries: []Entry{
+				{Location: "http://example.com/lists/songs/a.mp3"},
+				{Location: "http://example.com/b.mp3"},
+				{Location: "/home/me/c.mp3"},
+			}},
+		},
+		{
+			name:   "pls with keys out of order",
+			format: FormatPLS,
+			base:   "/music",
+			input: "; exported\n[Playlist]\nNumberOfEntries=3\n" +
+				"File10=ten.mp3\nTitle2=Two\nfile2=two.mp3\nLength2=120\n" +
+				"Title3=No file\nFile1=http://radio.example/stream\nLength1=-1\nVersion=2\n",
+			want: &Playlist{Entries: []Entry{
+				{Location: "http://radio.example/stream"},
+				{Location: "/music/two.mp3", Title: "Two", Duration: 2 * time.Minute},
+				{Location: "/music/ten.mp3"},
+			}},
+		},
+		{
+			name:   "xspf",
+			format: FormatXSPF,
+			base:   "/music",
+			input: `<?xml version="1.0" encoding="UTF-8"?>
+<playlist version="1">
+  <title>Mix</title>
+  <trackList>
+    <track><location>My%20Song.mp3</location><creator>Artist</creator><title>Song</title><duration>183500</duration></track>
+    <track><title>Missing location</title></track>
+    <track><location>file:///home/me/a%20b.ogg</location><title>Only title</title></track>
+    <track><location>https://cdn.example/x%20y.mp3</location></track>
+  </trackList>
+</playlist>`,
+			want: &Playlist{Title: "Mix", Entries: []Entry{
+				{Location: "/music/My Song.mp3", Title: "Artist - Song", Duration: 183500 * time.Millisecond},
+				{Location: "/home/me/a b.ogg", Title: "Only title"},
+				{Location: "https://cdn.example/x%20y.mp3"},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(strings.NewReader(tt.input), tt.format, tt.base)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got  %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		format Format
+		input  string
+	}{
+		{FormatPLS, "File1=a.mp3\n"},
+		{FormatXSPF, "<playlist><trackList>"},
+	}
+	for _, tt := range tests {
+		if _, err := Parse(strings.NewReader(tt.input), tt.format, ""); err == nil {
+			t.Errorf("%s: parsing %q succeeded", tt.format, tt.input)
+		}
+	}
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	pl := &Playlist{Title: "Favourites", Entries: []Entry{
+		{Location: "/music/Café/01 – Ça va.mp3", Title: "Ça va", Duration: 3*time.Minute + 20*time.Second},
+		{Location: "http://radio.example/live?id=1&q=2", Title: "Live radio"},
+		{Location: "/music/untitled 100%.flac"},
+	}}
+	for _, format := range []Format{FormatM3U, FormatPLS, FormatXSPF} {
+		t.Run(format.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, format, pl); err != nil {
+				t.Fatal(err)
+			}
+			got, err := Parse(&buf, format, "/elsewhere")
+			if err != nil {
+				t.Fatalf("parsing the written playlist: %v\n%s", err, buf.String())
+			}
+			want := *pl
+			if format == FormatPLS {
+				want.Title = "" // PLS has no playlist title
+			}
+			if !reflect.DeepEqual(got, &want) {
+				t.Errorf("got  %+v\nwant %+v", got, &want)
+			}
+		})
+	}
+}
+
+func TestWritePLSNumbersEntries(t *testing.T) {
+	pl := &Playlist{Entries: []Entry{
+		{Location: "/a.mp3", Title: "A", Duration: 1500 * time.Millisecond},
+		{Location: "/b.mp3"},
+	}}
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatPLS, pl); err != nil {
+		t.Fatal(err)
+	}
+	want := "[playlist]\nFile1=/a.mp3\nTitle1=A\nLength1=2\nFile2=/b.mp3\nLength2=-1\nNumberOfEntries=2\nVersion=2\n"
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		base, loc, want string
+	}{
+		{"/music", "a/b.mp3", "/music/a/b.mp3"},
+		{"/music", " /abs/c.mp3 ", "/abs/c.mp3"},
+		{"/music", "file:///home/me/d%20e.mp3", "/home/me/d e.mp3"},
+		{"/music", "https://cdn.example/f.mp3", "https://cdn.example/f.mp3"},
+		{"", "rel.mp3", "rel.mp3"},
+		{"http://example.com/lists/", "g.mp3", "http://example.com/lists/g.mp3"},
+		{"http://example.com/lists/all.pls", "/root.mp3", "http://example.com/root.mp3"},
+		{"/music", "", ""},
+	}
+	for _, tt := range tests {
+		if got := Resolve(tt.base, tt.loc); got != tt.want {
+			t.Errorf("Resolve(%q, %q) = %q, want %q", tt.base, tt.loc, got, tt.want)
+		}
+	}
+}
+
+func TestFormatFromPath(t *testing.T) {
+	tests := map[string]Format{
+		"a.m3u": FormatM3U, "b.M3U8": FormatM3U, "c.pls": FormatPLS, "d.xspf": FormatXSPF,
+	}
+	for path, want := range tests {
+		if got, err := FormatFromPath(path); err != nil || got != want {
+			t.Errorf("FormatFromPath(%q) = %v, %v; want %v", path, got, err, want)
+		}
+	}
+	if _, err := FormatFromPath("e.txt"); err == nil {
+		t.Error("FormatFromPath accepted a .txt file")
+	}
+}
@@ -0,0 +1,103 @@
+package playlist
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parsePLS reads the INI-style PLS format. Entries are numbered File1, Title1, Length1, ...
+// and are returned in number order regardless of how the keys are laid out.
+func parsePLS(r io.Reader) (*Playlist, error) {
+	entries := make(map[int]*Entry)
+	sawHeader := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.EqualFold(line, "[playlist]") {
+			sawHeader = true
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		var field string
+		for _, f := range []string{"file", "title", "length"} {
+			if strings.HasPrefix(key, f) {
+				field = f
+				break
+			}
+		}
+		if field == "" {
+			continue // NumberOfEntries, Version, ...
+		}
+		n, err := strconv.Atoi(key[len(field):])
+		if err != nil {
+			continue
+		}
+		e := entries[n]
+		if e == nil {
+			e = &Entry{}
+			entries[n] = e
+		}
+		switch field {
+		case "file":
+			e.Location = value
+		case "title":
+			e.Title = value
+		case "length":
+			if secs, err := strconv.Atoi(value); err == nil && secs > 0 {
+				e.Duration = time.Duration(secs) * time.Second
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !sawHeader {
+		return nil, fmt.Errorf("missing [playlist] header")
+	}
+
+	nums := make([]int, 0, len(entries))
+	for n := range entries {
+		nums = append(nums, n)
+	}
+	sort.Ints(nums)
+
+	pl := &Playlist{}
+	for _, n := range nums {
+		if entries[n].Location != "" {
+			pl.Entries = append(pl.Entries, *entries[n])
+		}
+	}
+	return pl, nil
+}
+
+func writePLS(w io.Writer, pl *Playlist) error {
+	var sb strings.Builder
+	sb.WriteString("[playlist]\n")
+	for i, e := range pl.Entries {
+		n := i + 1
+		sb.WriteString(fmt.Sprintf("File%d=%s\n", n, e.Location))
+		if e.Title != "" {
+			sb.WriteString(fmt.Sprintf("Title%d=%s\n", n, e.Title))
+		}
+		sb.WriteString(fmt.Sprintf("Length%d=%d\n", n, seconds(e.Duration)))
+	}
+	sb.WriteString(fmt.Sprintf("NumberOfEntries=%d\nVersion=2\n", len(pl.Entries)))
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,89 @@
+package playlist
+
+import (
+	"encoding/xml"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// xspfPlaylist mirrors the parts of the XSPF 1 schema gowav uses.
+// The namespace is written as a plain attribute so documents that omit it still parse.
+type xspfPlaylist struct {
+	XMLName xml.Name    `xml:"playlist"`
+	Xmlns   string      `xml:"xmlns,attr,omitempty"`
+	Version string      `xml:"version,attr"`
+	Title   string      `xml:"title,omitempty"`
+	Tracks  []xspfTrack `xml:"trackList>track"`
+}
+
+type xspfTrack struct {
+	Location []string `xml:"location"`
+	Title    string   `xml:"title,omitempty"`
+	Creator  string   `xml:"creator,omitempty"`
+	Duration int64    `xml:"duration,omitempty"` // milliseconds
+}
+
+func parseXSPF(r io.Reader) (*Playlist, error) {
+	var doc xspfPlaylist
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	pl := &Playlist{Title: doc.Title}
+	for _, t := range doc.Tracks {
+		if len(t.Location) == 0 {
+			continue
+		}
+		title := t.Title
+		if t.Creator != "" && title != "" {
+			title = t.Creator + " - " + title
+		}
+		// Locations are URIs, so relative paths arrive percent-encoded.
+		loc := strings.TrimSpace(t.Location[0])
+		if u, err := url.Parse(loc); err == nil && u.Scheme == "" {
+			loc = u.Path
+		}
+		pl.Entries = append(pl.Entries, Entry{
+			Location: loc,
+			Title:    title,
+			Duration: time.Duration(t.Duration) * time.Millisecond,
+		})
+	}
+	return pl, nil
+}
+
+func writeXSPF(w io.Writer, pl *Playlist) error {
+	doc := xspfPlaylist{Xmlns: "http://xspf.org/ns/0/", Version: "1", Title: pl.Title}
+	for _, e := range pl.Entries {
+		doc.Tracks = append(doc.Tracks, xspfTrack{
+			Location: []string{locationURI(e.Location)},
+			Title:    e.Title,
+			Duration: e.Duration.Milliseconds(),
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// locationURI converts a local path to a file:// URI; URLs pass through unchanged.
+func locationURI(loc string) string {
+	if isURL(loc) {
+		return loc
+	}
+	if abs, err := filepath.Abs(loc); err == nil {
+		loc = abs
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(loc)}).String()
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// FuzzyScore rates how well pattern matches text, case-insensitively. It returns 0 when
+// the pattern's characters do not all appear in text in order. Contiguous substrings
+// outrank scattered matches, and matches at the start of a word score higher.
+func FuzzyScore(pattern, text string) int {
+	p := []rune(strings.ToLower(pattern))
+	t := []rune(strings.ToLower(text))
+	if len(p) == 0 || len(p) > len(t) {
+		return 0
+	}
+
+	if idx := strings.Index(string(t), string(p)); idx >= 0 {
+		score := 50 * len(p)
+		start := len([]rune(string(t)[:idx]))
+		if isWordStart(t, start) {
+			score += 40
+		}
+		if len(p) == len(t) {
+			score += 40
+		}
+		return score
+	}
+
+	score, pi, prev := 0, 0, -2
+	for i, r := range t {
+		if pi == len(p) {
+			break
+		}
+		if r != p[pi] {
+			continue
+		}
+		s := 10
+		if i == prev+1 {
+			s += 15
+		}
+		if isWordStart(t, i) {
+			s += 20
+		}
+		score += s
+		prev = i
+		pi++
+	}
+	if pi < len(p) {
+		return 0
+	}
+	return score
+}
+
+func isWordStart(t []rune, i int) bool {
+	return i == 0 || !unicode.IsLetter(t[i-1]) && !unicode.IsDigit(t[i-1])
+}
@@ -0,0 +1,58 @@
+package utils
+
+import "testing"
+
+func TestFuzzyScoreRanking(t *testing.T) {
+	// Each list runs from the best match of pattern to the worst; every score must be
+	// strictly lower than the one before it.
+	tests := []struct {
+		pattern string
+		texts   []string
+	}{
+		{"love", []string{"Love", "Lovely Day", "Glove Box", "Long Overdue"}},
+		{"help", []string{"HELP", "Help Me", "whelp", "Hey Elephant"}},
+		// A contiguous substring beats the same letters scattered, even at word starts.
+		{"abc", []string{"xabcx", "A B C"}},
+		// Scattered letters score more when adjacent or at the start of a word.
+		{"sgtp", []string{"Sgt Pepper", "sugar top", "misguided tempo"}},
+		{"café", []string{"CAFÉ", "Le Café", "Nescafé"}},
+	}
+	for _, tt := range tests {
+		prev := 0
+		for i, text := range tt.texts {
+			score := FuzzyScore(tt.pattern, text)
+			if score <= 0 {
+				t.Errorf("FuzzyScore(%q, %q) = %d, want a match", tt.pattern, text, score)
+			}
+			if i > 0 && score >= prev {
+				t.Errorf("FuzzyScore(%q, %q) = %d, want less than %d for %q",
+					tt.pattern, text, score, prev, tt.texts[i-1])
+			}
+			prev = score
+		}
+	}
+}
+
+func TestFuzzyScoreNoMatch(t *testing.T) {
+	tests := []struct {
+		pattern, text string
+	}{
+		{"", "anything"},
+		{"abc", ""},
+		{"abcd", "abc"},
+		{"cba", "abc"},
+		{"beatles", "The Beetles"},
+		{"x", "Yesterday"},
+	}
+	for _, tt := range tests {
+		if got := FuzzyScore(tt.pattern, tt.text); got != 0 {
+			t.Errorf("FuzzyScore(%q, %q) = %d, want 0", tt.pattern, tt.text, got)
+		}
+	}
+}
+
+func TestFuzzyScoreIgnoresCase(t *testing.T) {
+	if a, b := FuzzyScore("YESTERDAY", "yesterday"), FuzzyScore("yesterday", "Yesterday"); a != b || a == 0 {
+		t.Errorf("scores %d and %d differ by case", a, b)
+	}
+}
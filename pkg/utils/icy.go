@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+)
+
+// AcceptICY makes t understand SHOUTcast v1 servers, which answer with an "ICY 200 OK"
+// status line that net/http rejects, by reading that line as HTTP/1.0.
+func AcceptICY(t *http.Transport) {
+	dial := t.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	t.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return &icyConn{Conn: conn}, nil
+	}
+}
+
+// icyConn rewrites an "ICY " at the very start of what the server sends. Anything else,
+// including a TLS handshake, passes through untouched.
+type icyConn struct {
+	net.Conn
+	checked bool
+	pending []byte
+}
+
+func (c *icyConn) Read(p []byte) (int, error) {
+	if !c.checked {
+		c.checked = true
+		head := make([]byte, 4)
+		n, err := io.ReadFull(c.Conn, head)
+		if n == 0 {
+			return 0, err
+		}
+		c.pending = head[:n]
+		if string(c.pending) == "ICY " {
+			c.pending = []byte("HTTP/1.0 ")
+		}
+	}
+	if len(c.pending) > 0 {
+		n := copy(p, c.pending)
+		c.pending = c.pending[n:]
+		return n, nil
+	}
+	return c.Conn.Read(p)
+}
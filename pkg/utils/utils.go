@@ -12,6 +12,8 @@ var MusicExtensions = map[string]bool{
 	".flac": true,
 	".m4a":  true,
 	".wav":  true,
+	".aif":  true,
+	".aiff": true,
 	".ogg":  true,
 	".opus": true,
 	".aac":  true,
@@ -25,6 +27,21 @@ var MagicNumbers = map[string][]byte{
 	"wav":  {0x52, 0x49, 0x46, 0x46}, // RIFF
 	"ogg":  {0x4F, 0x67, 0x67, 0x53}, // OggS
 	"m4a":  {0x66, 0x74, 0x79, 0x70}, // ftyp
+	"aiff": {0x46, 0x4F, 0x52, 0x4D}, // FORM
+}
+
+// HasMagic reports whether header starts with the magic number registered for format.
+func HasMagic(header []byte, format string) bool {
+	magic, ok := MagicNumbers[format]
+	if !ok || len(header) < len(magic) {
+		return false
+	}
+	for i, b := range magic {
+		if header[i] != b {
+			return false
+		}
+	}
+	return true
 }
 
 func IsMusicFile(path string) bool {
@@ -50,18 +67,9 @@ func IsMusicFile(path string) bool {
 		return true
 	}
 
-	for _, magic := range MagicNumbers {
-		if len(magic) <= len(header) {
-			matches := true
-			for i, b := range magic {
-				if header[i] != b {
-					matches = false
-					break
-				}
-			}
-			if matches {
-				return true
-			}
+	for format := range MagicNumbers {
+		if HasMagic(header, format) {
+			return true
 		}
 	}
 
@@ -0,0 +1,74 @@
+package viz
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ChannelLayout selects how multichannel audio is split into the tracks a visualization draws.
+type ChannelLayout int
+
+const (
+	// LayoutMono draws a single downmixed track.
+	LayoutMono ChannelLayout = iota
+	// LayoutStereo draws the left and right channels separately.
+	LayoutStereo
+	// LayoutMidSide draws the mid (L+R)/2 and side (L-R)/2 signals.
+	LayoutMidSide
+)
+
+// ParseChannelLayout maps a "viz" argument such as "stereo" or "ms" to a layout.
+func ParseChannelLayout(s string) (ChannelLayout, error) {
+	switch strings.ToLower(s) {
+	case "", "mono":
+		return LayoutMono, nil
+	case "stereo", "lr":
+		return LayoutStereo, nil
+	case "midside", "mid-side", "ms":
+		return LayoutMidSide, nil
+	default:
+		return LayoutMono, fmt.Errorf("unknown channel layout: %s (use mono, stereo or midside)", s)
+	}
+}
+
+func (l ChannelLayout) String() string {
+	switch l {
+	case LayoutStereo:
+		return "stereo"
+	case LayoutMidSide:
+		return "mid/side"
+	default:
+		return "mono"
+	}
+}
+
+// Track is one labelled signal drawn by a multi-track visualization.
+type Track struct {
+	Label string
+	Data  []float64
+}
+
+// SplitChannels builds the tracks for layout from the mono mix and per-channel samples.
+// Audio with fewer than two channels always yields the single mono track.
+func SplitChannels(mono []float64, channels [][]float64, layout ChannelLayout) []Track {
+	if layout == LayoutMono || len(channels) < 2 {
+		return []Track{{Label: "Mono", Data: mono}}
+	}
+
+	left, right := channels[0], channels[1]
+	if layout == LayoutStereo {
+		return []Track{{Label: "L", Data: left}, {Label: "R", Data: right}}
+	}
+
+	n := len(left)
+	if len(right) < n {
+		n = len(right)
+	}
+	mid := make([]float64, n)
+	side := make([]float64, n)
+	for i := 0; i < n; i++ {
+		mid[i] = (left[i] + right[i]) / 2
+		side[i] = (left[i] - right[i]) / 2
+	}
+	return []Track{{Label: "M", Data: mid}, {Label: "S", Data: side}}
+}
@@ -9,27 +9,65 @@ import (
 )
 
 type DensityViz struct {
-	densityData   []float64
-	spectralData  [][]float64
+	tracks        []densityTrack
 	freqBands     []float64
 	sampleRate    int
 	maxDensity    float64
 	totalDuration time.Duration
 }
 
+// densityTrack holds the per-frame spectral energy of one channel or channel mix.
+type densityTrack struct {
+	label        string
+	densityData  []float64
+	spectralData [][]float64
+}
+
 func NewDensityViz(rawData []float64, sampleRate int) *DensityViz {
+	return NewMultiTrackDensityViz([]Track{{Label: "Mono", Data: rawData}}, sampleRate)
+}
+
+// NewMultiTrackDensityViz computes a density map per track; they are drawn stacked on a shared scale.
+func NewMultiTrackDensityViz(tracks []Track, sampleRate int) *DensityViz {
 	// Initialize with window size for FFT
 	windowSize := 2048
 	hopSize := 512
-	numFrames := (len(rawData) - windowSize) / hopSize
 
 	// Create FFT plan
 	fft := fourier.NewFFT(windowSize)
 
+	d := &DensityViz{sampleRate: sampleRate}
+	for _, t := range tracks {
+		dt := computeDensity(fft, t.Data, windowSize, hopSize)
+		dt.label = t.Label
+		for _, energy := range dt.densityData {
+			if energy > d.maxDensity {
+				d.maxDensity = energy
+			}
+		}
+		d.tracks = append(d.tracks, dt)
+	}
+
+	// Create frequency bands
+	nyquist := float64(sampleRate) / 2.0
+	d.freqBands = make([]float64, windowSize/2)
+	for i := range d.freqBands {
+		d.freqBands[i] = float64(i) * nyquist / float64(windowSize/2)
+	}
+
+	return d
+}
+
+// computeDensity runs a windowed FFT over rawData and sums the magnitudes of each frame.
+func computeDensity(fft *fourier.FFT, rawData []float64, windowSize, hopSize int) densityTrack {
+	numFrames := (len(rawData) - windowSize) / hopSize
+	if numFrames < 0 {
+		numFrames = 0
+	}
+
 	// Initialize data structures
 	densityData := make([]float64, numFrames)
 	spectralData := make([][]float64, numFrames)
-	maxDensity := 0.0
 
 	// Process frames
 	window := make([]float64, windowSize)
@@ -59,30 +97,28 @@ func NewDensityViz(rawData []float64, sampleRate int) *DensityViz {
 
 		spectralData[i] = freqBins
 		densityData[i] = energy
-
-		if energy > maxDensity {
-			maxDensity = energy
-		}
 	}
 
-	// Create frequency bands
-	nyquist := float64(sampleRate) / 2.0
-	freqBands := make([]float64, windowSize/2)
-	for i := range freqBands {
-		freqBands[i] = float64(i) * nyquist / float64(windowSize/2)
-	}
+	return densityTrack{densityData: densityData, spectralData: spectralData}
+}
 
-	return &DensityViz{
-		densityData:  densityData,
-		spectralData: spectralData,
-		freqBands:    freqBands,
-		sampleRate:   sampleRate,
-		maxDensity:   maxDensity,
+// frames returns the frame count of the shortest track.
+func (d *DensityViz) frames() int {
+	if len(d.tracks) == 0 {
+		return 0
+	}
+	n := len(d.tracks[0].densityData)
+	for _, t := range d.tracks[1:] {
+		if len(t.densityData) < n {
+			n = len(t.densityData)
+		}
 	}
+	return n
 }
 
 func (d *DensityViz) Render(state ViewState) string {
-	if len(d.densityData) == 0 {
+	numFrames := d.frames()
+	if numFrames == 0 {
 		return "No density data available"
 	}
 
@@ -96,77 +132,92 @@ func (d *DensityViz) Render(state ViewState) string {
 	if height > 40 {
 		height = 40
 	}
+	bandHeight := height / len(d.tracks)
+	if bandHeight < 2 {
+		bandHeight = 2
+	}
 
 	// Calculate view parameters
-	samplesPerCol := int(float64(len(d.densityData)) / float64(state.Width) / state.Zoom)
+	samplesPerCol := int(float64(numFrames) / float64(state.Width) / state.Zoom)
 	if samplesPerCol < 1 {
 		samplesPerCol = 1
 	}
 
-	startFrame := int((state.Offset.Seconds() / d.totalDuration.Seconds()) * float64(len(d.densityData)))
-	startFrame = clamp(startFrame, 0, len(d.densityData)-1)
+	startFrame := 0
+	if d.totalDuration > 0 {
+		startFrame = int((state.Offset.Seconds() / d.totalDuration.Seconds()) * float64(numFrames))
+	}
+	startFrame = clamp(startFrame, 0, numFrames-1)
 
 	// Draw time axis
 	sb.WriteString(d.renderTimeAxis(state, startFrame, samplesPerCol))
 	sb.WriteString("\n")
 
-	// Initialize color scale
-	intensity := make([]float64, state.Width)
+	// Calculate intensity values; all tracks share one scale
+	intensities := make([][]float64, len(d.tracks))
 	maxIntensity := 0.0
+	for t, track := range d.tracks {
+		intensity := make([]float64, state.Width)
+		for x := 0; x < state.Width; x++ {
+			frame := startFrame + x*samplesPerCol
+			if frame >= numFrames {
+				break
+			}
 
-	// Calculate intensity values
-	for x := 0; x < state.Width; x++ {
-		frame := startFrame + x*samplesPerCol
-		if frame >= len(d.densityData) {
-			break
-		}
-
-		// Average over the column
-		sum := 0.0
-		count := 0
-		for i := 0; i < samplesPerCol && frame+i < len(d.densityData); i++ {
-			sum += d.densityData[frame+i]
-			count++
-		}
+			// Average over the column
+			sum := 0.0
+			count := 0
+			for i := 0; i < samplesPerCol && frame+i < numFrames; i++ {
+				sum += track.densityData[frame+i]
+				count++
+			}
 
-		if count > 0 {
-			intensity[x] = sum / float64(count)
-			if intensity[x] > maxIntensity {
-				maxIntensity = intensity[x]
+			if count > 0 {
+				intensity[x] = sum / float64(count)
+				if intensity[x] > maxIntensity {
+					maxIntensity = intensity[x]
+				}
 			}
 		}
+		intensities[t] = intensity
 	}
 
 	// Render density map
 	chars := []string{"·", ":", "▪", "▮", "█"}
+	labelStyle := lipgloss.NewStyle().Foreground(state.ColorScheme.Text)
 
-	for y := 0; y < height; y++ {
-		yRatio := float64(height-y-1) / float64(height-1)
-
-		for x := 0; x < state.Width; x++ {
-			if x >= len(intensity) {
-				sb.WriteString(" ")
-				continue
+	for t, intensity := range intensities {
+		for y := 0; y < bandHeight; y++ {
+			yRatio := 0.5
+			if bandHeight > 1 {
+				yRatio = float64(bandHeight-y-1) / float64(bandHeight-1)
 			}
 
-			normalizedIntensity := 0.0
-			if maxIntensity > 0 {
-				normalizedIntensity = intensity[x] / maxIntensity
+			x := 0
+			if y == 0 && len(d.tracks) > 1 {
+				sb.WriteString(labelStyle.Render(d.tracks[t].label))
+				x = len(d.tracks[t].label)
 			}
-
-			// Add vertical gradient effect
-			gradientIntensity := normalizedIntensity * (1.0 - 0.5*math.Abs(yRatio-0.5))
-
-			// Select character and color
-			charIdx := int(gradientIntensity * float64(len(chars)-1))
-			charIdx = clamp(charIdx, 0, len(chars)-1)
-
-			color := getGradientColor(gradientIntensity, state.ColorScheme)
-			sb.WriteString(lipgloss.NewStyle().
-				Foreground(color).
-				Render(chars[charIdx]))
+			for ; x < state.Width; x++ {
+				normalizedIntensity := 0.0
+				if maxIntensity > 0 {
+					normalizedIntensity = intensity[x] / maxIntensity
+				}
+
+				// Add vertical gradient effect
+				gradientIntensity := normalizedIntensity * (1.0 - 0.5*math.Abs(yRatio-0.5))
+
+				// Select character and color
+				charIdx := int(gradientIntensity * float64(len(chars)-1))
+				charIdx = clamp(charIdx, 0, len(chars)-1)
+
+				color := getGradientColor(gradientIntensity, state.ColorScheme)
+				sb.WriteString(lipgloss.NewStyle().
+					Foreground(color).
+					Render(chars[charIdx]))
+			}
+			sb.WriteString("\n")
 		}
-		sb.WriteString("\n")
 	}
 
 	// Add legend
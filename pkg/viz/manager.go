@@ -19,15 +19,11 @@ func NewManager() *Manager {
 	return &Manager{
 		visualizations: make(map[ViewMode]Visualization),
 		state: ViewState{
-			Mode:   WaveformMode,
-			Zoom:   1.0,
-			Width:  80,
-			Height: 24,
-			ColorScheme: ColorScheme{
-				Primary:   lipgloss.Color("#00ff00"),
-				Secondary: lipgloss.Color("#0088ff"),
-				Text:      lipgloss.Color("#ffffff"),
-			},
+			Mode:        WaveformMode,
+			Zoom:        1.0,
+			Width:       80,
+			Height:      24,
+			ColorScheme: ClassicColorScheme(),
 		},
 	}
 }
@@ -146,6 +142,13 @@ func (m *Manager) SetDimensions(width, height int) {
 	m.state.Height = height
 }
 
+// SetColorScheme changes the colors used by every visualization.
+func (m *Manager) SetColorScheme(scheme ColorScheme) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.state.ColorScheme = scheme
+}
+
 func (m *Manager) AddVisualization(mode ViewMode, viz Visualization) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
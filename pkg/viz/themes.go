@@ -28,8 +28,16 @@ func DefaultColorScheme() ColorScheme {
 	}
 }
 
+// ClassicColorScheme returns the green and blue scheme the visualizations start with
+func ClassicColorScheme() ColorScheme {
+	scheme := DefaultColorScheme()
+	scheme.Secondary = lipgloss.Color("#0088ff")
+	return scheme
+}
+
 // ColorSchemes contains all available color schemes
 var ColorSchemes = map[string]ColorScheme{
+	"classic": ClassicColorScheme(),
 	"default": DefaultColorScheme(),
 	"monokai": {
 		Primary:    lipgloss.Color("#a6e22e"),
@@ -12,35 +12,60 @@ import (
 const waveformMaxHeight = 40
 
 type WaveformViz struct {
-	data          []float64
+	tracks        []Track
 	sampleRate    int
 	maxAmp        float64
 	totalDuration time.Duration
 }
 
 func CreateWaveformViz(data []float64, sampleRate int) Visualization {
+	return CreateMultiTrackWaveformViz([]Track{{Label: "Mono", Data: data}}, sampleRate)
+}
+
+// CreateMultiTrackWaveformViz draws each track in its own band, stacked top to bottom.
+// All bands share one amplitude scale so their levels can be compared.
+func CreateMultiTrackWaveformViz(tracks []Track, sampleRate int) Visualization {
 	// Find peak amplitude
 	maxAmp := 0.0
-	for _, v := range data {
-		a := math.Abs(v)
-		if a > maxAmp {
-			maxAmp = a
+	for _, t := range tracks {
+		for _, v := range t.Data {
+			a := math.Abs(v)
+			if a > maxAmp {
+				maxAmp = a
+			}
 		}
 	}
+	if maxAmp == 0 {
+		maxAmp = 1 // silence: avoid dividing by zero when scaling
+	}
 	return &WaveformViz{
-		data:       data,
+		tracks:     tracks,
 		sampleRate: sampleRate,
 		maxAmp:     maxAmp,
 	}
 }
 
+// samples returns the length of the shortest track.
+func (w *WaveformViz) samples() int {
+	if len(w.tracks) == 0 {
+		return 0
+	}
+	n := len(w.tracks[0].Data)
+	for _, t := range w.tracks[1:] {
+		if len(t.Data) < n {
+			n = len(t.Data)
+		}
+	}
+	return n
+}
+
 func (w *WaveformViz) Render(state ViewState) string {
-	if len(w.data) == 0 {
+	if w.samples() == 0 {
 		return "No data for waveform."
 	}
 
 	// Compute actual audio length from sample count
-	actualDuration := time.Duration(float64(len(w.data)) / float64(w.sampleRate) * float64(time.Second))
+	actualDuration := time.Duration(float64(w.samples()) / float64(w.sampleRate) * float64(time.Second))
 	if w.totalDuration == 0 || w.totalDuration < actualDuration {
 		w.totalDuration = actualDuration
 	}
@@ -64,7 +89,7 @@ func (w *WaveformViz) Render(state ViewState) string {
 	}
 
 	// Number of total samples in track
-	totalSamples := len(w.data)
+	totalSamples := w.samples()
 
 	// 1) Calculate how many samples we can display in the current zoom level
 	//    If zoom = 1.0 => entire track fits in the screen
@@ -115,6 +140,13 @@ func (w *WaveformViz) Render(state ViewState) string {
 	sb.WriteString(w.renderTimeAxis(state, offsetSamples, displayedSamples, spc))
 	sb.WriteString("\n")
 
+	// Split the height into one band per track
+	bandHeight := availHeight / len(w.tracks)
+	if bandHeight < 3 {
+		bandHeight = 3
+	}
+	availHeight = bandHeight * len(w.tracks)
+
 	// Prepare a 2D text buffer
 	display := make([][]string, availHeight)
 	for i := 0; i < availHeight; i++ {
@@ -124,63 +156,19 @@ func (w *WaveformViz) Render(state ViewState) string {
 		}
 	}
 
-	centerY := availHeight / 2
-	style := lipgloss.NewStyle().Foreground(state.ColorScheme.Primary)
-
-	// For each column in terminal
-	for x := 0; x < availWidth; x++ {
-		// colStart is the first sample for this column
-		colStart := int(float64(offsetSamples) + float64(x)*spc)
-		if colStart >= totalSamples {
-			break
-		}
-		colEnd := int(float64(colStart) + spc)
-		if colEnd > totalSamples {
-			colEnd = totalSamples
-		}
-		if colEnd <= colStart {
-			continue
-		}
-
-		// find min & max in that slice
-		minVal := 0.0
-		maxVal := 0.0
-		first := true
-		for i := colStart; i < colEnd; i++ {
-			val := w.data[i]
-			if first {
-				minVal = val
-				maxVal = val
-				first = false
-			} else {
-				if val < minVal {
-					minVal = val
+	for i, t := range w.tracks {
+		w.drawBand(display, t.Data, i*bandHeight, bandHeight, offsetSamples, totalSamples, spc)
+		if len(w.tracks) > 1 {
+			for j, r := range t.Label {
+				if j < availWidth {
+					display[i*bandHeight][j] = string(r)
 				}
-				if val > maxVal {
-					maxVal = val
-				}
-			}
-		}
-
-		// scale to vertical
-		half := availHeight / 2
-		minPix := int((minVal / w.maxAmp) * float64(half-1))
-		maxPix := int((maxVal / w.maxAmp) * float64(half-1))
-
-		pixLow := clamp(centerY+minPix, 0, availHeight-1)
-		pixHigh := clamp(centerY+maxPix, 0, availHeight-1)
-
-		for y := pixLow; y <= pixHigh; y++ {
-			if y == centerY {
-				display[y][x] = "─"
-			} else if y == pixLow || y == pixHigh {
-				display[y][x] = "█"
-			} else {
-				display[y][x] = "│"
 			}
 		}
 	}
 
+	style := lipgloss.NewStyle().Foreground(state.ColorScheme.Primary)
+
 	// Write out the buffer
 	for y := 0; y < availHeight; y++ {
 		for x := 0; x < availWidth; x++ {
@@ -279,9 +267,61 @@ func (w *WaveformViz) renderTimeAxis(state ViewState, offsetSamples int, display
 	return sb.String()
 }
 
+// drawBand plots the min/max envelope of data into rows [top, top+height) of display.
+func (w *WaveformViz) drawBand(display [][]string, data []float64, top, height, offsetSamples, totalSamples int, spc float64) {
+	centerY := top + height/2
+	half := height / 2
+
+	// For each column in terminal
+	for x := 0; x < len(display[top]); x++ {
+		// colStart is the first sample for this column
+		colStart := int(float64(offsetSamples) + float64(x)*spc)
+		if colStart >= totalSamples {
+			break
+		}
+		colEnd := int(float64(colStart) + spc)
+		if colEnd > totalSamples {
+			colEnd = totalSamples
+		}
+		if colEnd <= colStart {
+			continue
+		}
+
+		// find min & max in that slice
+		minVal := data[colStart]
+		maxVal := data[colStart]
+		for i := colStart + 1; i < colEnd; i++ {
+			val := data[i]
+			if val < minVal {
+				minVal = val
+			}
+			if val > maxVal {
+				maxVal = val
+			}
+		}
+
+		// scale to vertical
+		minPix := int((minVal / w.maxAmp) * float64(half-1))
+		maxPix := int((maxVal / w.maxAmp) * float64(half-1))
+
+		pixLow := clamp(centerY+minPix, top, top+height-1)
+		pixHigh := clamp(centerY+maxPix, top, top+height-1)
+
+		for y := pixLow; y <= pixHigh; y++ {
+			if y == centerY {
+				display[y][x] = "─"
+			} else if y == pixLow || y == pixHigh {
+				display[y][x] = "█"
+			} else {
+				display[y][x] = "│"
+			}
+		}
+	}
+}
+
 func (w *WaveformViz) SetTotalDuration(duration time.Duration) {
 	// Compare with actual wave-based duration
-	actualDuration := time.Duration(float64(len(w.data)) / float64(w.sampleRate) * float64(time.Second))
+	actualDuration := time.Duration(float64(w.samples()) / float64(w.sampleRate) * float64(time.Second))
 	if actualDuration > duration {
 		w.totalDuration = actualDuration
 	} else {